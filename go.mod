@@ -2,13 +2,15 @@ module github.com/xaaha/hulak
 
 go 1.24
 
-require github.com/goccy/go-yaml v1.12.0
+require (
+	github.com/goccy/go-yaml v1.12.0
+	golang.org/x/net v0.32.0
+)
 
 require (
 	github.com/fatih/color v1.17.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	golang.org/x/net v0.32.0 // indirect
 	golang.org/x/sys v0.28.0 // indirect
 	golang.org/x/xerrors v0.0.0-20240903120638-7835f813f4da // indirect
 )
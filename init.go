@@ -4,15 +4,19 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math"
+	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
 	"time"
 
 	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
 	"github.com/xaaha/hulak/pkg/envparser"
 	"github.com/xaaha/hulak/pkg/features"
+	"github.com/xaaha/hulak/pkg/report"
 	"github.com/xaaha/hulak/pkg/utils"
 	"github.com/xaaha/hulak/pkg/yamlparser"
 )
@@ -33,8 +37,53 @@ func InitializeProject(env string) map[string]any {
 	return envMap
 }
 
-// runTasks manages the go tasks with a limited worker pool
-func runTasks(filePathList []string, secretsMap map[string]any, debug bool, fp string) {
+// runOptions are the flags that apply to every file in the run
+type runOptions struct {
+	debug bool
+	// failNon2xx fails the files with non-2xx responses, unless they assert the status
+	failNon2xx bool
+	// dataFile is the dataset from -data flag. It takes precedence over the data key in the files
+	dataFile string
+	// report is the format of the report saved after the run. No report is saved when it's empty
+	report report.Format
+	// reportOut is the path of the report, hulak_report with the extension of the format by default
+	reportOut string
+}
+
+// task is a file along with the secrets it runs with
+type task struct {
+	path       string
+	secretsMap map[string]any
+}
+
+// taskResult is the outcome of running a task
+type taskResult struct {
+	path     string
+	captured map[string]any
+	// responses of every request the file made, for the report
+	responses []apicalls.CustomResponse
+	// status code of the response. It's 0 when the file makes none or more than one request
+	status   int
+	duration time.Duration
+	retries  int
+	skipped  bool
+	err      error
+}
+
+// runTasks runs the files in the graph with a limited worker pool.
+// Files without pending dependencies run concurrently, and a file runs only after all of its dependencies succeed.
+// Files that depend on a failed file are skipped and added to failed along with the failed files.
+// Each file sees the secretsMap with the values captured by its dependencies on top,
+// and every captured value is added to runVars for the files that run later.
+// Returns the result of each file, including the skipped ones
+func runTasks(
+	graph *apicalls.DependencyGraph,
+	secretsMap map[string]any,
+	runVars *envparser.RunVars,
+	failed map[string]bool,
+	opts runOptions,
+	fp string,
+) []taskResult {
 	// Configuration parameters
 	maxWorkers := calculateOptimalWorkerCount() // Dynamically determine worker count
 	maxRetries := 3                             // Number of retries for failed tasks
@@ -47,14 +96,9 @@ func runTasks(filePathList []string, secretsMap map[string]any, debug bool, fp s
 
 	var wg sync.WaitGroup
 
-	taskChan := make(chan string, len(filePathList)) // Buffered channel for tasks
-
-	// Fill the task channel with file paths
-	for _, path := range filePathList {
-		taskChan <- path
-	}
-
-	close(taskChan)
+	total := len(graph.Order)
+	taskChan := make(chan task, total)         // Buffered channel for tasks
+	resultChan := make(chan taskResult, total) // Buffered channel for results
 
 	// Create a pool of worker goroutines
 	for i := range maxWorkers {
@@ -63,66 +107,192 @@ func runTasks(filePathList []string, secretsMap map[string]any, debug bool, fp s
 		go func(_ int) {
 			defer wg.Done()
 
-			for path := range taskChan {
-				// Process each task with retry logic
-				success := false
-
-				var lastErr error
-
-				for attempt := 0; attempt < maxRetries && !success; attempt++ {
-					if attempt > 0 {
-						// Exponential backoff for retries
-						backoffDuration := time.Duration(1<<uint(attempt-1)) * time.Second
-						utils.PrintWarning(fmt.Sprintf("Retrying %s (attempt %d/%d) after %v",
-							path, attempt+1, maxRetries, backoffDuration))
-						time.Sleep(backoffDuration)
-					}
-
-					// Create a context with timeout
-					ctx, cancel := context.WithTimeout(context.Background(), timeout)
-
-					// Use a channel to handle the task completion
-					doneChan := make(chan struct{})
-					errChan := make(chan error, 1)
-
-					// Execute the task in a separate goroutine
-					go func() {
-						err := processTask(path, utils.CopyEnvMap(secretsMap), debug)
-						if err != nil {
-							errChan <- err
-						} else {
-							close(doneChan)
-						}
-					}()
-
-					// Wait for either completion, error, or timeout
-					select {
-					case <-doneChan:
-						success = true
-
-						utils.PrintInfo(fmt.Sprintf("Processed '%s'", filepath.Base(path)))
-					case err := <-errChan:
-						lastErr = err
-
-						utils.PrintInfo(fmt.Sprintf("(attempt %d/%d)", attempt+1, maxRetries))
-					case <-ctx.Done():
-						lastErr = fmt.Errorf("timeout after %v", timeout)
-						utils.PrintRed(fmt.Sprintf("Timeout processing %s after %v (attempt %d/%d)",
-							path, timeout, attempt+1, maxRetries))
-					}
-					// Always cancel the context created with timeout
-					cancel()
-				}
+			for t := range taskChan {
+				resultChan <- runWithRetry(t, opts, maxRetries, taskTimeout(t, timeout))
+			}
+		}(i)
+	}
+
+	// number of dependencies each file is waiting for
+	pending := make(map[string]int, total)
+	for _, path := range graph.Order {
+		for _, dependent := range graph.Dependents(path) {
+			pending[dependent]++
+		}
+	}
 
-				if !success {
-					utils.PrintRed(fmt.Sprintf("Failed to process %s after %d attempts: %v",
-						path, maxRetries, lastErr))
+	captures := make(map[string]map[string]any)
+	skipped := make(map[string]bool)
+
+	dispatch := func(path string) {
+		fileEnv := utils.CopyEnvMap(secretsMap)
+		for _, ancestor := range graph.Ancestors(path) {
+			maps.Copy(fileEnv, captures[ancestor])
+		}
+
+		taskChan <- task{path: path, secretsMap: fileEnv}
+	}
+
+	for _, path := range graph.Order {
+		if pending[path] == 0 {
+			dispatch(path)
+		}
+	}
+
+	results := make([]taskResult, 0, total)
+
+	for len(results) < total {
+		result := <-resultChan
+		results = append(results, result)
+
+		if result.err != nil {
+			failed[result.path] = true
+
+			utils.PrintRed(fmt.Sprintf("Failed to process %s: %v", result.path, result.err))
+
+			for _, descendant := range graph.Descendants(result.path) {
+				if !skipped[descendant] {
+					skipped[descendant] = true
+					failed[descendant] = true
+
+					results = append(results, skippedResult(descendant, result.path))
 				}
 			}
-		}(i)
+
+			continue
+		}
+
+		runVars.SetAll(result.captured)
+		captures[result.path] = result.captured
+
+		for _, dependent := range graph.Dependents(result.path) {
+			pending[dependent]--
+			if pending[dependent] == 0 && !skipped[dependent] {
+				dispatch(dependent)
+			}
+		}
 	}
+
+	close(taskChan)
 	// Wait for all workers to finish
 	wg.Wait()
+
+	// results come in the order files finish, keep the order of the graph for the summary
+	position := make(map[string]int, total)
+	for i, path := range graph.Order {
+		position[path] = i
+	}
+
+	slices.SortFunc(results, func(a, b taskResult) int {
+		return position[a.path] - position[b.path]
+	})
+
+	return results
+}
+
+// skippedResult is the result of a file that didn't run because its dependency failed
+func skippedResult(path, failedDep string) taskResult {
+	utils.PrintWarning(fmt.Sprintf("Skipping '%s' because '%s' failed",
+		filepath.Base(path), filepath.Base(failedDep)))
+
+	return taskResult{
+		path:    path,
+		skipped: true,
+		err:     fmt.Errorf("skipped because '%s' failed", filepath.Base(failedDep)),
+	}
+}
+
+// Auth files wait for the user to log in, in the browser or on another device,
+// and the grants stop on their own timeouts, like the expiry of the device code
+const authTaskTimeout = 15 * time.Minute
+
+// taskTimeout returns the timeout for each attempt of the task
+func taskTimeout(t task, timeout time.Duration) time.Duration {
+	config, err := yamlparser.ParseConfig(t.path, t.secretsMap)
+	if err == nil && config.IsAuth() {
+		return authTaskTimeout
+	}
+
+	return timeout
+}
+
+// runWithRetry runs the task with retry logic and a timeout for each attempt.
+// Returns the result of the last attempt, along with the number of retries
+func runWithRetry(
+	t task,
+	opts runOptions,
+	maxRetries int,
+	timeout time.Duration,
+) taskResult {
+	lastResult := taskResult{path: t.path}
+
+	for attempt := range maxRetries {
+		if attempt > 0 {
+			// Exponential backoff for retries
+			backoffDuration := time.Duration(1<<uint(attempt-1)) * time.Second
+			utils.PrintWarning(fmt.Sprintf("Retrying %s (attempt %d/%d) after %v",
+				t.path, attempt+1, maxRetries, backoffDuration))
+			time.Sleep(backoffDuration)
+		}
+
+		// Create a context with timeout
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+
+		// Use a channel to handle the task completion
+		doneChan := make(chan taskResult, 1)
+
+		start := time.Now()
+
+		// Execute the task in a separate goroutine
+		go func() {
+			doneChan <- processTask(t.path, utils.CopyEnvMap(t.secretsMap), opts)
+		}()
+
+		// Wait for either completion, error, or timeout
+		select {
+		case result := <-doneChan:
+			// Always cancel the context created with timeout
+			cancel()
+
+			result.duration = time.Since(start)
+			result.retries = attempt
+
+			if result.err == nil {
+				utils.PrintInfo(fmt.Sprintf("Processed '%s'", filepath.Base(t.path)))
+
+				return result
+			}
+
+			// the request went through, retrying won't change the result.
+			// Values captured by a failed attempt are not passed to the other files
+			if apicalls.IsResponseError(result.err) {
+				result.captured = nil
+
+				return result
+			}
+
+			lastResult = result
+
+			utils.PrintInfo(fmt.Sprintf("(attempt %d/%d)", attempt+1, maxRetries))
+		case <-ctx.Done():
+			cancel()
+
+			lastResult = taskResult{
+				path:     t.path,
+				duration: time.Since(start),
+				retries:  attempt,
+				err:      fmt.Errorf("timeout after %v", timeout),
+			}
+
+			utils.PrintRed(fmt.Sprintf("Timeout processing %s after %v (attempt %d/%d)",
+				t.path, timeout, attempt+1, maxRetries))
+		}
+	}
+
+	// values captured by a failed attempt are not passed to the other files
+	lastResult.captured = nil
+
+	return lastResult
 }
 
 // calculateOptimalWorkerCount determines the optimal number of workers based on system resources
@@ -137,44 +307,93 @@ func calculateOptimalWorkerCount() int {
 	)
 }
 
-// processTask handles a single task, separated to simplify the worker logic
-func processTask(path string, secretsMap map[string]any, debug bool) error {
+// processTask handles a single task, separated to simplify the worker logic.
+// Returns the result with the values captured from the response
+func processTask(path string, secretsMap map[string]any, opts runOptions) taskResult {
+	result := taskResult{path: path}
+
 	// Parse the configuration for the file
 	config, err := yamlparser.ParseConfig(path, secretsMap)
 	if err != nil {
-		return fmt.Errorf("failed to parse config: %w", err)
+		result.err = fmt.Errorf("failed to parse config: %w", err)
+
+		return result
 	}
 
+	var runResult apicalls.RunResult
+
 	// Handle different kinds based on the yaml 'kind' we get
 	switch {
 	case config.IsAuth():
-		return features.SendAPIRequestForAuth2(secretsMap, path, debug)
+		runResult, result.err = features.SendAPIRequestForAuth2(secretsMap, path, opts.debug)
 	case config.IsAPI():
-		return apicalls.SendAndSaveAPIRequest(secretsMap, path, debug)
+		rows, err := dataRows(path, opts.dataFile)
+		if err != nil {
+			result.err = err
+
+			return result
+		}
+
+		if rows != nil {
+			runResult, result.err = apicalls.SendAndSaveForEachRow(
+				secretsMap, features.Token, path, rows, opts.debug, opts.failNon2xx,
+			)
+		} else {
+			runResult, result.err = apicalls.SendAndSaveAPIRequest(
+				secretsMap, features.Token, path, opts.debug, opts.failNon2xx,
+			)
+		}
+	case config.IsWorkflow():
+		runResult, result.err = features.RunWorkflow(secretsMap, path, opts.debug, opts.failNon2xx)
 	default:
-		return fmt.Errorf("unsupported kind in file: %s", path)
+		result.err = fmt.Errorf("unsupported kind in file: %s", path)
 	}
+
+	result.captured = runResult.Captured
+	result.responses = runResult.Responses
+
+	if len(result.responses) == 1 {
+		result.status = result.responses[0].Exchange.StatusCode
+	}
+
+	return result
+}
+
+// dataRows returns the rows of the dataset from -data flag, or from the data key in the file.
+// Returns nil when there is no dataset, and the file runs once
+func dataRows(path, dataFile string) ([]map[string]any, error) {
+	if dataFile != "" {
+		return envparser.LoadDataset(dataFile)
+	}
+
+	return yamlparser.DataRows(path)
 }
 
 /*
  things we could optiize for but is probably too much here
  Rate limiting an API.
  Priority Queues: For mixed task types with different priorities
- Result Collection: Add a results channel to collect success/failure statistics.
  Graceful Shutdown: Add signal handling to cancel in-progress tasks if the program is terminated.
 */
 
 // HandleAPIRequests processes API requests, and runs taks from individual files and directories
 // It also includes Auth2.0 call
-// Handling both concurrent (-dir) and sequential (-dirseq) processing
+// Handling both concurrent (-dir) and sequential (-dirseq) processing.
+// Prints a summary of the run, saves the report when asked for,
+// and returns an error when any of the files fail or are skipped
 func HandleAPIRequests(
 	secretsMap map[string]any,
-	debug bool,
 	filePathList []string,
 	dir, dirseq, fp string,
-) {
+	opts runOptions,
+) error {
 	var allFiles []string
 
+	var results []taskResult
+
+	// values captured from responses, available to the files that run later
+	runVars := envparser.NewRunVars()
+
 	var sequentialFiles []string
 
 	// Add existing file paths to the concurrent processing list
@@ -201,40 +420,137 @@ func HandleAPIRequests(
 		}
 	}
 
+	// depends_on decides the order of the files and may add files that are not in the lists
+	concurrentGraph, err := apicalls.NewDependencyGraph(allFiles)
+	if err != nil {
+		return err
+	}
+
+	// sequential files run after the concurrent ones, so concurrent files are already done
+	sequentialGraph, err := apicalls.NewDependencyGraph(sequentialFiles, concurrentGraph.Order...)
+	if err != nil {
+		return err
+	}
+
+	// files that failed or were skipped, so that their dependents are skipped
+	failed := make(map[string]bool)
+
 	// Process concurrent files if any
-	if len(allFiles) > 0 {
-		if dir != "" || dirseq != "" {
-			utils.PrintInfo(fmt.Sprintf("Processing %d files concurrently...", len(allFiles)))
+	if len(concurrentGraph.Order) > 0 {
+		if dir != "" || dirseq != "" || concurrentGraph.HasDependencies() {
+			utils.PrintInfo(
+				fmt.Sprintf("Processing %d files concurrently...", len(concurrentGraph.Order)),
+			)
 		}
 
-		runTasks(allFiles, secretsMap, debug, fp)
+		results = append(results, runTasks(concurrentGraph, secretsMap, runVars, failed, opts, fp)...)
 	}
 
 	// Process sequential files one by one
-	if len(sequentialFiles) > 0 {
-		utils.PrintInfo(fmt.Sprintf("Processing %d files sequentially...", len(sequentialFiles)))
-		processFilesSequentially(sequentialFiles, secretsMap, debug)
+	if len(sequentialGraph.Order) > 0 {
+		utils.PrintInfo(
+			fmt.Sprintf("Processing %d files sequentially...", len(sequentialGraph.Order)),
+		)
+
+		results = append(
+			results,
+			processFilesSequentially(sequentialGraph, secretsMap, runVars, failed, opts)...,
+		)
 	}
 
-	totalFiles := len(allFiles) + len(sequentialFiles)
+	totalFiles := len(concurrentGraph.Order) + len(sequentialGraph.Order)
 	if totalFiles < 0 {
 		utils.PrintWarning(
 			"No files were processed. Please check your path or directory arguments.",
 		)
 	}
+
+	if len(results) == 0 {
+		return nil
+	}
+
+	printRunSummary(os.Stdout, results)
+
+	if opts.report != "" {
+		reportPath, err := report.Write(
+			opts.report,
+			opts.reportOut,
+			reportResults(results),
+			runVars.Merge(secretsMap),
+		)
+		if err != nil {
+			return err
+		}
+
+		utils.PrintInfo(fmt.Sprintf("Report saved to '%s'", reportPath))
+	}
+
+	if failedFiles, skippedFiles := countFailed(results); failedFiles+skippedFiles > 0 {
+		return fmt.Errorf(
+			"%d of %d files failed and %d skipped",
+			failedFiles,
+			totalFiles,
+			skippedFiles,
+		)
+	}
+
+	return nil
 }
 
-// processFilesSequentially handles files one by one in a sequential manner
-func processFilesSequentially(filePaths []string, secretsMap map[string]any, debug bool) {
-	for _, path := range filePaths {
-		// Create a fresh copy of the environment for each file
-		fileEnv := utils.CopyEnvMap(secretsMap)
+// processFilesSequentially handles files one by one in a sequential manner, in the order of the graph.
+// Values captured by a file are available to the files after it.
+// Files that depend on a failed file are skipped.
+// Returns the result of each file, including the skipped ones
+func processFilesSequentially(
+	graph *apicalls.DependencyGraph,
+	secretsMap map[string]any,
+	runVars *envparser.RunVars,
+	failed map[string]bool,
+	opts runOptions,
+) []taskResult {
+	results := make([]taskResult, 0, len(graph.Order))
+
+	for _, path := range graph.Order {
+		if failedDep := firstFailed(graph.Dependencies(path), failed); failedDep != "" {
+			failed[path] = true
+
+			results = append(results, skippedResult(path, failedDep))
+
+			continue
+		}
+
+		// Create a fresh copy of the environment for each file, with captured values on top
+		fileEnv := runVars.Merge(secretsMap)
+
+		start := time.Now()
+		result := processTask(path, fileEnv, opts)
+		result.duration = time.Since(start)
+
+		results = append(results, result)
 
-		err := processTask(path, fileEnv, debug)
 		utils.PrintInfo(fmt.Sprintf("Processed: '%s'", filepath.Base(path)))
 
-		if err != nil {
-			utils.PrintRed(fmt.Sprintf("Error processing %s: %v", path, err))
+		if result.err != nil {
+			failed[path] = true
+
+			utils.PrintRed(fmt.Sprintf("Error processing %s: %v", path, result.err))
+
+			continue
+		}
+
+		runVars.SetAll(result.captured)
+	}
+
+	return results
+}
+
+// firstFailed returns the first file in paths that failed
+func firstFailed(paths []string, failed map[string]bool) string {
+	for _, path := range paths {
+		if failed[path] {
+			return path
 		}
 	}
+
+	return ""
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+)
+
+func TestRunWithRetryCaptureError(t *testing.T) {
+	var hits atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "create_user.yaml")
+	content := `method: POST
+url: "{{.baseUrl}}/users"
+capture:
+  - name: token
+    path: token
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := runWithRetry(
+		task{path: path, secretsMap: map[string]any{"baseUrl": server.URL}},
+		runOptions{},
+		3,
+		5*time.Second,
+	)
+
+	if !apicalls.IsResponseError(result.err) {
+		t.Fatalf("expected a capture error, got %v", result.err)
+	}
+
+	if hits.Load() != 1 {
+		t.Errorf("expected the request to be sent once, got %d", hits.Load())
+	}
+
+	if result.retries != 0 || result.captured != nil {
+		t.Errorf("expected no retries and no captured values, got %d retries and %v", result.retries, result.captured)
+	}
+}
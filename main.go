@@ -4,6 +4,7 @@ package main
 import (
 	"fmt"
 
+	"github.com/xaaha/hulak/pkg/report"
 	userflags "github.com/xaaha/hulak/pkg/userFlags"
 	"github.com/xaaha/hulak/pkg/utils"
 )
@@ -19,9 +20,20 @@ func main() {
 	env := flags.Env
 	fp := flags.FilePath
 	fileName := flags.File
-	debug := flags.Debug
 	dir := flags.Dir
 	dirseq := flags.Dirseq
+	opts := runOptions{
+		debug:      flags.Debug,
+		failNon2xx: flags.FailNon2xx,
+		dataFile:   flags.Data,
+		report:     report.Format(flags.Report),
+		reportOut:  flags.ReportOut,
+	}
+
+	// fail before making any request, rather than after the run
+	if opts.report != "" && !opts.report.IsValid() {
+		utils.PanicRedAndExit("unsupported report format '%s'. Use junit, tap, json, html or har", opts.report)
+	}
 
 	// Initialize project environment
 	envMap := InitializeProject(env)
@@ -46,7 +58,9 @@ func main() {
 	}
 
 	if hasFileFlags || hasDirFlags {
-		HandleAPIRequests(envMap, debug, filePathList, dir, dirseq, fp)
+		if err := HandleAPIRequests(envMap, filePathList, dir, dirseq, fp, opts); err != nil {
+			utils.PanicRedAndExit("%v", err)
+		}
 	} else {
 		utils.PrintWarning("No file or directory specified. Use -file, -fp, -dir, or -dirseq flags.")
 	}
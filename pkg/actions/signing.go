@@ -0,0 +1,185 @@
+package actions
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtLifetime is the exp of the signed JWT, when the claims don't have one
+const jwtLifetime = 5 * time.Minute
+
+// Digest is the result of hmacSHA256 and sha256. It's printed as lowercase hex,
+// and base64 encodes the bytes of the digest, not the hex
+type Digest []byte
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d)
+}
+
+// toBytes is the content of a string, or the bytes of a Digest
+func toBytes(value any) []byte {
+	switch v := value.(type) {
+	case Digest:
+		return v
+	case []byte:
+		return v
+	case string:
+		return []byte(v)
+	default:
+		return fmt.Append(nil, v)
+	}
+}
+
+// HmacSHA256 is the HMAC-SHA256 of the message with the key
+func HmacSHA256(key, message any) Digest {
+	mac := hmac.New(sha256.New, toBytes(key))
+	mac.Write(toBytes(message))
+
+	return mac.Sum(nil)
+}
+
+// SHA256 is the SHA-256 hash of the message
+func SHA256(message any) Digest {
+	sum := sha256.Sum256(toBytes(message))
+
+	return sum[:]
+}
+
+// Base64 encodes the string, or the bytes of a Digest, with standard base64
+func Base64(value any) string {
+	return base64.StdEncoding.EncodeToString(toBytes(value))
+}
+
+// JWTSign signs the claims, a json object, with the key in keyFile.
+// HS256 uses the content of the file as the secret, RS256 and ES256 use a PEM private key.
+// iat is set to now when it's missing, and exp five minutes later
+func JWTSign(alg, keyFile, claims string) (string, error) {
+	return signJWT(alg, keyFile, claims, time.Now())
+}
+
+func signJWT(alg, keyFile, claims string, now time.Time) (string, error) {
+	var claimsMap map[string]any
+	if err := json.Unmarshal([]byte(claims), &claimsMap); err != nil {
+		return "", fmt.Errorf("jwtSign claims should be a json object: %w", err)
+	}
+
+	if _, ok := claimsMap["iat"]; !ok {
+		claimsMap["iat"] = now.Unix()
+	}
+
+	if _, ok := claimsMap["exp"]; !ok {
+		claimsMap["exp"] = now.Add(jwtLifetime).Unix()
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claimsMap)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := GetFile(keyFile)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	signature, err := signJWS(alg, key, []byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// signJWS signs the input with the algorithm. RFC 7518
+func signJWS(alg, key string, input []byte) ([]byte, error) {
+	digest := sha256.Sum256(input)
+
+	switch alg {
+	case "HS256":
+		// secret files usually end with a new line, which is not part of the secret
+		secret := strings.TrimRight(key, "\r\n")
+		if secret == "" {
+			return nil, errors.New("HS256 secret is empty")
+		}
+
+		return HmacSHA256(secret, input), nil
+	case "RS256":
+		privateKey, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		rsaKey, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("RS256 needs an RSA private key")
+		}
+
+		return rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+	case "ES256":
+		privateKey, err := parsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		ecKey, ok := privateKey.(*ecdsa.PrivateKey)
+		if !ok || ecKey.Curve.Params().BitSize != 256 {
+			return nil, errors.New("ES256 needs a P-256 EC private key")
+		}
+
+		r, s, err := ecdsa.Sign(rand.Reader, ecKey, digest[:])
+		if err != nil {
+			return nil, err
+		}
+
+		// JWS signature is r and s, 32 bytes each
+		signature := make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+
+		return signature, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwtSign algorithm '%s'. Use HS256, RS256 or ES256", alg)
+	}
+}
+
+// parsePrivateKey parses PKCS #8, PKCS #1 RSA and SEC 1 EC private keys in PEM
+func parsePrivateKey(content string) (any, error) {
+	block, _ := pem.Decode([]byte(content))
+	if block == nil {
+		return nil, errors.New("key file should have a PEM private key")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("could not parse the '%s' private key", block.Type)
+}
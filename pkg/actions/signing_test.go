@@ -0,0 +1,164 @@
+package actions
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) {
+	t.Helper()
+
+	content := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := os.WriteFile(name, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJWTSign(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.WriteFile("secret.txt", []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecDER, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, "ec.pem", "PRIVATE KEY", ecDER)
+
+	now := time.Unix(1700000000, 0)
+
+	verify := map[string]func(input, signature []byte) bool{
+		"HS256": func(input, signature []byte) bool {
+			mac := hmac.New(sha256.New, []byte("s3cret"))
+			mac.Write(input)
+
+			return hmac.Equal(mac.Sum(nil), signature)
+		},
+		"RS256": func(input, signature []byte) bool {
+			digest := sha256.Sum256(input)
+
+			return rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature) == nil
+		},
+		"ES256": func(input, signature []byte) bool {
+			digest := sha256.Sum256(input)
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+
+			return len(signature) == 64 && ecdsa.Verify(&ecKey.PublicKey, digest[:], r, s)
+		},
+	}
+
+	keyFiles := map[string]string{"HS256": "secret.txt", "RS256": "rsa.pem", "ES256": "ec.pem"}
+
+	for alg, keyFile := range keyFiles {
+		t.Run(alg, func(t *testing.T) {
+			token, err := signJWT(alg, keyFile, `{"sub": "hulak"}`, now)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			parts := strings.Split(token, ".")
+			if len(parts) != 3 {
+				t.Fatalf("expected 3 parts, got %s", token)
+			}
+
+			signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !verify[alg]([]byte(parts[0]+"."+parts[1]), signature) {
+				t.Errorf("signature of %s is invalid", token)
+			}
+
+			var header, claims map[string]any
+
+			headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+			claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+
+			if err := json.Unmarshal(headerJSON, &header); err != nil || header["alg"] != alg {
+				t.Errorf("expected alg %s in the header, got %s", alg, headerJSON)
+			}
+
+			if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+				t.Fatal(err)
+			}
+
+			if claims["sub"] != "hulak" || claims["iat"] != float64(1700000000) || claims["exp"] != float64(1700000300) {
+				t.Errorf("unexpected claims %s", claimsJSON)
+			}
+		})
+	}
+}
+
+func TestJWTSignErrors(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writePEM(t, "ec.pem", "EC PRIVATE KEY", ecDER)
+
+	if err := os.WriteFile("empty.txt", []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		alg         string
+		keyFile     string
+		claims      string
+		expectedErr string
+	}{
+		{"claims are not an object", "ES256", "ec.pem", `["sub"]`, "should be a json object"},
+		{"unsupported algorithm", "none", "ec.pem", `{}`, "unsupported jwtSign algorithm 'none'"},
+		{"RS256 with an EC key", "RS256", "ec.pem", `{}`, "RS256 needs an RSA private key"},
+		{"empty secret", "HS256", "empty.txt", `{}`, "HS256 secret is empty"},
+		{"missing key file", "HS256", "missing.txt", `{}`, "does not exist"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := JWTSign(tc.alg, tc.keyFile, tc.claims)
+			if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error with %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -4,96 +4,226 @@ package apicalls
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"path/filepath"
 	"time"
 
+	"github.com/xaaha/hulak/pkg/envparser"
 	"github.com/xaaha/hulak/pkg/utils"
 	"github.com/xaaha/hulak/pkg/yamlparser"
 )
 
 // StandardCall calls the api and returns the json body string
 func StandardCall(apiInfo yamlparser.ApiInfo, debug bool) (CustomResponse, error) {
-	if apiInfo.Headers == nil {
-		apiInfo.Headers = map[string]string{}
+	req, bodyBytes, err := newRequest(apiInfo)
+	if err != nil {
+		return CustomResponse{}, err
 	}
 
-	method := apiInfo.Method
-	urlStr := apiInfo.Url
-	bodyReader := apiInfo.Body
+	reqBodyForDebug := make([]byte, len(bodyBytes))
+	copy(reqBodyForDebug, bodyBytes)
 
-	var bodyBytes []byte
+	client, err := NewHTTPClient(apiInfo.Client)
+	if err != nil {
+		return CustomResponse{}, err
+	}
+
+	start := time.Now()
 
-	var err error
-	if bodyReader != nil {
-		bodyBytes, err = io.ReadAll(bodyReader)
+	response, err := client.Do(req)
+	if err != nil {
+		return CustomResponse{}, err
+	}
+
+	// digest credentials are sent only after the server asks for them
+	if apiInfo.Digest != nil && response.StatusCode == http.StatusUnauthorized {
+		req, response, err = retryWithDigest(client, req, response, bodyBytes, apiInfo.Digest)
 		if err != nil {
 			return CustomResponse{}, err
 		}
-	} else {
-		bodyBytes = []byte{}
 	}
 
-	newBodyReader := bytes.NewReader(bodyBytes)
-	headers := apiInfo.Headers
-	urlParams := map[string]string{}
-	preparedURL := PrepareURL(urlStr, urlParams)
+	end := time.Now()
 
-	reqBodyForDebug := make([]byte, len(bodyBytes))
-	copy(reqBodyForDebug, bodyBytes)
+	duration := end.Sub(start)
 
-	req, err := http.NewRequest(method, preparedURL, newBodyReader)
-	if err != nil {
-		return CustomResponse{}, fmt.Errorf("error occurred on '%s': %v", method, err)
+	customResp := processResponse(req, response, duration, debug, reqBodyForDebug)
+	if customResp.Exchange != nil {
+		customResp.Exchange.Started = start
 	}
 
-	if len(headers) > 0 {
-		for key, val := range headers {
-			req.Header.Add(key, val)
-		}
+	if debug && response.TLS != nil && customResp.HTTPInfo != nil {
+		customResp.HTTPInfo.ClientCertInfo = clientCertInfo(client)
 	}
 
-	client := &http.Client{}
+	return customResp, nil
+}
 
-	start := time.Now()
+// newRequest builds the request of the apiInfo with its url params and headers,
+// then signs it when the file asks for it. Returns the request along with its body
+func newRequest(apiInfo yamlparser.ApiInfo) (*http.Request, []byte, error) {
+	bodyBytes := []byte{}
 
-	response, err := client.Do(req)
+	if apiInfo.Body != nil {
+		var err error
+
+		bodyBytes, err = io.ReadAll(apiInfo.Body)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	method := apiInfo.Method
+	preparedURL := AppendURLParams(apiInfo.Url, apiInfo.UrlParams, apiInfo.UrlParamsFormat)
+
+	req, err := http.NewRequest(method, preparedURL, bytes.NewReader(bodyBytes))
 	if err != nil {
-		return CustomResponse{}, err
+		return nil, nil, fmt.Errorf("error occurred on '%s': %v", method, err)
 	}
 
-	end := time.Now()
+	for key, val := range apiInfo.Headers {
+		req.Header.Add(key, val)
+	}
 
-	duration := end.Sub(start)
+	if apiInfo.Sign != nil {
+		if err := signRequest(req, bodyBytes, apiInfo.Sign, time.Now()); err != nil {
+			return nil, nil, err
+		}
+	}
 
-	return processResponse(req, response, duration, debug, reqBodyForDebug), nil
+	// signature covers the headers and the body, so the request is signed once it's complete
+	if apiInfo.SigV4 != nil {
+		signSigV4(req, bodyBytes, apiInfo.SigV4, time.Now())
+	}
+
+	return req, bodyBytes, nil
 }
 
 // SendAndSaveAPIRequest calls the PrepareStruct using the provided envMap
-// and makes the Api Call with StandardCall and prints the response in console
-func SendAndSaveAPIRequest(secretsMap map[string]any, path string, debug bool) error {
+// and makes the Api Call with StandardCall and prints the response in console.
+// Returns the response and the values captured from it
+func SendAndSaveAPIRequest(
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+	path string,
+	debug, failNon2xx bool,
+) (RunResult, error) {
 	apiConfig, _, err := yamlparser.FinalStructForAPI(
 		path,
 		secretsMap,
+		token,
 	)
 	if err != nil {
-		return err
+		return RunResult{}, err
+	}
+
+	resp, captured, err := RunAPICall(apiConfig, secretsMap, token, path, debug, failNon2xx)
+
+	return RunResult{Responses: ResponsesOf(resp), Captured: captured}, err
+}
+
+// ResponsesOf returns the response in a list, or an empty list when the request was never made
+func ResponsesOf(resp CustomResponse) []CustomResponse {
+	if resp.Exchange == nil {
+		return nil
 	}
 
+	return []CustomResponse{resp}
+}
+
+// RunAPICall makes the api call for apiConfig, then prints and saves the response next to the path.
+// Values are captured, assertions are run and the captured values are saved to env, if the file asks for them.
+// With failNon2xx, a response with non-2xx status fails, unless the file asserts the status.
+// Returns the response and the captured values
+func RunAPICall(
+	apiConfig yamlparser.ApiCallFile,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+	path string,
+	debug, failNon2xx bool,
+) (CustomResponse, map[string]any, error) {
 	apiInfo, err := apiConfig.PrepareStruct()
 	if err != nil {
-		return err
+		return CustomResponse{}, nil, err
+	}
+
+	projectConfig, err := yamlparser.FinalStructForProject(secretsMap, token)
+	if err != nil {
+		return CustomResponse{}, nil, err
+	}
+
+	// client section in the file takes precedence over the project's client section
+	apiInfo.Client = projectConfig.Client.Merge(apiInfo.Client)
+
+	if apiInfo.Sign != nil {
+		apiInfo.Sign.Secrets = secretsMap
 	}
 
 	resp, err := StandardCall(apiInfo, debug)
 	if err != nil {
-		return err
+		return CustomResponse{}, nil, err
 	}
 
 	PrintAndSaveFinalResp(resp, path)
 
-	return nil
+	// values of a partial capture are not saved to env, or passed to other files
+	captured, err := CaptureValues(apiConfig.Capture, resp.Exchange)
+	if err != nil {
+		return resp, nil, &CaptureError{Path: path, Err: err}
+	}
+
+	if apiConfig.Assert.HasAssertions() {
+		results := RunAssertions(apiConfig.Assert, resp.Exchange)
+		PrintAssertionResults(path, results)
+
+		resp.Assertions = results
+
+		// values from a response that failed assertions are not saved to env, or passed to other files
+		if AssertionsFailed(results) {
+			return resp, nil, &AssertionError{Path: path, Results: results}
+		}
+	}
+
+	// status assertion decides the expected status, when the file has one
+	if failNon2xx && (apiConfig.Assert == nil || len(apiConfig.Assert.Status) == 0) &&
+		resp.Exchange != nil && !is2xx(resp.Exchange.StatusCode) {
+		return resp, nil, &StatusError{Path: path, StatusCode: resp.Exchange.StatusCode}
+	}
+
+	if err := SaveCapturedToEnv(apiConfig.SaveToEnv, captured); err != nil {
+		return resp, captured, &CaptureError{Path: path, Err: err}
+	}
+
+	return resp, captured, nil
+}
+
+// StatusError is returned for a response with non-2xx status, when the run fails on non-2xx responses
+type StatusError struct {
+	Path       string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("'%s' responded with status %d", filepath.Base(e.Path), e.StatusCode)
+}
+
+// IsResponseError is true when the request went through, but the response failed the checks,
+// or its values couldn't be captured. Retrying the file won't change the result of these errors
+func IsResponseError(err error) bool {
+	var assertErr *AssertionError
+
+	var statusErr *StatusError
+
+	var captureErr *CaptureError
+
+	return errors.As(err, &assertErr) || errors.As(err, &statusErr) || errors.As(err, &captureErr)
+}
+
+func is2xx(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
 }
 
 // PrintAndSaveFinalResp prints and saves the CustomResponse
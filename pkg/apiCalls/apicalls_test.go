@@ -0,0 +1,116 @@
+package apicalls
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestRunAPICallFailOnNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "get_user.yaml")
+
+	tests := []struct {
+		name       string
+		failNon2xx bool
+		assert     *yamlparser.Assert
+		expectErr  bool
+	}{
+		{name: "non-2xx passes by default"},
+		{name: "non-2xx fails when enabled", failNon2xx: true, expectErr: true},
+		{
+			name:       "status assertion decides the expected status",
+			failNon2xx: true,
+			assert:     &yamlparser.Assert{Status: yamlparser.StatusAssertion{"404"}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			apiConfig := yamlparser.ApiCallFile{
+				Method:  yamlparser.GET,
+				URL:     yamlparser.URL(server.URL),
+				Assert:  tc.assert,
+				Capture: yamlparser.Captures{{Name: "status", Status: true}},
+			}
+
+			_, captured, err := RunAPICall(apiConfig, map[string]any{}, nil, path, false, tc.failNon2xx)
+
+			var statusErr *StatusError
+			if tc.expectErr != errors.As(err, &statusErr) {
+				t.Fatalf("expected status error %v, got %v", tc.expectErr, err)
+			}
+
+			if tc.expectErr && !IsResponseError(err) {
+				t.Errorf("expected status error to be a response error")
+			}
+
+			if tc.expectErr && captured != nil {
+				t.Errorf("expected no captured values from a failed response, got %v", captured)
+			}
+
+			if !tc.expectErr && captured["status"] == nil {
+				t.Errorf("expected the status to be captured, got %v", captured)
+			}
+		})
+	}
+}
+
+func TestRunAPICallPartialCapture(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"token": "t0k3n"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	envPath := filepath.Join(dir, utils.EnvironmentFolder, utils.DefaultEnvVal+utils.DefaultEnvFileSuffix)
+	if err := os.MkdirAll(filepath.Dir(envPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "token=old\n"
+	if err := os.WriteFile(envPath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	apiConfig := yamlparser.ApiCallFile{
+		Method: yamlparser.GET,
+		URL:    yamlparser.URL(server.URL),
+		Capture: yamlparser.Captures{
+			{Name: "token", Path: "token"},
+			{Name: "userId", Path: "user.id"},
+		},
+		SaveToEnv: yamlparser.SaveToEnv{"token", "userId"},
+	}
+
+	_, captured, err := RunAPICall(apiConfig, map[string]any{}, nil, filepath.Join(dir, "login.yaml"), false, false)
+	if err == nil {
+		t.Fatal("expected an error for the missing capture path")
+	}
+
+	if captured != nil {
+		t.Errorf("expected no captured values from a partial capture, got %v", captured)
+	}
+
+	got, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(got) != content {
+		t.Errorf("expected the env file to be unchanged, got:\n%s", got)
+	}
+}
@@ -0,0 +1,266 @@
+// Package apicalls has all things related to api call
+package apicalls
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// AssertionResult is the outcome of a single assertion
+type AssertionResult struct {
+	Name    string `json:"name"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// AssertionError is returned when at least one assertion of a file fails
+type AssertionError struct {
+	Path    string
+	Results []AssertionResult
+}
+
+func (e *AssertionError) Error() string {
+	failed := 0
+
+	for _, result := range e.Results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	return fmt.Sprintf(
+		"%d of %d assertions failed in '%s'",
+		failed,
+		len(e.Results),
+		filepath.Base(e.Path),
+	)
+}
+
+// RunAssertions runs all the assertions in the assert section against the exchange
+func RunAssertions(assert *yamlparser.Assert, exchange *Exchange) []AssertionResult {
+	var results []AssertionResult
+
+	if assert == nil || exchange == nil {
+		return results
+	}
+
+	if len(assert.Status) > 0 {
+		result := AssertionResult{
+			Name:   "status " + strings.Join(assert.Status, " or "),
+			Passed: assert.Status.Matches(exchange.StatusCode),
+		}
+		if !result.Passed {
+			result.Message = fmt.Sprintf("got %d", exchange.StatusCode)
+		}
+
+		results = append(results, result)
+	}
+
+	if assert.MaxDuration != nil {
+		maxDuration := time.Duration(*assert.MaxDuration)
+		result := AssertionResult{
+			Name:   "duration under " + maxDuration.String(),
+			Passed: exchange.Duration <= maxDuration,
+		}
+
+		if !result.Passed {
+			result.Message = "took " + exchange.Duration.String()
+		}
+
+		results = append(results, result)
+	}
+
+	for _, header := range assert.Headers {
+		values := exchange.ResponseHeaders.Values(header.Name)
+		results = append(results, matchValue(
+			"header "+header.Name,
+			header.Matcher,
+			strings.Join(values, ", "),
+			len(values) > 0,
+		))
+	}
+
+	for _, body := range assert.Body {
+		value, err := lookupBody(body.Path, exchange.Body)
+		results = append(results, matchValue("body "+body.Path, body.Matcher, value, err == nil))
+	}
+
+	rawBody := string(exchange.ResponseBody)
+	for _, str := range assert.BodyContains {
+		result := AssertionResult{
+			Name:   fmt.Sprintf("body contains '%s'", str),
+			Passed: strings.Contains(rawBody, str),
+		}
+
+		if !result.Passed {
+			result.Message = "not found in the response body"
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// PrintAssertionResults prints the result of each assertion
+func PrintAssertionResults(path string, results []AssertionResult) {
+	utils.PrintInfo(fmt.Sprintf("Assertions for '%s':", filepath.Base(path)))
+
+	for _, result := range results {
+		if result.Passed {
+			utils.PrintGreen(fmt.Sprintf("  %s %s", utils.CheckMark, result.Name))
+		} else {
+			utils.PrintRed(fmt.Sprintf("  %s %s: %s", utils.CrossMark, result.Name, result.Message))
+		}
+	}
+}
+
+// AssertionsFailed returns true if any of the assertion failed
+func AssertionsFailed(results []AssertionResult) bool {
+	for _, result := range results {
+		if !result.Passed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lookupBody finds the value at the path in the JSON response body
+func lookupBody(path string, body any) (any, error) {
+	switch content := body.(type) {
+	case map[string]any:
+		return utils.LookupRawValue(path, content)
+	case []any:
+		// empty key for root level array, like getValueOf
+		return utils.LookupRawValue(path, map[string]any{"": content})
+	default:
+		return nil, utils.ColorError("response body is not JSON")
+	}
+}
+
+// matchValue runs all the checks in the matcher against the actual value
+func matchValue(name string, matcher yamlparser.Matcher, actual any, exists bool) AssertionResult {
+	result := AssertionResult{Name: name, Passed: true}
+
+	fail := func(msg string) AssertionResult {
+		result.Passed = false
+		result.Message = msg
+
+		return result
+	}
+
+	if matcher.Exists != nil {
+		if *matcher.Exists != exists {
+			if exists {
+				return fail("expected not to exist")
+			}
+
+			return fail("expected to exist")
+		}
+
+		if !exists {
+			return result
+		}
+	}
+
+	if !exists {
+		return fail("not found")
+	}
+
+	actualStr := comparableString(actual)
+
+	if matcher.Type != "" && !matchesType(matcher.Type, actual) {
+		return fail(fmt.Sprintf("expected type %s, got %s", matcher.Type, jsonType(actual)))
+	}
+
+	// 'equals: null' passes only for JSON null, not for the string "null"
+	if matcher.HasEquals() &&
+		(comparableString(matcher.Equals) != actualStr || matcher.Equals == nil && actual != nil) {
+		return fail(fmt.Sprintf("expected '%s', got '%s'", comparableString(matcher.Equals), actualStr))
+	}
+
+	if matcher.Contains != "" && !strings.Contains(actualStr, matcher.Contains) {
+		return fail(fmt.Sprintf("expected to contain '%s', got '%s'", matcher.Contains, actualStr))
+	}
+
+	if matcher.Matches != "" {
+		re, err := regexp.Compile(matcher.Matches)
+		if err != nil {
+			return fail(err.Error())
+		}
+
+		if !re.MatchString(actualStr) {
+			return fail(fmt.Sprintf("expected to match '%s', got '%s'", matcher.Matches, actualStr))
+		}
+	}
+
+	return result
+}
+
+// comparableString converts the value to string so that values from yaml and JSON could be compared.
+// Objects and arrays are compared as JSON string
+func comparableString(val any) string {
+	switch typed := val.(type) {
+	case nil:
+		return utils.JSONNull
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool, int, int64, uint64:
+		return fmt.Sprint(typed)
+	default:
+		jsonStr, err := json.Marshal(typed)
+		if err != nil {
+			return fmt.Sprint(typed)
+		}
+
+		return string(jsonStr)
+	}
+}
+
+// jsonType returns the JSON type of the value decoded with encoding/json
+func jsonType(val any) string {
+	switch typed := val.(type) {
+	case nil:
+		return utils.JSONNull
+	case string:
+		return utils.JSONString
+	case bool:
+		return utils.JSONBool
+	case float64:
+		if typed == math.Trunc(typed) {
+			return utils.JSONInt
+		}
+
+		return utils.JSONNumber
+	case []any:
+		return utils.JSONArray
+	case map[string]any:
+		return utils.JSONObject
+	default:
+		return fmt.Sprintf("%T", val)
+	}
+}
+
+// matchesType checks the value is of expected type. int is also a number
+func matchesType(expected string, val any) bool {
+	expected = strings.ToLower(expected)
+	actual := jsonType(val)
+
+	if expected == utils.JSONNumber && actual == utils.JSONInt {
+		return true
+	}
+
+	return expected == actual
+}
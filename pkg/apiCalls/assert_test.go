@@ -0,0 +1,215 @@
+package apicalls
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func testExchange() *Exchange {
+	body := `{"data":{"users":[{"name":"xaaha","age":30,"score":9.5,"admin":false,"manager":null}]}}`
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json; charset=utf-8")
+
+	return &Exchange{
+		StatusCode:      200,
+		ResponseHeaders: headers,
+		ResponseBody:    []byte(body),
+		Body: map[string]any{
+			"data": map[string]any{
+				"users": []any{
+					map[string]any{
+						"name":    "xaaha",
+						"age":     float64(30),
+						"score":   9.5,
+						"admin":   false,
+						"manager": nil,
+					},
+				},
+			},
+		},
+		Duration: 120 * time.Millisecond,
+	}
+}
+
+func TestRunAssertions(t *testing.T) {
+	yes := true
+	no := false
+	fast := yamlparser.Duration(100 * time.Millisecond)
+	slow := yamlparser.Duration(time.Second)
+
+	tests := []struct {
+		name   string
+		assert yamlparser.Assert
+		passed bool
+	}{
+		{name: "status", assert: yamlparser.Assert{Status: yamlparser.StatusAssertion{"2xx"}}, passed: true},
+		{name: "wrong status", assert: yamlparser.Assert{Status: yamlparser.StatusAssertion{"201"}}, passed: false},
+		{name: "under max duration", assert: yamlparser.Assert{MaxDuration: &slow}, passed: true},
+		{name: "over max duration", assert: yamlparser.Assert{MaxDuration: &fast}, passed: false},
+		{
+			name: "header contains",
+			assert: yamlparser.Assert{Headers: []yamlparser.HeaderAssertion{
+				{Name: "content-type", Matcher: yamlparser.Matcher{Contains: "application/json"}},
+			}},
+			passed: true,
+		},
+		{
+			name: "header regex",
+			assert: yamlparser.Assert{Headers: []yamlparser.HeaderAssertion{
+				{Name: "Content-Type", Matcher: yamlparser.Matcher{Matches: "^text/"}},
+			}},
+			passed: false,
+		},
+		{
+			name: "missing header",
+			assert: yamlparser.Assert{Headers: []yamlparser.HeaderAssertion{
+				{Name: "X-Request-Id", Matcher: yamlparser.Matcher{Equals: "abc"}},
+			}},
+			passed: false,
+		},
+		{
+			name: "header does not exist",
+			assert: yamlparser.Assert{Headers: []yamlparser.HeaderAssertion{
+				{Name: "X-Request-Id", Matcher: yamlparser.Matcher{Exists: &no}},
+			}},
+			passed: true,
+		},
+		{
+			name: "body equals",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.users[0].name", Matcher: yamlparser.Matcher{Equals: "xaaha"}},
+				{Path: "data.users[0].age", Matcher: yamlparser.Matcher{Equals: uint64(30)}},
+				{Path: "data.users[0].score", Matcher: yamlparser.Matcher{Equals: 9.5}},
+				{Path: "data.users[0].admin", Matcher: yamlparser.Matcher{Equals: false}},
+			}},
+			passed: true,
+		},
+		{
+			name: "body not equals",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.users[0].name", Matcher: yamlparser.Matcher{Equals: "someone"}},
+			}},
+			passed: false,
+		},
+		{
+			name: "body types",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.users", Matcher: yamlparser.Matcher{Type: "array"}},
+				{Path: "data", Matcher: yamlparser.Matcher{Type: "object"}},
+				{Path: "data.users[0].age", Matcher: yamlparser.Matcher{Type: "int"}},
+				{Path: "data.users[0].age", Matcher: yamlparser.Matcher{Type: "number"}},
+				{Path: "data.users[0].score", Matcher: yamlparser.Matcher{Type: "number"}},
+				{Path: "data.users[0].admin", Matcher: yamlparser.Matcher{Type: "bool"}},
+				{Path: "data.users[0].manager", Matcher: yamlparser.Matcher{Type: "null"}},
+			}},
+			passed: true,
+		},
+		{
+			name: "body wrong type",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.users[0].score", Matcher: yamlparser.Matcher{Type: "int"}},
+			}},
+			passed: false,
+		},
+		{
+			name: "body path exists",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.users[0].name", Matcher: yamlparser.Matcher{Exists: &yes}},
+				{Path: "data.users[1]", Matcher: yamlparser.Matcher{Exists: &no}},
+			}},
+			passed: true,
+		},
+		{
+			name: "body path missing",
+			assert: yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+				{Path: "data.token", Matcher: yamlparser.Matcher{Type: "string"}},
+			}},
+			passed: false,
+		},
+		{name: "body contains", assert: yamlparser.Assert{BodyContains: []string{`"name":"xaaha"`}}, passed: true},
+		{name: "body does not contain", assert: yamlparser.Assert{BodyContains: []string{"secret"}}, passed: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := RunAssertions(&tc.assert, testExchange())
+			if len(results) == 0 {
+				t.Fatalf("expected assertion results")
+			}
+
+			if failed := AssertionsFailed(results); failed == tc.passed {
+				t.Errorf("expected passed to be %v, got results %+v", tc.passed, results)
+			}
+		})
+	}
+}
+
+func TestRunAssertionsEqualsNull(t *testing.T) {
+	exchange := &Exchange{
+		StatusCode:   200,
+		ResponseBody: []byte(`{"manager":null,"name":"xaaha","note":"null"}`),
+		Body:         map[string]any{"manager": nil, "name": "xaaha", "note": "null"},
+	}
+
+	tests := []struct {
+		path   string
+		passed bool
+	}{
+		{"manager", true},
+		{"name", false},
+		{"note", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			var assert yamlparser.Assert
+			if err := yaml.Unmarshal([]byte("body:\n  - path: "+tc.path+"\n    equals: null\n"), &assert); err != nil {
+				t.Fatal(err)
+			}
+
+			if valid, err := assert.IsValid(); !valid {
+				t.Fatalf("expected equals null to be valid, got %v", err)
+			}
+
+			if failed := AssertionsFailed(RunAssertions(&assert, exchange)); failed == tc.passed {
+				t.Errorf("expected passed to be %v for %s", tc.passed, tc.path)
+			}
+		})
+	}
+}
+
+func TestRunAssertionsRootArray(t *testing.T) {
+	exchange := &Exchange{
+		StatusCode:   200,
+		ResponseBody: []byte(`[{"id":1}]`),
+		Body:         []any{map[string]any{"id": float64(1)}},
+	}
+
+	assert := &yamlparser.Assert{Body: []yamlparser.BodyAssertion{
+		{Path: "[0].id", Matcher: yamlparser.Matcher{Equals: uint64(1)}},
+	}}
+
+	results := RunAssertions(assert, exchange)
+	if AssertionsFailed(results) {
+		t.Errorf("expected assertions to pass, got %+v", results)
+	}
+}
+
+func TestAssertionError(t *testing.T) {
+	err := &AssertionError{
+		Path: "/tmp/users.yaml",
+		Results: []AssertionResult{
+			{Name: "status 200", Passed: true},
+			{Name: "body id", Passed: false, Message: "not found"},
+		},
+	}
+
+	expected := "1 of 2 assertions failed in 'users.yaml'"
+	if err.Error() != expected {
+		t.Errorf("expected '%s', got '%s'", expected, err.Error())
+	}
+}
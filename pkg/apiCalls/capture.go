@@ -0,0 +1,131 @@
+package apicalls
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// CaptureError is returned when the values of a response can't be captured or saved to env.
+// The request went through, so retrying the file won't help
+type CaptureError struct {
+	Path string
+	Err  error
+}
+
+func (e *CaptureError) Error() string {
+	return fmt.Sprintf("'%s': %v", filepath.Base(e.Path), e.Err)
+}
+
+func (e *CaptureError) Unwrap() error {
+	return e.Err
+}
+
+// CaptureValues extracts the captured values from the exchange.
+// Values are converted to the types supported in the secrets map,
+// and objects or arrays are stored as JSON string
+func CaptureValues(captures yamlparser.Captures, exchange *Exchange) (map[string]any, error) {
+	captured := make(map[string]any)
+
+	if len(captures) == 0 {
+		return captured, nil
+	}
+
+	if exchange == nil {
+		return nil, utils.ColorError("no response to capture values from")
+	}
+
+	for _, capture := range captures {
+		value, err := captureValue(capture, exchange)
+		if err != nil {
+			return captured, fmt.Errorf("failed to capture '%s': %w", capture.Name, err)
+		}
+
+		captured[capture.Name] = value
+	}
+
+	return captured, nil
+}
+
+func captureValue(capture yamlparser.Capture, exchange *Exchange) (any, error) {
+	switch {
+	case capture.Status:
+		return exchange.StatusCode, nil
+	case capture.Header != "":
+		values := exchange.ResponseHeaders.Values(capture.Header)
+		if len(values) == 0 {
+			return nil, fmt.Errorf("header '%s' not found in the response", capture.Header)
+		}
+
+		return values[0], nil
+	case capture.Cookie != "":
+		for _, cookie := range exchange.Cookies {
+			if cookie.Name == capture.Cookie {
+				return cookie.Value, nil
+			}
+		}
+
+		return nil, fmt.Errorf("cookie '%s' not found in the response", capture.Cookie)
+	default:
+		value, err := lookupBody(capture.Path, exchange.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		return secretsMapValue(value)
+	}
+}
+
+// secretsMapValue converts the JSON value to one of the types supported by the secrets map
+func secretsMapValue(value any) (any, error) {
+	switch typed := value.(type) {
+	case nil, string, bool:
+		return typed, nil
+	case float64:
+		if typed == math.Trunc(typed) && math.Abs(typed) <= math.MaxInt32 {
+			return int(typed), nil
+		}
+
+		return typed, nil
+	default:
+		jsonStr, err := json.Marshal(typed)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(jsonStr), nil
+	}
+}
+
+// SaveCapturedToEnv writes the captured values listed in save_to_env to the env file of the current environment
+func SaveCapturedToEnv(saveToEnv yamlparser.SaveToEnv, captured map[string]any) error {
+	values := saveToEnv.Values(captured)
+	if len(values) == 0 {
+		return nil
+	}
+
+	if err := envparser.SaveToEnv(values); err != nil {
+		return utils.ColorError("failed to save captured values to env", err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	utils.PrintInfo(fmt.Sprintf(
+		"Saved %s to '%s.env'",
+		strings.Join(keys, ", "),
+		envparser.CurrentEnvName(),
+	))
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package apicalls
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestCaptureValues(t *testing.T) {
+	exchange := testExchange()
+	exchange.ResponseHeaders.Set("X-Request-Id", "req-123")
+	exchange.Cookies = []*http.Cookie{{Name: "session_id", Value: "s3cr3t"}}
+
+	captures := yamlparser.Captures{
+		{Name: "name", Path: "data.users[0].name"},
+		{Name: "age", Path: "data.users[0].age"},
+		{Name: "score", Path: "data.users[0].score"},
+		{Name: "admin", Path: "data.users[0].admin"},
+		{Name: "user", Path: "data.users[0]"},
+		{Name: "requestId", Header: "x-request-id"},
+		{Name: "session", Cookie: "session_id"},
+		{Name: "status", Status: true},
+	}
+
+	captured, err := CaptureValues(captures, exchange)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]any{
+		"name":      "xaaha",
+		"age":       30,
+		"score":     9.5,
+		"admin":     false,
+		"user":      `{"admin":false,"age":30,"manager":null,"name":"xaaha","score":9.5}`,
+		"requestId": "req-123",
+		"session":   "s3cr3t",
+		"status":    200,
+	}
+
+	for key, val := range expected {
+		if captured[key] != val {
+			t.Errorf("expected %s to be %v (%T), got %v (%T)", key, val, val, captured[key], captured[key])
+		}
+	}
+}
+
+func TestCaptureValuesMissing(t *testing.T) {
+	tests := []struct {
+		name    string
+		capture yamlparser.Capture
+	}{
+		{name: "body path", capture: yamlparser.Capture{Name: "token", Path: "data.token"}},
+		{name: "header", capture: yamlparser.Capture{Name: "token", Header: "X-Token"}},
+		{name: "cookie", capture: yamlparser.Capture{Name: "token", Cookie: "token"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := CaptureValues(yamlparser.Captures{tc.capture}, testExchange()); err == nil {
+				t.Errorf("expected error for missing %s", tc.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package apicalls has all things related to api call
+package apicalls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// defaultMaxRedirects is the same as go's http.Client default
+const defaultMaxRedirects = 10
+
+// NewHTTPClient creates http client based on the 'client' section of the yaml file.
+// When config is nil, the client behaves like the go's default client.
+// Proxy defaults to HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+func NewHTTPClient(config *yamlparser.ClientConfig) (*http.Client, error) {
+	client := &http.Client{}
+	if config == nil {
+		return client, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, utils.ColorError("invalid proxy url", err)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig, err := clientTLSConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	switch {
+	case config.ForceHTTP1():
+		// non-nil empty map disables HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	case config.ForceHTTP2():
+		// only HTTP/2 is offered, so the request fails when the server doesn't support it.
+		// http:// urls use HTTP/2 without TLS, with prior knowledge
+		protocols := new(http.Protocols)
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+		transport.Protocols = protocols
+	}
+
+	client.Transport = transport
+
+	if config.Timeout != nil {
+		client.Timeout = time.Duration(*config.Timeout)
+	}
+
+	client.CheckRedirect = checkRedirect(config)
+
+	return client, nil
+}
+
+// clientTLSConfig prepares tls config with custom ca bundle and insecure_skip_verify
+func clientTLSConfig(config *yamlparser.ClientConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if config.InsecureSkipVerify != nil && *config.InsecureSkipVerify {
+		utils.PrintWarning("TLS certificate verification is disabled with 'insecure_skip_verify'")
+
+		tlsConfig.InsecureSkipVerify = true //nolint:gosec // user opted in
+	}
+
+	if config.CACert != "" {
+		pemContent, err := readPEM(config.CACert)
+		if err != nil {
+			return nil, utils.ColorError("error reading ca_cert", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+
+		if !pool.AppendCertsFromPEM(pemContent) {
+			return nil, utils.ColorError("no valid PEM certificates found in " + config.CACert)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if config.Cert != "" {
+		cert, err := loadClientCert(config)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// readPEM returns the value as is, if it is PEM content. Otherwise, reads the file at the path
+func readPEM(value string) ([]byte, error) {
+	if yamlparser.IsPEM(value) {
+		return []byte(value), nil
+	}
+
+	return os.ReadFile(value)
+}
+
+// loadClientCert loads the client certificate and key pair for mutual TLS.
+// When key is missing, the cert is expected to have the private key as well
+func loadClientCert(config *yamlparser.ClientConfig) (tls.Certificate, error) {
+	certPEM, err := readPEM(config.Cert)
+	if err != nil {
+		return tls.Certificate{}, utils.ColorError("error reading client cert", err)
+	}
+
+	keyPEM := certPEM
+	if config.Key != "" {
+		keyPEM, err = readPEM(config.Key)
+		if err != nil {
+			return tls.Certificate{}, utils.ColorError("error reading client key", err)
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, utils.ColorError("invalid client cert and key pair", err)
+	}
+
+	return cert, nil
+}
+
+// clientCertInfo returns the information about the client certificate of the client, if any, for debugging.
+// The certificate is the one NewHTTPClient parsed, so it is not loaded again
+func clientCertInfo(client *http.Client) *CertInfo {
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok || transport.TLSClientConfig == nil || len(transport.TLSClientConfig.Certificates) == 0 {
+		return nil
+	}
+
+	leaf := transport.TLSClientConfig.Certificates[0].Leaf
+	if leaf == nil {
+		return nil
+	}
+
+	info := newCertInfo(leaf)
+
+	return &info
+}
+
+// checkRedirect returns redirect policy based on follow_redirects and max_redirects
+func checkRedirect(config *yamlparser.ClientConfig) func(*http.Request, []*http.Request) error {
+	if config.FollowRedirects != nil && !*config.FollowRedirects {
+		return func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+
+	maxRedirects := defaultMaxRedirects
+	if config.MaxRedirects != nil {
+		maxRedirects = *config.MaxRedirects
+	}
+
+	return func(_ *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+
+		return nil
+	}
+}
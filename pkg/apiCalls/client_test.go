@@ -0,0 +1,264 @@
+package apicalls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestNewHTTPClientRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/first":
+			http.Redirect(w, r, "/second", http.StatusFound)
+		case "/second":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	follow := false
+	one := 1
+
+	tests := []struct {
+		name           string
+		config         *yamlparser.ClientConfig
+		expectedStatus int
+		expectErr      bool
+	}{
+		{name: "default client follows redirects", config: nil, expectedStatus: http.StatusOK},
+		{
+			name:           "do not follow redirects",
+			config:         &yamlparser.ClientConfig{FollowRedirects: &follow},
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:      "too many redirects",
+			config:    &yamlparser.ClientConfig{MaxRedirects: &one},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := NewHTTPClient(tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			resp, err := client.Get(server.URL + "/first")
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestNewHTTPClientTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	timeout := yamlparser.Duration(20 * time.Millisecond)
+
+	client, err := NewHTTPClient(&yamlparser.ClientConfig{Timeout: &timeout})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = client.Get(server.URL)
+
+	var netErr interface{ Timeout() bool }
+	if err == nil || !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestNewHTTPClientTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewHTTPClient(&yamlparser.ClientConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatalf("expected certificate error for self signed server")
+	}
+
+	skip := true
+
+	client, err = NewHTTPClient(&yamlparser.ClientConfig{
+		InsecureSkipVerify: &skip,
+		HTTPVersion:        yamlparser.HTTP1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 1 {
+		t.Errorf("expected HTTP/1.x, got %s", resp.Proto)
+	}
+}
+
+func TestNewHTTPClientForceHTTP2(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h2Server := httptest.NewUnstartedServer(handler)
+	h2Server.EnableHTTP2 = true
+	h2Server.StartTLS()
+	defer h2Server.Close()
+
+	h1Server := httptest.NewTLSServer(handler)
+	defer h1Server.Close()
+
+	skip := true
+
+	client, err := NewHTTPClient(&yamlparser.ClientConfig{InsecureSkipVerify: &skip, HTTPVersion: "2.0"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.Get(h2Server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.ProtoMajor != 2 {
+		t.Errorf("expected HTTP/2, got %s", resp.Proto)
+	}
+
+	if resp, err := client.Get(h1Server.URL); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected an error from a server without HTTP/2, got %s", resp.Proto)
+	}
+}
+
+// selfSignedCertPEM generates self signed certificate and private key in PEM format
+func selfSignedCertPEM(t *testing.T, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	return string(certPEM), string(keyPEM)
+}
+
+func TestNewHTTPClientMutualTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = io.WriteString(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert, MinVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	// trust the test server with ca_cert as PEM content
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+	certPEM, keyPEM := selfSignedCertPEM(t, "hulak-client")
+
+	// cert from a file path and key as PEM content
+	certPath := filepath.Join(t.TempDir(), "client.pem")
+	if err := os.WriteFile(certPath, []byte(certPEM), 0o600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+
+	config := &yamlparser.ClientConfig{CACert: caPEM, Cert: certPath, Key: keyPEM}
+
+	client, err := NewHTTPClient(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hulak-client" {
+		t.Errorf("expected server to receive the client cert, got status %d and body %s", resp.StatusCode, body)
+	}
+
+	info := clientCertInfo(client)
+	if info == nil || info.Subject != "CN=hulak-client" {
+		t.Errorf("expected client cert info for CN=hulak-client, got %+v", info)
+	}
+
+	if _, err := NewHTTPClient(&yamlparser.ClientConfig{Cert: certPEM, Key: caPEM}); err == nil {
+		t.Errorf("expected error for mismatched cert and key")
+	}
+}
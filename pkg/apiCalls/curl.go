@@ -0,0 +1,144 @@
+package apicalls
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// ExportCurl renders the api file as a curl command, with the values of the secretsMap.
+// Client section of the project applies, like it does when the file runs
+func ExportCurl(
+	path string,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (string, error) {
+	apiConfig, _, err := yamlparser.FinalStructForAPI(path, secretsMap, token)
+	if err != nil {
+		return "", err
+	}
+
+	apiInfo, err := apiConfig.PrepareStruct()
+	if err != nil {
+		return "", err
+	}
+
+	projectConfig, err := yamlparser.FinalStructForProject(secretsMap, token)
+	if err != nil {
+		return "", err
+	}
+
+	apiInfo.Client = projectConfig.Client.Merge(apiInfo.Client)
+
+	if apiInfo.Sign != nil {
+		apiInfo.Sign.Secrets = secretsMap
+	}
+
+	return CurlCommand(apiInfo)
+}
+
+// CurlCommand renders the request of the apiInfo as a curl command, one option per line.
+// The request is built like StandardCall builds it, so the url params, encoded body,
+// auth and signatures are already in the command. Digest auth uses curl's --digest
+func CurlCommand(apiInfo yamlparser.ApiInfo) (string, error) {
+	req, bodyBytes, err := newRequest(apiInfo)
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"curl"}
+
+	// curl sends GET, or POST when there's a body, unless -X says otherwise
+	impliedMethod := http.MethodGet
+	if len(bodyBytes) > 0 {
+		impliedMethod = http.MethodPost
+	}
+
+	if req.Method != impliedMethod {
+		args = append(args, "-X "+req.Method)
+	}
+
+	args = append(args, shellQuote(req.URL.String()))
+
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range req.Header[name] {
+			args = append(args, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	if apiInfo.Digest != nil {
+		args = append(args, "--digest", "-u "+shellQuote(apiInfo.Digest.Username+":"+apiInfo.Digest.Password))
+	}
+
+	if len(bodyBytes) > 0 {
+		args = append(args, "--data-raw "+shellQuote(string(bodyBytes)))
+	}
+
+	args = append(args, curlClientOptions(apiInfo.Client)...)
+
+	return strings.Join(args, " \\\n  "), nil
+}
+
+// curlClientOptions converts the client section to curl options.
+// Certificates given as PEM content, rather than a file, can't be passed to curl and are left out
+func curlClientOptions(config *yamlparser.ClientConfig) []string {
+	// like hulak, curl follows the redirects unless the client says otherwise
+	if config == nil {
+		return []string{"-L"}
+	}
+
+	var options []string
+
+	if config.FollowRedirects == nil || *config.FollowRedirects {
+		options = append(options, "-L")
+
+		if config.MaxRedirects != nil {
+			options = append(options, "--max-redirs "+strconv.Itoa(*config.MaxRedirects))
+		}
+	}
+
+	if config.Timeout != nil && *config.Timeout > 0 {
+		seconds := time.Duration(*config.Timeout).Seconds()
+		options = append(options, "--max-time "+strconv.FormatFloat(seconds, 'f', -1, 64))
+	}
+
+	if config.Proxy != "" {
+		options = append(options, "--proxy "+shellQuote(config.Proxy))
+	}
+
+	for _, file := range [][2]string{{"--cacert", config.CACert}, {"--cert", config.Cert}, {"--key", config.Key}} {
+		if file[1] != "" && !yamlparser.IsPEM(file[1]) {
+			options = append(options, file[0]+" "+shellQuote(file[1]))
+		}
+	}
+
+	if config.InsecureSkipVerify != nil && *config.InsecureSkipVerify {
+		options = append(options, "-k")
+	}
+
+	switch {
+	case config.ForceHTTP1():
+		options = append(options, "--http1.1")
+	case config.ForceHTTP2():
+		options = append(options, "--http2")
+	}
+
+	return options
+}
+
+// shellQuote wraps the value in single quotes, so the shell passes it to curl as is
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
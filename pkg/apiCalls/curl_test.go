@@ -0,0 +1,127 @@
+package apicalls
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestCurlCommand(t *testing.T) {
+	follow := false
+	insecure := true
+	timeout := yamlparser.Duration(1500 * time.Millisecond)
+
+	tests := []struct {
+		name     string
+		apiInfo  yamlparser.ApiInfo
+		expected string
+	}{
+		{
+			name:     "get",
+			apiInfo:  yamlparser.ApiInfo{Method: "GET", Url: "https://api.io/users"},
+			expected: "curl \\\n  'https://api.io/users' \\\n  -L",
+		},
+		{
+			name: "post with url params, headers and body",
+			apiInfo: yamlparser.ApiInfo{
+				Method:    "POST",
+				Url:       "https://api.io/users",
+				UrlParams: yamlparser.URLParams{{Key: "q", Values: []string{"it's"}}},
+				Headers:   map[string]string{"X-Id": "1", "Content-Type": "application/json"},
+				Body:      strings.NewReader(`{"name":"it's"}`),
+			},
+			expected: "curl \\\n  'https://api.io/users?q=it%27s' \\\n" +
+				"  -H 'Content-Type: application/json' \\\n  -H 'X-Id: 1' \\\n" +
+				"  --data-raw '{\"name\":\"it'\\''s\"}' \\\n  -L",
+		},
+		{
+			name:     "method other than the one curl implies",
+			apiInfo:  yamlparser.ApiInfo{Method: "DELETE", Url: "https://api.io/users/1"},
+			expected: "curl \\\n  -X DELETE \\\n  'https://api.io/users/1' \\\n  -L",
+		},
+		{
+			name: "digest auth and client",
+			apiInfo: yamlparser.ApiInfo{
+				Method: "GET",
+				Url:    "https://api.io",
+				Digest: &yamlparser.DigestCredentials{Username: "bob", Password: "secret"},
+				Client: &yamlparser.ClientConfig{
+					Timeout:            &timeout,
+					FollowRedirects:    &follow,
+					Proxy:              "http://proxy:8080",
+					CACert:             "-----BEGIN CERTIFICATE-----",
+					Cert:               "certs/client.pem",
+					InsecureSkipVerify: &insecure,
+					HTTPVersion:        yamlparser.HTTP2,
+				},
+			},
+			expected: "curl \\\n  'https://api.io' \\\n  --digest \\\n  -u 'bob:secret' \\\n" +
+				"  --max-time 1.5 \\\n  --proxy 'http://proxy:8080' \\\n  --cert 'certs/client.pem' \\\n" +
+				"  -k \\\n  --http2",
+		},
+		{
+			name: "http version 2.0",
+			apiInfo: yamlparser.ApiInfo{
+				Method: "GET",
+				Url:    "https://api.io",
+				Client: &yamlparser.ClientConfig{HTTPVersion: "2.0"},
+			},
+			expected: "curl \\\n  'https://api.io' \\\n  -L \\\n  --http2",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			command, err := CurlCommand(tc.apiInfo)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if command != tc.expected {
+				t.Errorf("expected\n%s\ngot\n%s", tc.expected, command)
+			}
+		})
+	}
+}
+
+func TestExportCurl(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	content := `method: POST
+url: "{{.baseUrl}}/login"
+headers:
+  Authorization: Bearer {{.token}}
+body:
+  urlencodedformdata:
+    user: "{{.user}}"
+`
+	if err := os.WriteFile("login.yaml", []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	secretsMap := map[string]any{"baseUrl": "https://api.io", "token": "abc", "user": "bob"}
+
+	command, err := ExportCurl(filepath.Join(".", "login.yaml"), secretsMap, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, expected := range []string{
+		"'https://api.io/login'",
+		"-H 'Authorization: Bearer abc'",
+		"-H 'Content-Type: application/x-www-form-urlencoded'",
+		"--data-raw 'user=bob'",
+	} {
+		if !strings.Contains(command, expected) {
+			t.Errorf("expected %q in\n%s", expected, command)
+		}
+	}
+
+	if strings.Contains(command, "-X POST") {
+		t.Errorf("expected no -X for the method curl implies:\n%s", command)
+	}
+}
@@ -0,0 +1,168 @@
+// Package apicalls has all things related to api call
+package apicalls
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// RowResult is the outcome of the request for a single row of the dataset
+type RowResult struct {
+	Row      int
+	Passed   bool
+	Status   int
+	Duration time.Duration
+	Err      error
+}
+
+// DataRunError is returned when the request fails for at least one row of the dataset.
+// It wraps the error of the first failed row
+type DataRunError struct {
+	Path    string
+	Results []RowResult
+	first   error
+}
+
+func (e *DataRunError) Error() string {
+	failed := 0
+
+	for _, result := range e.Results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	return fmt.Sprintf(
+		"%d of %d rows failed in '%s': %v",
+		failed,
+		len(e.Results),
+		filepath.Base(e.Path),
+		e.first,
+	)
+}
+
+func (e *DataRunError) Unwrap() error {
+	return e.first
+}
+
+// SendAndSaveForEachRow runs the api file once for each row of the dataset.
+// Values of the row are merged into the secretsMap before the file is templated,
+// and the response of each row is saved with the row number, like getUser_row2_response.json.
+// Prints the result of each row, and returns the responses and the captured values of all rows
+func SendAndSaveForEachRow(
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+	path string,
+	rows []map[string]any,
+	debug, failNon2xx bool,
+) (RunResult, error) {
+	runResult := RunResult{Captured: make(map[string]any)}
+	results := make([]RowResult, 0, len(rows))
+
+	var firstErr error
+
+	for i, row := range rows {
+		rowEnv := utils.CopyEnvMap(secretsMap)
+		maps.Copy(rowEnv, row)
+
+		result := RowResult{Row: i + 1, Passed: true}
+		start := time.Now()
+
+		resp, rowCaptured, err := sendRow(rowEnv, token, path, RowResponsePath(path, i+1), debug, failNon2xx)
+
+		result.Duration = time.Since(start)
+		if resp.Exchange != nil {
+			result.Status = resp.Exchange.StatusCode
+			result.Duration = resp.Exchange.Duration
+		}
+
+		maps.Copy(runResult.Captured, rowCaptured)
+		runResult.Responses = append(runResult.Responses, ResponsesOf(resp)...)
+
+		if err != nil {
+			result.Passed = false
+			result.Err = err
+
+			if firstErr == nil {
+				firstErr = err
+			}
+
+			utils.PrintRed(fmt.Sprintf("Row %d of '%s' failed: %v", i+1, filepath.Base(path), err))
+		}
+
+		results = append(results, result)
+	}
+
+	PrintRowResults(os.Stdout, path, results)
+
+	if firstErr != nil {
+		return runResult, &DataRunError{Path: path, Results: results, first: firstErr}
+	}
+
+	return runResult, nil
+}
+
+// sendRow templates the file with the row's values and makes the api call
+func sendRow(
+	rowEnv map[string]any,
+	token envparser.TokenFunc,
+	path, responsePath string,
+	debug, failNon2xx bool,
+) (CustomResponse, map[string]any, error) {
+	apiConfig, _, err := yamlparser.FinalStructForAPI(path, rowEnv, token)
+	if err != nil {
+		return CustomResponse{}, nil, err
+	}
+
+	return RunAPICall(apiConfig, rowEnv, token, responsePath, debug, failNon2xx)
+}
+
+// RowResponsePath adds the row number to the path, so that the response of each row is saved in its own file
+func RowResponsePath(path string, row int) string {
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf("%s_row%d%s", strings.TrimSuffix(path, ext), row, ext)
+}
+
+// PrintRowResults prints the result of each row as a table
+func PrintRowResults(out io.Writer, path string, results []RowResult) {
+	passed := 0
+
+	for _, result := range results {
+		if result.Passed {
+			passed++
+		}
+	}
+
+	fmt.Fprintf(out, "\n'%s': %d of %d rows passed\n", filepath.Base(path), passed, len(results))
+
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "ROW\tRESULT\tSTATUS\tDURATION")
+
+	for _, result := range results {
+		outcome := utils.CheckMark + " passed"
+		if !result.Passed {
+			outcome = utils.CrossMark + " failed"
+		}
+
+		status := "-"
+		if result.Status != 0 {
+			status = fmt.Sprint(result.Status)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n",
+			result.Row, outcome, status, result.Duration.Round(time.Millisecond))
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,101 @@
+package apicalls
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSendAndSaveForEachRow(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "2" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+
+		fmt.Fprintf(w, `{"id": %q}`, r.URL.Query().Get("id"))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "get_user.yaml")
+	content := `method: GET
+url: "{{.baseUrl}}/users"
+urlparams:
+  id: "{{.userId}}"
+capture:
+  - name: lastId
+    path: id
+assert:
+  status: 200
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := []map[string]any{{"userId": 1}, {"userId": 2}, {"userId": 3}}
+
+	runResult, err := SendAndSaveForEachRow(
+		map[string]any{"baseUrl": server.URL, "userId": 0},
+		nil,
+		path,
+		rows,
+		false,
+		false,
+	)
+
+	var dataErr *DataRunError
+	if !errors.As(err, &dataErr) {
+		t.Fatalf("expected DataRunError, got %v", err)
+	}
+
+	var assertErr *AssertionError
+	if !errors.As(err, &assertErr) {
+		t.Errorf("expected the assertion error of row 2 to be wrapped, got %v", err)
+	}
+
+	passed := []bool{true, false, true}
+	for i, result := range dataErr.Results {
+		if result.Passed != passed[i] {
+			t.Errorf("row %d: expected passed %v, got %v", i+1, passed[i], result.Passed)
+		}
+	}
+
+	if runResult.Captured["lastId"] != "3" {
+		t.Errorf("expected captured value of the last row, got %v", runResult.Captured["lastId"])
+	}
+
+	if len(runResult.Responses) != len(rows) {
+		t.Errorf("expected a response for each row, got %d", len(runResult.Responses))
+	}
+
+	for row := 1; row <= len(rows); row++ {
+		response := filepath.Join(dir, fmt.Sprintf("get_user_row%d_response.json", row))
+		if _, err := os.Stat(response); err != nil {
+			t.Errorf("expected response of row %d to be saved: %v", row, err)
+		}
+	}
+}
+
+func TestPrintRowResults(t *testing.T) {
+	var out bytes.Buffer
+
+	PrintRowResults(&out, "collection/get_user.yaml", []RowResult{
+		{Row: 1, Passed: true, Status: 200},
+		{Row: 2, Status: 500},
+		{Row: 3},
+	})
+
+	summary := out.String()
+
+	for _, expected := range []string{"'get_user.yaml': 1 of 3 rows passed", "500", "failed"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary to contain '%s', got:\n%s", expected, summary)
+		}
+	}
+}
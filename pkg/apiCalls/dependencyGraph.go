@@ -0,0 +1,295 @@
+package apicalls
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// DependencyGraph is the DAG of files built from the depends_on key of each file
+type DependencyGraph struct {
+	// Order has every file in topological order.
+	// Files keep their original order unless a dependency needs to run first
+	Order        []string
+	dependencies map[string][]string
+	dependents   map[string][]string
+}
+
+// NewDependencyGraph builds the graph for the files. Dependencies that are not in the list are added to the graph,
+// unless they are in external, which are the files that already ran before the files in this graph.
+// Files in external are left out of the graph, even when they are in the list, so they don't run twice.
+// Returns error if a dependency does not exist or the dependencies have a cycle
+func NewDependencyGraph(filePaths []string, external ...string) (*DependencyGraph, error) {
+	graph := &DependencyGraph{
+		dependencies: make(map[string][]string),
+		dependents:   make(map[string][]string),
+	}
+
+	externalFiles := make(map[string]bool)
+
+	for _, path := range external {
+		externalFiles[absPath(path)] = true
+	}
+
+	// index keeps the order in which files are discovered
+	index := make(map[string]int)
+
+	var files []string
+
+	addFile := func(path string) {
+		if _, ok := index[path]; !ok {
+			index[path] = len(files)
+			files = append(files, path)
+		}
+	}
+
+	for _, path := range filePaths {
+		if !externalFiles[absPath(path)] {
+			addFile(absPath(path))
+		}
+	}
+
+	// files slice grows as the dependencies are discovered
+	for i := 0; i < len(files); i++ {
+		path := files[i]
+
+		refs, err := yamlparser.DependsOn(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			dep, err := resolveDependency(path, ref)
+			if err != nil {
+				return nil, err
+			}
+
+			if dep == path {
+				return nil, fmt.Errorf("'%s' depends on itself", filepath.Base(path))
+			}
+
+			graph.dependencies[path] = append(graph.dependencies[path], dep)
+
+			if externalFiles[dep] {
+				continue
+			}
+
+			graph.dependents[dep] = append(graph.dependents[dep], path)
+			addFile(dep)
+		}
+	}
+
+	order, err := graph.topologicalOrder(files, index, externalFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	graph.Order = order
+
+	return graph, nil
+}
+
+// Dependencies returns the files the path depends on
+func (g *DependencyGraph) Dependencies(path string) []string {
+	return g.dependencies[absPath(path)]
+}
+
+// Dependents returns the files that depend on the path directly
+func (g *DependencyGraph) Dependents(path string) []string {
+	return g.dependents[absPath(path)]
+}
+
+// Descendants returns all the files that depend on the path directly or indirectly
+func (g *DependencyGraph) Descendants(path string) []string {
+	var result []string
+
+	seen := make(map[string]bool)
+	queue := []string{absPath(path)}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dependent := range g.dependents[current] {
+			if !seen[dependent] {
+				seen[dependent] = true
+				result = append(result, dependent)
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	return result
+}
+
+// Ancestors returns all the files in the graph the path depends on directly or indirectly, in topological order
+func (g *DependencyGraph) Ancestors(path string) []string {
+	seen := make(map[string]bool)
+	queue := []string{absPath(path)}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range g.dependencies[current] {
+			if !seen[dep] {
+				seen[dep] = true
+				queue = append(queue, dep)
+			}
+		}
+	}
+
+	var result []string
+
+	for _, each := range g.Order {
+		if seen[each] {
+			result = append(result, each)
+		}
+	}
+
+	return result
+}
+
+// HasDependencies is true when at least one file in the graph has depends_on
+func (g *DependencyGraph) HasDependencies() bool {
+	return len(g.dependencies) > 0
+}
+
+// topologicalOrder sorts the files with Kahn's algorithm,
+// always picking the ready file that was discovered first
+func (g *DependencyGraph) topologicalOrder(
+	files []string,
+	index map[string]int,
+	external map[string]bool,
+) ([]string, error) {
+	remaining := make(map[string]int, len(files))
+
+	for _, path := range files {
+		for _, dep := range g.dependencies[path] {
+			if !external[dep] {
+				remaining[path]++
+			}
+		}
+	}
+
+	var ready []string
+
+	for _, path := range files {
+		if remaining[path] == 0 {
+			ready = append(ready, path)
+		}
+	}
+
+	order := make([]string, 0, len(files))
+
+	for len(ready) > 0 {
+		next := 0
+
+		for i := range ready {
+			if index[ready[i]] < index[ready[next]] {
+				next = i
+			}
+		}
+
+		path := ready[next]
+		ready = append(ready[:next], ready[next+1:]...)
+		order = append(order, path)
+
+		for _, dependent := range g.dependents[path] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+
+	if len(order) != len(files) {
+		return nil, fmt.Errorf("depends_on has a cycle: %s", g.findCycle(files, external))
+	}
+
+	return order, nil
+}
+
+// findCycle returns the first cycle found in the graph as 'a.yaml -> b.yaml -> a.yaml'
+func (g *DependencyGraph) findCycle(files []string, external map[string]bool) string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+
+	var stack []string
+
+	var visit func(path string) []string
+
+	visit = func(path string) []string {
+		state[path] = visiting
+		stack = append(stack, path)
+
+		for _, dep := range g.dependencies[path] {
+			if external[dep] {
+				continue
+			}
+
+			switch state[dep] {
+			case visiting:
+				for i, each := range stack {
+					if each == dep {
+						return append(append([]string{}, stack[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[path] = visited
+
+		return nil
+	}
+
+	for _, path := range files {
+		if state[path] == unvisited {
+			if cycle := visit(path); cycle != nil {
+				names := make([]string, 0, len(cycle))
+				for _, each := range cycle {
+					names = append(names, filepath.Base(each))
+				}
+
+				return strings.Join(names, " -> ")
+			}
+		}
+	}
+
+	return ""
+}
+
+// resolveDependency finds the dependency relative to the directory of the file,
+// and then relative to the project root
+func resolveDependency(filePath, ref string) (string, error) {
+	if resolved, ok := utils.ResolveRelativeFile(filePath, ref); ok {
+		return resolved, nil
+	}
+
+	return "", fmt.Errorf(
+		"'%s' in depends_on of '%s' does not exist",
+		ref,
+		filepath.Base(filePath),
+	)
+}
+
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return filepath.Clean(path)
+}
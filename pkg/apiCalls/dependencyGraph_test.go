@@ -0,0 +1,173 @@
+package apicalls
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeRequestFiles creates yaml files in a temp dir with the provided depends_on and returns the dir
+func writeRequestFiles(t *testing.T, dependsOn map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, deps := range dependsOn {
+		content := "method: GET\nurl: \"{{.baseUrl}}/" + name + "\"\n"
+		if deps != "" {
+			content = "depends_on: [" + deps + "]\n" + content
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func baseNames(paths []string) []string {
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, filepath.Base(path))
+	}
+
+	return names
+}
+
+func TestDependencyGraphOrder(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"a_delete.yaml": "b_update.yaml",
+		"b_update.yaml": "c_create.yaml, login.yaml",
+		"c_create.yaml": "login.yaml",
+		"login.yaml":    "",
+		"health.yaml":   "",
+	})
+
+	files := []string{
+		filepath.Join(dir, "a_delete.yaml"),
+		filepath.Join(dir, "b_update.yaml"),
+		filepath.Join(dir, "c_create.yaml"),
+		filepath.Join(dir, "health.yaml"),
+		filepath.Join(dir, "login.yaml"),
+	}
+
+	graph, err := NewDependencyGraph(files)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"health.yaml", "login.yaml", "c_create.yaml", "b_update.yaml", "a_delete.yaml"}
+	if got := baseNames(graph.Order); !slices.Equal(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+
+	if got := baseNames(graph.Descendants(filepath.Join(dir, "c_create.yaml"))); !slices.Equal(
+		got,
+		[]string{"b_update.yaml", "a_delete.yaml"},
+	) {
+		t.Errorf("unexpected descendants %v", got)
+	}
+
+	if got := baseNames(graph.Ancestors(filepath.Join(dir, "a_delete.yaml"))); !slices.Equal(
+		got,
+		[]string{"login.yaml", "c_create.yaml", "b_update.yaml"},
+	) {
+		t.Errorf("unexpected ancestors %v", got)
+	}
+
+	if !graph.HasDependencies() {
+		t.Errorf("expected graph to have dependencies")
+	}
+}
+
+func TestDependencyGraphAddsMissingDependencies(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"profile.yaml": "login.yaml",
+		"login.yaml":   "",
+	})
+
+	graph, err := NewDependencyGraph([]string{filepath.Join(dir, "profile.yaml")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"login.yaml", "profile.yaml"}
+	if got := baseNames(graph.Order); !slices.Equal(got, expected) {
+		t.Errorf("expected order %v, got %v", expected, got)
+	}
+}
+
+func TestDependencyGraphExternal(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"profile.yaml": "login.yaml",
+		"login.yaml":   "",
+	})
+
+	login := filepath.Join(dir, "login.yaml")
+
+	graph, err := NewDependencyGraph([]string{filepath.Join(dir, "profile.yaml")}, login)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := baseNames(graph.Order); !slices.Equal(got, []string{"profile.yaml"}) {
+		t.Errorf("expected external file not to be added, got %v", got)
+	}
+
+	if got := graph.Dependencies(filepath.Join(dir, "profile.yaml")); !slices.Equal(got, []string{login}) {
+		t.Errorf("expected login.yaml as dependency, got %v", got)
+	}
+}
+
+func TestDependencyGraphExternalInList(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"profile.yaml": "",
+		"login.yaml":   "",
+	})
+
+	login := filepath.Join(dir, "login.yaml")
+
+	// login.yaml ran as a dependency of an earlier graph, and is in this list too
+	graph, err := NewDependencyGraph([]string{login, filepath.Join(dir, "profile.yaml")}, login)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := baseNames(graph.Order); !slices.Equal(got, []string{"profile.yaml"}) {
+		t.Errorf("expected external file to run once, got %v", got)
+	}
+}
+
+func TestDependencyGraphCycle(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"a.yaml":      "b.yaml",
+		"b.yaml":      "c.yaml",
+		"c.yaml":      "a.yaml",
+		"health.yaml": "",
+	})
+
+	_, err := NewDependencyGraph([]string{filepath.Join(dir, "health.yaml"), filepath.Join(dir, "a.yaml")})
+	if err == nil {
+		t.Fatal("expected cycle error")
+	}
+
+	if !strings.Contains(err.Error(), "a.yaml -> b.yaml -> c.yaml -> a.yaml") {
+		t.Errorf("expected cycle in the error, got %v", err)
+	}
+}
+
+func TestDependencyGraphErrors(t *testing.T) {
+	dir := writeRequestFiles(t, map[string]string{
+		"self.yaml":    "self.yaml",
+		"missing.yaml": "nowhere.yaml",
+	})
+
+	for _, name := range []string{"self.yaml", "missing.yaml"} {
+		if _, err := NewDependencyGraph([]string{filepath.Join(dir, name)}); err == nil {
+			t.Errorf("expected error for %s", name)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package apicalls
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// digestNC is the nonce count. Each request answers a fresh challenge, so it's always the first use
+const digestNC = "00000001"
+
+// newCnonce is the client nonce of the digest response, replaced in tests
+var newCnonce = func() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// authChallenge is a challenge of the WWW-Authenticate header, like Digest realm="...", nonce="..."
+type authChallenge struct {
+	scheme string
+	params map[string]string
+}
+
+// retryWithDigest answers the Digest challenge of the 401 response, and sends the request again.
+// Returns the response to the first request when the server didn't send a Digest challenge
+func retryWithDigest(
+	client *http.Client,
+	req *http.Request,
+	response *http.Response,
+	body []byte,
+	credentials *yamlparser.DigestCredentials,
+) (*http.Request, *http.Response, error) {
+	challenge, ok := digestChallenge(response.Header.Values("WWW-Authenticate"))
+	if !ok {
+		return req, response, nil
+	}
+
+	authorization, err := digestAuthorization(challenge, credentials, req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the first response is discarded, so the connection could be reused
+	io.Copy(io.Discard, response.Body)
+	response.Body.Close()
+
+	retry := req.Clone(req.Context())
+	retry.Body = io.NopCloser(bytes.NewReader(body))
+	retry.ContentLength = int64(len(body))
+	retry.Header.Set("Authorization", authorization)
+
+	retryResponse, err := client.Do(retry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return retry, retryResponse, nil
+}
+
+// digestChallenge picks the Digest challenge with the strongest supported algorithm
+func digestChallenge(headers []string) (authChallenge, bool) {
+	var challenges []authChallenge
+
+	for _, header := range headers {
+		for _, challenge := range parseChallenges(header) {
+			if strings.EqualFold(challenge.scheme, "Digest") {
+				if _, ok := digestHash(challenge.params["algorithm"]); ok {
+					challenges = append(challenges, challenge)
+				}
+			}
+		}
+	}
+
+	if len(challenges) == 0 {
+		return authChallenge{}, false
+	}
+
+	// servers list the challenges in their order of preference, but SHA-256 is preferred over MD5
+	for _, challenge := range challenges {
+		if strings.HasPrefix(strings.ToUpper(challenge.params["algorithm"]), "SHA-256") {
+			return challenge, true
+		}
+	}
+
+	return challenges[0], true
+}
+
+// parseChallenges parses the challenges of a WWW-Authenticate header. RFC 9110, section 11.6.1
+func parseChallenges(header string) []authChallenge {
+	var challenges []authChallenge
+
+	for _, item := range splitQuoted(header) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		space := strings.IndexByte(item, ' ')
+		equal := strings.IndexByte(item, '=')
+
+		// an item that starts with the scheme starts a new challenge
+		if equal < 0 || (space > 0 && space < equal) {
+			scheme, rest, _ := strings.Cut(item, " ")
+			challenges = append(challenges, authChallenge{scheme: scheme, params: make(map[string]string)})
+			item = strings.TrimSpace(rest)
+		}
+
+		if item == "" || len(challenges) == 0 {
+			continue
+		}
+
+		key, value, _ := strings.Cut(item, "=")
+		challenges[len(challenges)-1].params[strings.ToLower(strings.TrimSpace(key))] = unquote(strings.TrimSpace(value))
+	}
+
+	return challenges
+}
+
+// splitQuoted splits the header by commas outside of quoted strings
+func splitQuoted(header string) []string {
+	var parts []string
+
+	var current strings.Builder
+
+	quoted, escaped := false, false
+
+	for _, char := range header {
+		switch {
+		case escaped:
+			escaped = false
+		case char == '\\' && quoted:
+			escaped = true
+		case char == '"':
+			quoted = !quoted
+		case char == ',' && !quoted:
+			parts = append(parts, current.String())
+			current.Reset()
+
+			continue
+		}
+
+		current.WriteRune(char)
+	}
+
+	return append(parts, current.String())
+}
+
+// quote makes the quoted-string of the header, which only escapes quotes and backslashes
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func unquote(value string) string {
+	if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return value
+	}
+
+	var result strings.Builder
+
+	escaped := false
+
+	for _, char := range value[1 : len(value)-1] {
+		if char == '\\' && !escaped {
+			escaped = true
+
+			continue
+		}
+
+		escaped = false
+
+		result.WriteRune(char)
+	}
+
+	return result.String()
+}
+
+// digestHash returns the hash of the algorithm. MD5 is the default, when the challenge has none
+func digestHash(algorithm string) (func() hash.Hash, bool) {
+	switch strings.ToUpper(algorithm) {
+	case "", "MD5", "MD5-SESS":
+		return md5.New, true
+	case "SHA-256", "SHA-256-SESS":
+		return sha256.New, true
+	default:
+		return nil, false
+	}
+}
+
+// digestAuthorization is the Authorization header that answers the challenge. RFC 7616
+func digestAuthorization(
+	challenge authChallenge,
+	credentials *yamlparser.DigestCredentials,
+	method, uri string,
+	body []byte,
+) (string, error) {
+	params := challenge.params
+	algorithm := params["algorithm"]
+
+	newHash, ok := digestHash(algorithm)
+	if !ok {
+		return "", fmt.Errorf("unsupported digest algorithm '%s'", algorithm)
+	}
+
+	hashHex := func(values ...string) string {
+		h := newHash()
+		h.Write([]byte(strings.Join(values, ":")))
+
+		return hex.EncodeToString(h.Sum(nil))
+	}
+
+	cnonce, err := newCnonce()
+	if err != nil {
+		return "", err
+	}
+
+	realm, nonce := params["realm"], params["nonce"]
+	if nonce == "" {
+		return "", errors.New("digest challenge has no nonce")
+	}
+
+	ha1 := hashHex(credentials.Username, realm, credentials.Password)
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = hashHex(ha1, nonce, cnonce)
+	}
+
+	qop := pickQop(params["qop"])
+
+	ha2 := hashHex(method, uri)
+	if qop == "auth-int" {
+		ha2 = hashHex(method, uri, hashHex(string(body)))
+	}
+
+	username := credentials.Username
+	if strings.EqualFold(params["userhash"], "true") {
+		username = hashHex(credentials.Username, realm)
+	}
+
+	fields := []string{
+		"username=" + quote(username),
+		"realm=" + quote(realm),
+		"nonce=" + quote(nonce),
+		"uri=" + quote(uri),
+	}
+
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+
+	// servers from RFC 2069 don't send qop, and expect the response without it
+	if qop == "" {
+		fields = append(fields, "response="+quote(hashHex(ha1, nonce, ha2)))
+	} else {
+		fields = append(fields,
+			"response="+quote(hashHex(ha1, nonce, digestNC, cnonce, qop, ha2)),
+			"qop="+qop,
+			"nc="+digestNC,
+			"cnonce="+quote(cnonce),
+		)
+	}
+
+	if opaque, ok := params["opaque"]; ok {
+		fields = append(fields, "opaque="+quote(opaque))
+	}
+
+	if strings.EqualFold(params["userhash"], "true") {
+		fields = append(fields, "userhash=true")
+	}
+
+	return "Digest " + strings.Join(fields, ", "), nil
+}
+
+// pickQop prefers auth over auth-int, since auth-int hashes the whole body
+func pickQop(offered string) string {
+	options := strings.Split(offered, ",")
+	for i := range options {
+		options[i] = strings.TrimSpace(strings.ToLower(options[i]))
+	}
+
+	switch {
+	case slices.Contains(options, "auth"):
+		return "auth"
+	case slices.Contains(options, "auth-int"):
+		return "auth-int"
+	default:
+		return ""
+	}
+}
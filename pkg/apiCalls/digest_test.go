@@ -0,0 +1,171 @@
+package apicalls
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	// example of RFC 7616, section 3.9.1
+	original := newCnonce
+	newCnonce = func() (string, error) { return "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", nil }
+
+	t.Cleanup(func() { newCnonce = original })
+
+	credentials := &yamlparser.DigestCredentials{Username: "Mufasa", Password: "Circle of Life"}
+
+	tests := []struct {
+		algorithm string
+		response  string
+	}{
+		{"MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			header := `Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=` + tc.algorithm +
+				`, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`
+
+			challenge, ok := digestChallenge([]string{header})
+			if !ok {
+				t.Fatal("expected a digest challenge")
+			}
+
+			authorization, err := digestAuthorization(challenge, credentials, http.MethodGet, "/dir/index.html", nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, expected := range []string{
+				`username="Mufasa"`,
+				`response="` + tc.response + `"`,
+				"qop=auth,",
+				"nc=00000001",
+				`opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+			} {
+				if !strings.Contains(authorization, expected) {
+					t.Errorf("expected %s in %s", expected, authorization)
+				}
+			}
+		})
+	}
+}
+
+func TestDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		headers   []string
+		ok        bool
+		algorithm string
+		realm     string
+	}{
+		{
+			name:    "basic only",
+			headers: []string{`Basic realm="api"`},
+		},
+		{
+			name:    "digest after basic in the same header",
+			headers: []string{`Basic realm="api", Digest realm="a, b", nonce="n1", qop="auth"`},
+			ok:      true,
+			realm:   "a, b",
+		},
+		{
+			name:      "sha-256 is preferred",
+			headers:   []string{`Digest realm="api", nonce="n1", algorithm=MD5`, `Digest realm="api", nonce="n2", algorithm=SHA-256`},
+			ok:        true,
+			algorithm: "SHA-256",
+			realm:     "api",
+		},
+		{
+			name:    "unsupported algorithm",
+			headers: []string{`Digest realm="api", nonce="n1", algorithm=SHA-512-256`},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			challenge, ok := digestChallenge(tc.headers)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %v, got %v", tc.ok, ok)
+			}
+
+			if challenge.params["algorithm"] != tc.algorithm || challenge.params["realm"] != tc.realm {
+				t.Errorf("unexpected challenge %+v", challenge)
+			}
+		})
+	}
+}
+
+func TestStandardCallDigest(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, _ := io.ReadAll(r.Body)
+		authorization := r.Header.Get("Authorization")
+
+		if !strings.HasPrefix(authorization, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="api", nonce="abc", qop="auth", algorithm=SHA-256`)
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		challenge := parseChallenges(authorization)[0]
+
+		// the server computes the response with the cnonce of the client
+		original := newCnonce
+		newCnonce = func() (string, error) { return challenge.params["cnonce"], nil }
+		expected, _ := digestAuthorization(
+			authChallenge{scheme: "Digest", params: map[string]string{
+				"realm": "api", "nonce": "abc", "qop": "auth", "algorithm": "SHA-256",
+			}},
+			&yamlparser.DigestCredentials{Username: "john", Password: "s3cret"},
+			r.Method,
+			r.URL.RequestURI(),
+			nil,
+		)
+		newCnonce = original
+
+		if authorization != expected || string(body) != `{"name":"john"}` {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	file := yamlparser.ApiCallFile{
+		Method: yamlparser.POST,
+		URL:    yamlparser.URL(server.URL + "/users"),
+		Body:   &yamlparser.Body{Raw: `{"name":"john"}`},
+		Auth:   &yamlparser.ApiAuth{Type: yamlparser.DigestAuth, Username: "john", Password: "s3cret"},
+	}
+
+	apiInfo, err := file.PrepareStruct()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := StandardCall(apiInfo, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if resp.Exchange.StatusCode != http.StatusOK || requests != 2 {
+		t.Errorf("expected 200 after the challenge, got %d in %d requests", resp.Exchange.StatusCode, requests)
+	}
+
+	if !strings.HasPrefix(resp.Exchange.RequestHeaders.Get("Authorization"), "Digest ") {
+		t.Errorf("expected the answered request in the exchange, got %v", resp.Exchange.RequestHeaders)
+	}
+}
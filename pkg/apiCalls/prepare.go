@@ -2,6 +2,8 @@
 package apicalls
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,24 +14,39 @@ import (
 	"time"
 
 	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
 )
 
 // PrepareURL perpares and returns the full url.
-// If the url has parameters, then the function returns the provided baseUrl
+// Params are appended to the query string already present in the baseURL.
+// Since map has no order, params are sorted by key
 func PrepareURL(baseURL string, urlParams map[string]string) string {
+	return AppendURLParams(baseURL, yamlparser.URLParamsFromMap(urlParams), yamlparser.ArrayRepeat)
+}
+
+// AppendURLParams appends the url params, in the provided order, to the query string of the baseURL.
+// Existing query string in the baseURL is kept as is.
+// If the baseURL can't be parsed, baseURL is returned as is
+func AppendURLParams(
+	baseURL string,
+	urlParams yamlparser.URLParams,
+	format yamlparser.ArrayFormat,
+) string {
 	u, err := url.Parse(baseURL)
 	if err != nil {
 		// If parsing fails, return the base URL as is
 		return baseURL
 	}
-	// Prepare URL query parameters if params are provided
-	if urlParams != nil {
-		queryParams := url.Values{}
-		for key, val := range urlParams {
-			queryParams.Add(key, val)
-		}
 
-		u.RawQuery = queryParams.Encode()
+	encoded := urlParams.Encode(format)
+	if encoded == "" {
+		return u.String()
+	}
+
+	if u.RawQuery == "" {
+		u.RawQuery = encoded
+	} else {
+		u.RawQuery += "&" + encoded
 	}
 
 	return u.String()
@@ -61,6 +78,21 @@ func processResponse(
 		responseBody = string(respBody)
 	}
 
+	exchange := &Exchange{
+		Method:          req.Method,
+		URL:             req.URL.String(),
+		RequestHeaders:  req.Header.Clone(),
+		RequestBody:     reqBody,
+		StatusCode:      resp.StatusCode,
+		Status:          resp.Status,
+		Proto:           resp.Proto,
+		ResponseHeaders: resp.Header.Clone(),
+		Cookies:         resp.Cookies(),
+		ResponseBody:    respBody,
+		Body:            responseBody,
+		Duration:        duration,
+	}
+
 	if !debug {
 		// Return minimal set of data
 		return CustomResponse{
@@ -69,21 +101,10 @@ func processResponse(
 				Body:       responseBody,
 			},
 			Duration: durationFormatted,
+			Exchange: exchange,
 		}
 	}
 
-	// Reading Response Headers
-	responseHeaders := make(map[string]string)
-	for name, values := range resp.Header {
-		responseHeaders[name] = strings.Join(values, ", ")
-	}
-
-	// Reading Request Headers
-	requestHeaders := make(map[string]string)
-	for name, values := range req.Header {
-		requestHeaders[name] = strings.Join(values, ", ")
-	}
-
 	// Preparing TLS Info
 	var tlsInfo HTTPInfo
 
@@ -92,19 +113,24 @@ func processResponse(
 
 		var subjects []string
 
+		var chain []CertInfo
+
 		for _, cert := range resp.TLS.PeerCertificates {
 			issuers = append(issuers, cert.Issuer.String())
 			subjects = append(subjects, cert.Subject.String())
+			chain = append(chain, newCertInfo(cert))
 		}
 
 		tlsInfo = HTTPInfo{
-			Protocol:    resp.Proto,
-			TLSVersion:  resp.TLS.NegotiatedProtocol,
-			CipherSuite: resp.TLS.CipherSuite,
+			Protocol:           resp.Proto,
+			TLSVersion:         resp.TLS.NegotiatedProtocol,
+			TLSProtocolVersion: tls.VersionName(resp.TLS.Version),
+			CipherSuite:        resp.TLS.CipherSuite,
 			ServerCertInfo: &CertInfo{
 				Issuer:  strings.Join(issuers, ", "),
 				Subject: strings.Join(subjects, ", "),
 			},
+			ServerCertChain: chain,
 		}
 	} else {
 		tlsInfo = HTTPInfo{
@@ -113,20 +139,71 @@ func processResponse(
 	}
 
 	return CustomResponse{
-		Request: &RequestInfo{
-			URL:     req.URL.String(),
-			Method:  req.Method,
-			Headers: requestHeaders,
-			Body:    string(reqBody),
-		},
-		Response: &ResponseInfo{
-			StatusCode: resp.StatusCode,
-			Status:     resp.Status,
-			Headers:    responseHeaders,
-			Body:       responseBody,
-		},
+		Request:  newRequestInfo(exchange),
+		Response: newResponseInfo(exchange),
 		HTTPInfo: &tlsInfo,
 		Duration: durationFormatted,
+		Exchange: exchange,
+	}
+}
+
+// Detailed returns the response with the complete request and response info from the exchange,
+// same as the debug mode, regardless of the mode the request was made in
+func (c CustomResponse) Detailed() CustomResponse {
+	if c.Exchange == nil {
+		return c
+	}
+
+	c.Request = newRequestInfo(c.Exchange)
+	c.Response = newResponseInfo(c.Exchange)
+
+	return c
+}
+
+// newRequestInfo prepares the RequestInfo from the request in the exchange
+func newRequestInfo(exchange *Exchange) *RequestInfo {
+	info := &RequestInfo{
+		URL:     exchange.URL,
+		Method:  exchange.Method,
+		Headers: joinHeaders(exchange.RequestHeaders),
+		Body:    string(exchange.RequestBody),
+	}
+
+	if u, err := url.Parse(exchange.URL); err == nil {
+		info.URLParams = u.Query()
+	}
+
+	return info
+}
+
+// newResponseInfo prepares the ResponseInfo from the response in the exchange
+func newResponseInfo(exchange *Exchange) *ResponseInfo {
+	return &ResponseInfo{
+		StatusCode: exchange.StatusCode,
+		Status:     exchange.Status,
+		Headers:    joinHeaders(exchange.ResponseHeaders),
+		Body:       exchange.Body,
+	}
+}
+
+// joinHeaders joins the values of each header with comma
+func joinHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		headers[name] = strings.Join(values, ", ")
+	}
+
+	return headers
+}
+
+// newCertInfo prepares CertInfo from x509 certificate
+func newCertInfo(cert *x509.Certificate) CertInfo {
+	return CertInfo{
+		Issuer:    cert.Issuer.String(),
+		Subject:   cert.Subject.String(),
+		NotBefore: cert.NotBefore.UTC().Format(time.RFC3339),
+		NotAfter:  cert.NotAfter.UTC().Format(time.RFC3339),
+		DNSNames:  cert.DNSNames,
 	}
 }
 
@@ -160,6 +237,12 @@ func processDirectory(dirPath string) ([]string, error) {
 		return nil, err
 	}
 
+	// project configuration is not a request file
+	projectConfigPath, err := utils.CreatePath(utils.ProjectConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
 	// since we save json as responses, examples, and such,
 	// let's not allow json file to be run concurrently
 	fileExtensions := []string{utils.YAML, utils.YML}
@@ -175,7 +258,7 @@ func processDirectory(dirPath string) ([]string, error) {
 			}
 		}
 
-		if fileIsValid {
+		if fileIsValid && file != projectConfigPath {
 			result = append(result, file)
 		}
 	}
@@ -1,7 +1,9 @@
 package apicalls
 
 import (
+	"crypto/tls"
 	"io"
+	"net/http"
 	"strings"
 	"testing"
 
@@ -60,6 +62,19 @@ func TestFullUrl(t *testing.T) {
 			},
 			expected: "https://api.example.com/resource?limit=10&search=",
 		},
+		// Test with query string already present in the url
+		{
+			name:     "Existing query string is kept",
+			baseURL:  "https://api.example.com/resource?page=2",
+			params:   map[string]string{"limit": "10"},
+			expected: "https://api.example.com/resource?page=2&limit=10",
+		},
+		{
+			name:     "Existing query string without parameters",
+			baseURL:  "https://api.example.com/resource?page=2",
+			params:   nil,
+			expected: "https://api.example.com/resource?page=2",
+		},
 	}
 
 	for _, tt := range tests {
@@ -72,6 +87,55 @@ func TestFullUrl(t *testing.T) {
 	}
 }
 
+func TestAppendURLParams(t *testing.T) {
+	params := yamlparser.URLParams{
+		{Key: "sort", Values: []string{"desc"}},
+		{Key: "ids", Values: []string{"1", "2", "3"}, IsArray: true},
+		{Key: "q", Values: []string{"go lang"}},
+	}
+
+	tests := []struct {
+		name     string
+		baseURL  string
+		format   yamlparser.ArrayFormat
+		expected string
+	}{
+		{
+			name:     "default format repeats the key in declared order",
+			baseURL:  "https://api.example.com/users",
+			format:   "",
+			expected: "https://api.example.com/users?sort=desc&ids=1&ids=2&ids=3&q=go+lang",
+		},
+		{
+			name:     "comma format",
+			baseURL:  "https://api.example.com/users",
+			format:   yamlparser.ArrayComma,
+			expected: "https://api.example.com/users?sort=desc&ids=1,2,3&q=go+lang",
+		},
+		{
+			name:     "brackets format",
+			baseURL:  "https://api.example.com/users",
+			format:   yamlparser.ArrayBrackets,
+			expected: "https://api.example.com/users?sort=desc&ids%5B%5D=1&ids%5B%5D=2&ids%5B%5D=3&q=go+lang",
+		},
+		{
+			name:     "merges with existing query string",
+			baseURL:  "https://api.example.com/users?page=3",
+			format:   yamlparser.ArrayRepeat,
+			expected: "https://api.example.com/users?page=3&sort=desc&ids=1&ids=2&ids=3&q=go+lang",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AppendURLParams(tt.baseURL, params, tt.format)
+			if got != tt.expected {
+				t.Errorf("AppendURLParams() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestEncodeXwwwFormUrlBody(t *testing.T) {
 	tests := []struct {
 		input       map[string]string
@@ -314,3 +378,68 @@ func TestEncodeGraphQlBody(t *testing.T) {
 		})
 	}
 }
+
+func TestCustomResponseDetailed(t *testing.T) {
+	resp := CustomResponse{
+		Response: &ResponseInfo{StatusCode: 200},
+		Exchange: &Exchange{
+			Method:          http.MethodGet,
+			URL:             "https://example.com/users?page=2&tag=a&tag=b",
+			RequestHeaders:  http.Header{"Accept": {"text/plain", "application/json"}},
+			StatusCode:      200,
+			Status:          "200 OK",
+			ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+			Body:            map[string]any{"id": 1},
+		},
+	}
+
+	detailed := resp.Detailed()
+
+	if detailed.Request == nil || detailed.Request.Method != http.MethodGet {
+		t.Fatalf("expected request info from the exchange, got %+v", detailed.Request)
+	}
+
+	if got := detailed.Request.Headers["Accept"]; got != "text/plain, application/json" {
+		t.Errorf("expected joined header values, got %q", got)
+	}
+
+	if got := detailed.Request.URLParams["tag"]; len(got) != 2 {
+		t.Errorf("expected url params from the url, got %v", got)
+	}
+
+	if detailed.Response.Status != "200 OK" || detailed.Response.Headers["Content-Type"] != "application/json" {
+		t.Errorf("expected response info from the exchange, got %+v", detailed.Response)
+	}
+
+	if resp.Request != nil {
+		t.Error("expected the original response to be unchanged")
+	}
+}
+
+func TestProcessResponseTLSInfo(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &http.Response{
+		StatusCode: 200,
+		Proto:      "HTTP/2.0",
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader("ok")),
+		TLS:        &tls.ConnectionState{Version: tls.VersionTLS13, NegotiatedProtocol: "h2"},
+	}
+
+	info := processResponse(req, resp, 0, true, nil).HTTPInfo
+	if info == nil {
+		t.Fatal("expected http info in debug mode")
+	}
+
+	if info.TLSVersion != "h2" {
+		t.Errorf("expected tls_version to keep the negotiated protocol, got %q", info.TLSVersion)
+	}
+
+	if info.TLSProtocolVersion != "TLS 1.3" {
+		t.Errorf("expected TLS 1.3, got %q", info.TLSProtocolVersion)
+	}
+}
@@ -0,0 +1,41 @@
+package apicalls
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// signRequest sets the headers of the sign section. Their templates have the env values,
+// and the final request in .request, since the encoded body is not known when the file is templated
+func signRequest(req *http.Request, body []byte, signing *yamlparser.Signing, now time.Time) error {
+	data := make(map[string]any, len(signing.Secrets)+1)
+	maps.Copy(data, signing.Secrets)
+
+	data["request"] = map[string]any{
+		"method":    req.Method,
+		"url":       req.URL.String(),
+		"path":      req.URL.EscapedPath(),
+		"query":     req.URL.RawQuery,
+		"body":      string(body),
+		"timestamp": strconv.FormatInt(now.Unix(), 10),
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(signing.Headers)) {
+		value, err := envparser.SubstituteVariables(signing.Headers[name], data, nil)
+		if err != nil {
+			return utils.ColorError(fmt.Sprintf("could not sign the '%s' header", name), err)
+		}
+
+		req.Header.Set(name, fmt.Sprint(value))
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package apicalls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestSignRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodPost, "https://api.example.com/v1/orders?page=2", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signing := &yamlparser.Signing{
+		Headers: yamlparser.SignHeaders{
+			"x-timestamp": "{{.request.timestamp}}",
+			"x-signature": `{{hmacSHA256 .secret (print .request.method .request.path .request.timestamp .request.body)}}`,
+			"x-target":    "{{.request.url}}",
+		},
+		Secrets: map[string]any{"secret": "partner-secret"},
+	}
+
+	body := []byte(`{"id":1}`)
+	if err := signRequest(req, body, signing, time.Unix(1700000000, 0)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("partner-secret"))
+	mac.Write([]byte(`POST/v1/orders1700000000{"id":1}`))
+
+	expected := map[string]string{
+		"X-Timestamp": "1700000000",
+		"X-Signature": hex.EncodeToString(mac.Sum(nil)),
+		"X-Target":    "https://api.example.com/v1/orders?page=2",
+	}
+
+	for name, value := range expected {
+		if got := req.Header.Get(name); got != value {
+			t.Errorf("expected %s: %s, got %s", name, value, got)
+		}
+	}
+
+	signing.Headers = yamlparser.SignHeaders{"x-signature": "{{.missing}}"}
+	if err := signRequest(req, body, signing, time.Now()); err == nil {
+		t.Error("expected an error for the missing key")
+	}
+}
+
+func TestStandardCallSign(t *testing.T) {
+	var signature, receivedBody string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, _ := io.ReadAll(r.Body)
+		receivedBody = string(content)
+		signature = r.Header.Get("X-Signature")
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	file := yamlparser.ApiCallFile{
+		Method: yamlparser.POST,
+		URL:    yamlparser.URL(server.URL),
+		Body:   &yamlparser.Body{URLEncodedFormData: map[string]string{"name": "hulak"}},
+		Sign:   yamlparser.SignHeaders{"x-signature": "{{sha256 .request.body}}"},
+	}
+
+	apiInfo, err := file.PrepareStruct()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := StandardCall(apiInfo, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sum := sha256.Sum256([]byte(receivedBody))
+	if receivedBody == "" || !strings.EqualFold(signature, hex.EncodeToString(sum[:])) {
+		t.Errorf("expected the hash of the encoded body %q, got %s", receivedBody, signature)
+	}
+}
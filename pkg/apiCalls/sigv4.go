@@ -0,0 +1,215 @@
+package apicalls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+const (
+	sigV4Algorithm  = "AWS4-HMAC-SHA256"
+	sigV4DateFormat = "20060102T150405Z"
+	sigV4Terminator = "aws4_request"
+)
+
+// headers that proxies and the http client may change, so they are never signed
+var sigV4UnsignedHeaders = []string{"authorization", "user-agent", "expect", "x-amzn-trace-id"}
+
+// signSigV4 signs the request with AWS Signature Version 4, and sets the Authorization header.
+// Body is the final body of the request, since its hash is part of the signature
+func signSigV4(req *http.Request, body []byte, credentials *yamlparser.SigV4Credentials, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(sigV4DateFormat)
+	date := amzDate[:8]
+
+	payloadHash := sha256Hex(body)
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	// S3 requires the hash of the payload in a header
+	if isS3(credentials.Service) {
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	canonicalHeaders, signedHeaders := sigV4Headers(req)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		sigV4Path(req.URL, credentials.Service),
+		sigV4Query(req.URL.RawQuery),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, credentials.Region, credentials.Service, sigV4Terminator}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := sigV4Key(credentials.SecretKey, date, credentials.Region, credentials.Service)
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, credentials.AccessKey, scope, signedHeaders, signature,
+	))
+}
+
+// sigV4Key derives the signing key of the day, region and service from the secret key
+func sigV4Key(secretKey, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+
+	return hmacSHA256(key, sigV4Terminator)
+}
+
+// sigV4Headers returns the canonical headers and the signed headers. Names are lowercase and sorted,
+// and the spaces around and inside the values are trimmed
+func sigV4Headers(req *http.Request) (string, string) {
+	values := map[string][]string{}
+
+	for name, headerValues := range req.Header {
+		name = strings.ToLower(name)
+		if slices.Contains(sigV4UnsignedHeaders, name) {
+			continue
+		}
+
+		for _, value := range headerValues {
+			values[name] = append(values[name], strings.Join(strings.Fields(value), " "))
+		}
+	}
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values["host"] = []string{host}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + strings.Join(values[name], ",") + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// sigV4Path is the canonical uri. Each segment is encoded twice, except for S3,
+// which uses the path as it is and encodes it once
+func sigV4Path(u *url.URL, service string) string {
+	// the root of the bucket, or of the api, has no path
+	if u.Path == "" {
+		return "/"
+	}
+
+	if isS3(service) {
+		return sigV4Escape(u.Path, false)
+	}
+
+	escaped := u.EscapedPath()
+
+	cleaned := path.Clean(escaped)
+	if strings.HasSuffix(escaped, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return sigV4Escape(cleaned, false)
+}
+
+// sigV4Query is the canonical query string, sorted by key and then by value
+func sigV4Query(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	pairs := []string{}
+
+	for part := range strings.SplitSeq(rawQuery, "&") {
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		pairs = append(pairs, sigV4Escape(key, true)+"="+sigV4Escape(value, true))
+	}
+
+	slices.SortFunc(pairs, func(a, b string) int {
+		keyA, valueA, _ := strings.Cut(a, "=")
+		keyB, valueB, _ := strings.Cut(b, "=")
+
+		if c := strings.Compare(keyA, keyB); c != 0 {
+			return c
+		}
+
+		return strings.Compare(valueA, valueB)
+	})
+
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape encodes everything but the unreserved characters, with uppercase hex.
+// Slashes are kept in paths
+func sigV4Escape(value string, encodeSlash bool) string {
+	var result strings.Builder
+
+	for _, char := range []byte(value) {
+		switch {
+		case 'A' <= char && char <= 'Z', 'a' <= char && char <= 'z', '0' <= char && char <= '9',
+			char == '-', char == '_', char == '.', char == '~':
+			result.WriteByte(char)
+		case char == '/' && !encodeSlash:
+			result.WriteByte(char)
+		default:
+			fmt.Fprintf(&result, "%%%02X", char)
+		}
+	}
+
+	return result.String()
+}
+
+func isS3(service string) bool {
+	return service == "s3"
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+
+	return mac.Sum(nil)
+}
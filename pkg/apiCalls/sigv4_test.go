@@ -0,0 +1,219 @@
+package apicalls
+
+import (
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// requests and signatures of the AWS Signature Version 4 test suite
+func TestSignSigV4(t *testing.T) {
+	credentials := &yamlparser.SigV4Credentials{
+		Region:    "us-east-1",
+		Service:   "service",
+		AccessKey: "AKIDEXAMPLE",
+		SecretKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+
+	now := time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method        string
+		url           string
+		headers       map[string]string
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name:          "get-vanilla",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:          "get-vanilla-query-order-key-case",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:          "get-vanilla-empty-query-key",
+			method:        http.MethodGet,
+			url:           "https://example.amazonaws.com/?Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb",
+		},
+		{
+			name:          "post-vanilla",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:          "post-vanilla-query",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/?Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11",
+		},
+		{
+			name:          "post-header-key-sort",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			headers:       map[string]string{"My-Header1": "value1"},
+			signedHeaders: "host;my-header1;x-amz-date",
+			signature:     "c5410059b04c1ee005303aed430f6e6645f61f4dc9e1461ec8f8916fdf18852c",
+		},
+		{
+			name:          "post-x-www-form-urlencoded",
+			method:        http.MethodPost,
+			url:           "https://example.amazonaws.com/",
+			headers:       map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:          "Param1=value1",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, tc.url, strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for key, value := range tc.headers {
+				req.Header.Set(key, value)
+			}
+
+			signSigV4(req, []byte(tc.body), credentials, now)
+
+			expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+				"SignedHeaders=" + tc.signedHeaders + ", Signature=" + tc.signature
+
+			if got := req.Header.Get("Authorization"); got != expected {
+				t.Errorf("expected\n%s\ngot\n%s", expected, got)
+			}
+
+			if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("expected X-Amz-Date 20150830T123600Z, got %s", got)
+			}
+		})
+	}
+}
+
+func TestSigV4Key(t *testing.T) {
+	// example of the AWS documentation, deriving the signing key
+	key := sigV4Key("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "20150830", "us-east-1", "iam")
+
+	expected := "c4afb1cc5771d871763a393e44b703571b55cc28424d1a5e86da6ed3c154a4b9"
+	if got := hex.EncodeToString(key); got != expected {
+		t.Errorf("expected %s, got %s", expected, got)
+	}
+}
+
+func TestSigV4Query(t *testing.T) {
+	tests := []struct {
+		raw      string
+		expected string
+	}{
+		{"", ""},
+		{"b=2&a=1", "a=1&b=2"},
+		{"a=2&a=1", "a=1&a=2"},
+		{"flag&a=1", "a=1&flag="},
+		{"q=hello%20world&star=*&tilde=~", "q=hello%20world&star=%2A&tilde=~"},
+		{"Param-3=value3&-Param1=value1", "-Param1=value1&Param-3=value3"},
+	}
+
+	for _, tc := range tests {
+		if got := sigV4Query(tc.raw); got != tc.expected {
+			t.Errorf("sigV4Query(%q): expected %q, got %q", tc.raw, tc.expected, got)
+		}
+	}
+}
+
+func TestSigV4Path(t *testing.T) {
+	tests := []struct {
+		url      string
+		service  string
+		expected string
+	}{
+		{"https://example.com", "execute-api", "/"},
+		{"https://example.com/a/./b/../c/", "execute-api", "/a/c/"},
+		{"https://example.com/my%20item", "execute-api", "/my%2520item"},
+		{"https://bucket.s3.amazonaws.com", "s3", "/"},
+		{"https://bucket.s3.amazonaws.com/", "s3", "/"},
+		{"https://bucket.s3.amazonaws.com/my%20item", "s3", "/my%20item"},
+		{"https://bucket.s3.amazonaws.com/a//b", "s3", "/a//b"},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(http.MethodGet, tc.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := sigV4Path(req.URL, tc.service); got != tc.expected {
+			t.Errorf("sigV4Path(%q, %s): expected %q, got %q", tc.url, tc.service, tc.expected, got)
+		}
+	}
+}
+
+func TestStandardCallSigV4(t *testing.T) {
+	var authorization, contentHash, securityToken string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+
+		authorization = r.Header.Get("Authorization")
+		contentHash = r.Header.Get("X-Amz-Content-Sha256")
+		securityToken = r.Header.Get("X-Amz-Security-Token")
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	body := `{"name":"hulak"}`
+
+	_, err := StandardCall(yamlparser.ApiInfo{
+		Method:  http.MethodPut,
+		Url:     server.URL + "/bucket/key.json",
+		Body:    strings.NewReader(body),
+		Headers: map[string]string{"content-type": "application/json", "authorization": "Bearer old"},
+		SigV4: &yamlparser.SigV4Credentials{
+			Region:       "us-east-1",
+			Service:      "s3",
+			AccessKey:    "minioadmin",
+			SecretKey:    "minioadmin",
+			SessionToken: "session",
+		},
+	}, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !strings.HasPrefix(authorization, "AWS4-HMAC-SHA256 Credential=minioadmin/") ||
+		!strings.Contains(authorization, "/us-east-1/s3/aws4_request") ||
+		!strings.Contains(authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("unexpected Authorization header %s", authorization)
+	}
+
+	if contentHash != sha256Hex([]byte(body)) {
+		t.Errorf("expected the hash of the body in X-Amz-Content-Sha256, got %s", contentHash)
+	}
+
+	if securityToken != "session" {
+		t.Errorf("expected the session token in X-Amz-Security-Token, got %s", securityToken)
+	}
+}
@@ -1,20 +1,69 @@
 // Package apicalls has all things related to api call
 package apicalls
 
+import (
+	"net/http"
+	"time"
+)
+
 // CustomResponse is structure of the result to print and save
 type CustomResponse struct {
 	Request  *RequestInfo  `json:"request,omitempty"`
 	Response *ResponseInfo `json:"response,omitempty"`
 	HTTPInfo *HTTPInfo     `json:"http_info,omitempty"`
 	Duration string        `json:"duration,omitempty"`
+	// Exchange is never printed or saved
+	Exchange *Exchange `json:"-"`
+	// Assertions are the results of the assert section, used for the reports
+	Assertions []AssertionResult `json:"-"`
+	// IDToken is the decoded id_token of an OpenID Connect token response
+	IDToken *IDToken `json:"id_token,omitempty"`
+}
+
+// IDToken is the header and the claims of an id_token, with its expiry.
+// Verified is set only when the signature is checked against the keys of the issuer
+type IDToken struct {
+	Header    map[string]any `json:"header"`
+	Claims    map[string]any `json:"claims"`
+	ExpiresAt string         `json:"expires_at,omitempty"`
+	Expired   bool           `json:"expired"`
+	Verified  *bool          `json:"signature_verified,omitempty"`
+}
+
+// RunResult is everything a file produced when it ran: the responses,
+// in the order the requests were made, and the values captured from them
+type RunResult struct {
+	Responses []CustomResponse
+	Captured  map[string]any
+}
+
+// Exchange has the complete request and response regardless of the debug mode.
+// It's used for assertions and anything else that needs the raw response
+type Exchange struct {
+	Method          string
+	URL             string
+	RequestHeaders  http.Header
+	RequestBody     []byte
+	StatusCode      int
+	Status          string
+	Proto           string
+	ResponseHeaders http.Header
+	Cookies         []*http.Cookie
+	ResponseBody    []byte
+	// Body is the parsed JSON body, or the body as string if it's not JSON
+	Body any
+	// Started is when the request was sent
+	Started  time.Time
+	Duration time.Duration
 }
 
 // RequestInfo has all the information about the  request body
 type RequestInfo struct {
-	URL     string            `json:"url,omitempty"`
-	Method  string            `json:"method,omitempty"`
-	Headers map[string]string `json:"headers,omitempty"`
-	Body    any               `json:"body,omitempty"`
+	URL       string              `json:"url,omitempty"`
+	Method    string              `json:"method,omitempty"`
+	URLParams map[string][]string `json:"urlparams,omitempty"`
+	Headers   map[string]string   `json:"headers,omitempty"`
+	Body      any                 `json:"body,omitempty"`
 }
 
 // ResponseInfo has response body info
@@ -25,16 +74,23 @@ type ResponseInfo struct {
 	Body       any               `json:"body,omitempty"`
 }
 
-// HTTPInfo Protocol, TLSVersion, CipherSuite, ServerCertInfo
+// HTTPInfo Protocol, TLSVersion, CipherSuite, ServerCertInfo.
+// TLSVersion keeps the negotiated protocol, like h2, as it always has. TLSProtocolVersion is the TLS version, like TLS 1.3
 type HTTPInfo struct {
-	Protocol       string    `json:"protocol,omitempty"`
-	TLSVersion     string    `json:"tls_version,omitempty"`
-	CipherSuite    uint16    `json:"cipher_suite,omitempty"`
-	ServerCertInfo *CertInfo `json:"server_cert_info,omitempty"`
+	Protocol           string     `json:"protocol,omitempty"`
+	TLSVersion         string     `json:"tls_version,omitempty"`
+	TLSProtocolVersion string     `json:"tls_protocol_version,omitempty"`
+	CipherSuite        uint16     `json:"cipher_suite,omitempty"`
+	ServerCertInfo     *CertInfo  `json:"server_cert_info,omitempty"`
+	ServerCertChain    []CertInfo `json:"server_cert_chain,omitempty"`
+	ClientCertInfo     *CertInfo  `json:"client_cert_info,omitempty"`
 }
 
-// CertInfo has Issuer and Subject
+// CertInfo has Issuer and Subject, and validity of the certificate when available
 type CertInfo struct {
-	Issuer  string `json:"issuer,omitempty"`
-	Subject string `json:"subject,omitempty"`
+	Issuer    string   `json:"issuer,omitempty"`
+	Subject   string   `json:"subject,omitempty"`
+	NotBefore string   `json:"not_before,omitempty"`
+	NotAfter  string   `json:"not_after,omitempty"`
+	DNSNames  []string `json:"dns_names,omitempty"`
 }
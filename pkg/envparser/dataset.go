@@ -0,0 +1,135 @@
+package envparser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// LoadDataset reads the rows of a .csv or .json dataset.
+// CSV files need a header row, and each row is a map of the header to the value.
+// JSON files need an array of objects. Values of the row are merged into the secrets map for each run
+func LoadDataset(filePath string) ([]map[string]any, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, utils.ColorError("error reading dataset", err)
+	}
+
+	var rows []map[string]any
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		rows, err = parseCSVRows(content)
+	case utils.JSON:
+		rows, err = parseJSONRows(content)
+	default:
+		return nil, fmt.Errorf("unsupported dataset '%s', use a .csv or .json file", filePath)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataset '%s': %w", filePath, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("dataset '%s' has no rows", filePath)
+	}
+
+	return rows, nil
+}
+
+// parseCSVRows uses the first row as keys for the rows after it
+func parseCSVRows(content []byte) ([]map[string]any, error) {
+	// byte order mark added by spreadsheet apps is not part of the first column name
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	header := records[0]
+	for i, key := range header {
+		header[i] = strings.TrimSpace(key)
+		if header[i] == "" {
+			return nil, fmt.Errorf("column %d has no name in the header", i+1)
+		}
+	}
+
+	rows := make([]map[string]any, 0, len(records)-1)
+
+	for _, record := range records[1:] {
+		row := make(map[string]any, len(header))
+		for i, key := range header {
+			row[key] = csvValue(record[i])
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// csvValue infers the type of the value like the values in .env files.
+// Values that would change when converted, like 007 or 1.50, stay strings
+func csvValue(val string) any {
+	inferred := inferType(val, false)
+	if fmt.Sprint(inferred) != val {
+		return val
+	}
+
+	return inferred
+}
+
+// parseJSONRows reads an array of objects
+func parseJSONRows(content []byte) ([]map[string]any, error) {
+	var rows []map[string]any
+	if err := json.Unmarshal(content, &rows); err != nil {
+		return nil, fmt.Errorf("expected an array of objects: %w", err)
+	}
+
+	for _, row := range rows {
+		for key, val := range row {
+			row[key] = wholeNumbersToInt(val)
+		}
+	}
+
+	return rows, nil
+}
+
+// wholeNumbersToInt converts whole numbers decoded as float64 to int, so that 5 isn't rendered as 5.0
+func wholeNumbersToInt(val any) any {
+	switch typed := val.(type) {
+	case float64:
+		if typed == math.Trunc(typed) && math.Abs(typed) <= math.MaxInt32 {
+			return int(typed)
+		}
+
+		return typed
+	case map[string]any:
+		for key, each := range typed {
+			typed[key] = wholeNumbersToInt(each)
+		}
+
+		return typed
+	case []any:
+		for i, each := range typed {
+			typed[i] = wholeNumbersToInt(each)
+		}
+
+		return typed
+	default:
+		return val
+	}
+}
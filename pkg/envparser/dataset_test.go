@@ -0,0 +1,87 @@
+package envparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadDataset(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		content   string
+		expected  []map[string]any
+		expectErr bool
+	}{
+		{
+			name:     "csv with types",
+			fileName: "users.csv",
+			content:  "\ufeffuserId, zip ,locale,active,price\n1,00501,en-US,true,1.50\n2,10001,\"fr, FR\",false,2.5\n",
+			expected: []map[string]any{
+				{"userId": 1, "zip": "00501", "locale": "en-US", "active": true, "price": "1.50"},
+				{"userId": 2, "zip": 10001, "locale": "fr, FR", "active": false, "price": 2.5},
+			},
+		},
+		{
+			name:     "json rows",
+			fileName: "users.json",
+			content:  `[{"userId": 1, "tags": ["a"], "payload": {"count": 2}}, {"userId": 2.5}]`,
+			expected: []map[string]any{
+				{"userId": 1, "tags": []any{"a"}, "payload": map[string]any{"count": 2}},
+				{"userId": 2.5},
+			},
+		},
+		{
+			name:      "header only",
+			fileName:  "empty.csv",
+			content:   "userId,locale\n",
+			expectErr: true,
+		},
+		{
+			name:      "json object",
+			fileName:  "users.json",
+			content:   `{"userId": 1}`,
+			expectErr: true,
+		},
+		{
+			name:      "missing column name",
+			fileName:  "users.csv",
+			content:   "userId,\n1,2\n",
+			expectErr: true,
+		},
+		{
+			name:      "unsupported extension",
+			fileName:  "users.txt",
+			content:   "userId\n1\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(filePath, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := LoadDataset(filePath)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got rows %v", rows)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(rows, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, rows)
+			}
+		})
+	}
+}
@@ -130,7 +130,8 @@ func LoadEnvVars(filePath string) (map[string]any, error) {
 		}
 
 		key := strings.TrimSpace(secret[0])
-		val := strings.TrimSpace(secret[1])
+		// '# comment' after the value is not part of it
+		val := strings.TrimSpace(strings.TrimSuffix(secret[1], inlineComment(secret[1])))
 		val, wasTrimmed := trimQuotes(val)
 
 		// Infer value type and assign to the map
@@ -54,6 +54,10 @@ func TestLoadEnvVars(t *testing.T) {
 KEY1=value1
 KEY2="value2"
 KEY3='value3'
+KEY4=value4  # inline comment
+KEY5="value #5" # quoted hash
+KEY6=value#6
+KEY7= # empty
 `
 
 	filePath, err := createTempEnvFile(content)
@@ -66,6 +70,10 @@ KEY3='value3'
 		"KEY1": "value1",
 		"KEY2": "value2",
 		"KEY3": "value3",
+		"KEY4": "value4",
+		"KEY5": "value #5",
+		"KEY6": "value#6",
+		"KEY7": "",
 	}
 
 	result, err := LoadEnvVars(filePath)
@@ -9,9 +9,15 @@ import (
 	"github.com/xaaha/hulak/pkg/actions"
 )
 
+// TokenFunc gets the access token of an auth file for {{token "auth_file"}}.
+// Getting a token may run the auth file, which needs the features package,
+// so the caller passes it along with the secretsMap
+type TokenFunc func(authFile string, secretsMap map[string]any) (string, error)
+
 func replaceVariables(
 	strToChange string,
 	secretsMap map[string]any,
+	token TokenFunc,
 ) (string, error) {
 	if len(strToChange) == 0 {
 		return "", nil
@@ -24,6 +30,17 @@ func replaceVariables(
 		"getFile": func(fileName string) (string, error) {
 			return actions.GetFile(fileName)
 		},
+		"token": func(authFile string) (string, error) {
+			if token == nil {
+				return "", fmt.Errorf("token of '%s' is not available here", authFile)
+			}
+
+			return token(authFile, secretsMap)
+		},
+		"hmacSHA256": actions.HmacSHA256,
+		"sha256":     actions.SHA256,
+		"base64":     actions.Base64,
+		"jwtSign":    actions.JWTSign,
 	}
 
 	tmpl, err := template.New("template").
@@ -48,13 +65,13 @@ func replaceVariables(
 // containing template variables using the replaceVariables function.
 // It ensures that non-string values (e.g., booleans, integers) are preserved and validates against unsupported types.
 // Returns a new map with resolved values or an error if any resolution fails.
-func prepareMap(secretsMap map[string]any) (map[string]any, error) {
+func prepareMap(secretsMap map[string]any, token TokenFunc) (map[string]any, error) {
 	updatedMap := make(map[string]any)
 
 	for key, val := range secretsMap {
 		switch v := val.(type) {
 		case string:
-			changedValue, err := replaceVariables(v, secretsMap)
+			changedValue, err := replaceVariables(v, secretsMap, token)
 			if err != nil {
 				return nil, err
 			}
@@ -62,6 +79,9 @@ func prepareMap(secretsMap map[string]any) (map[string]any, error) {
 			updatedMap[key] = changedValue
 		case bool, int, float64, nil:
 			updatedMap[key] = v
+		case map[string]any, []any:
+			// structured values, like the workflow step results, are used as they are
+			updatedMap[key] = v
 		default:
 			return nil, fmt.Errorf("unsupported type for key '%s': %T", key, val)
 		}
@@ -77,13 +97,14 @@ func prepareMap(secretsMap map[string]any) (map[string]any, error) {
 func SubstituteVariables(
 	strToChange string,
 	secretsMap map[string]any,
+	token TokenFunc,
 ) (any, error) {
-	finalMap, err := prepareMap(secretsMap)
+	finalMap, err := prepareMap(secretsMap, token)
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := replaceVariables(strToChange, finalMap)
+	result, err := replaceVariables(strToChange, finalMap, token)
 	if err != nil {
 		return nil, err
 	}
@@ -105,7 +105,7 @@ func TestSubstituteVariables(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			output, err := SubstituteVariables(tc.stringToChange, tc.varMap)
+			output, err := SubstituteVariables(tc.stringToChange, tc.varMap, nil)
 
 			// Compare output
 			if output != tc.expectedOutput {
@@ -122,3 +122,55 @@ func TestSubstituteVariables(t *testing.T) {
 		})
 	}
 }
+
+func TestSubstituteVariablesToken(t *testing.T) {
+	if _, err := SubstituteVariables(`{{token "auth"}}`, map[string]any{}, nil); err == nil {
+		t.Error("expected an error without a token func")
+	}
+
+	tokenFunc := TokenFunc(func(authFile string, secretsMap map[string]any) (string, error) {
+		if authFile != "auth" {
+			return "", errors.New("unexpected auth file " + authFile)
+		}
+
+		return "token_for_" + secretsMap["user"].(string), nil
+	})
+
+	got, err := SubstituteVariables(`Bearer {{token "auth"}}`, map[string]any{"user": "john"}, tokenFunc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got != "Bearer token_for_john" {
+		t.Errorf("expected 'Bearer token_for_john', got %v", got)
+	}
+}
+
+func TestSubstituteVariablesSigning(t *testing.T) {
+	secrets := map[string]any{"secret": "Jefe", "message": "what do ya want for nothing?"}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		// RFC 4231, test case 2
+		{
+			`{{hmacSHA256 .secret .message}}`,
+			"5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{`{{hmacSHA256 .secret .message | base64}}`, "W9zBRr9gdU5qBCQmCJV1x1oAPwidJzmDnexYuWTsOEM="},
+		{`{{sha256 "abc"}}`, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{`{{base64 .secret}}`, "SmVmZQ=="},
+	}
+
+	for _, tc := range tests {
+		got, err := SubstituteVariables(tc.template, secrets, nil)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.template, err)
+		}
+
+		if got != tc.expected {
+			t.Errorf("%s: expected %s, got %v", tc.template, tc.expected, got)
+		}
+	}
+}
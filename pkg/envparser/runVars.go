@@ -0,0 +1,63 @@
+// Package envparser contains environment parsing and functions around it
+package envparser
+
+import (
+	"maps"
+	"sync"
+)
+
+// RunVars holds the values captured from responses during a single run of hulak.
+// They only live in memory and are merged on top of the secrets map
+// for the files that run later
+type RunVars struct {
+	mu   sync.RWMutex
+	vars map[string]any
+}
+
+// NewRunVars returns an empty set of run scoped variables
+func NewRunVars() *RunVars {
+	return &RunVars{vars: make(map[string]any)}
+}
+
+// SetAll adds the values, replacing the existing ones with the same key
+func (r *RunVars) SetAll(values map[string]any) {
+	if r == nil || len(values) == 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	maps.Copy(r.vars, values)
+}
+
+// Get returns the value of the key and whether it exists
+func (r *RunVars) Get(key string) (any, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	val, ok := r.vars[key]
+
+	return val, ok
+}
+
+// Merge returns a copy of the secretsMap with run scoped variables on top of it
+func (r *RunVars) Merge(secretsMap map[string]any) map[string]any {
+	merged := make(map[string]any, len(secretsMap))
+	maps.Copy(merged, secretsMap)
+
+	if r == nil {
+		return merged
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	maps.Copy(merged, r.vars)
+
+	return merged
+}
@@ -0,0 +1,72 @@
+package envparser
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRunVarsMerge(t *testing.T) {
+	secretsMap := map[string]any{"baseUrl": "http://localhost", "userId": 1}
+
+	runVars := NewRunVars()
+	runVars.SetAll(map[string]any{"userId": 42, "token": "abc"})
+
+	merged := runVars.Merge(secretsMap)
+
+	if merged["userId"] != 42 {
+		t.Errorf("expected captured userId to override the secret, got %v", merged["userId"])
+	}
+
+	if merged["token"] != "abc" || merged["baseUrl"] != "http://localhost" {
+		t.Errorf("unexpected merged map %v", merged)
+	}
+
+	if secretsMap["userId"] != 1 {
+		t.Errorf("secrets map should not be modified, got %v", secretsMap["userId"])
+	}
+
+	if _, ok := secretsMap["token"]; ok {
+		t.Errorf("secrets map should not have captured values")
+	}
+}
+
+func TestRunVarsNil(t *testing.T) {
+	var runVars *RunVars
+
+	runVars.SetAll(map[string]any{"token": "abc"})
+
+	if _, ok := runVars.Get("token"); ok {
+		t.Errorf("nil run vars should not have values")
+	}
+
+	merged := runVars.Merge(map[string]any{"baseUrl": "http://localhost"})
+	if merged["baseUrl"] != "http://localhost" {
+		t.Errorf("expected secrets to be copied, got %v", merged)
+	}
+}
+
+func TestRunVarsConcurrentAccess(t *testing.T) {
+	runVars := NewRunVars()
+
+	var wg sync.WaitGroup
+
+	for i := range 50 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			runVars.SetAll(map[string]any{"count": i})
+		}()
+
+		go func() {
+			defer wg.Done()
+			runVars.Merge(map[string]any{})
+		}()
+	}
+
+	wg.Wait()
+
+	if _, ok := runVars.Get("count"); !ok {
+		t.Errorf("expected count to be captured")
+	}
+}
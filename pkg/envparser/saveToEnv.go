@@ -0,0 +1,228 @@
+// Package envparser contains environment parsing and functions around it
+package envparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+const (
+	lockSuffix  = ".lock"
+	lockTimeout = 10 * time.Second
+	lockRetry   = 50 * time.Millisecond
+)
+
+// envFileMu guards env files between goroutines of the same run.
+// Lock file guards them between separate hulak processes
+var envFileMu sync.Mutex
+
+// CurrentEnvName returns the name of the environment set with -env flag
+func CurrentEnvName() string {
+	if envName := os.Getenv(utils.EnvKey); envName != "" {
+		return envName
+	}
+
+	return utils.DefaultEnvVal
+}
+
+// SaveToEnv writes the values in the env file of the current environment, set with -env flag.
+// Existing keys are updated in place and new keys are appended at the end
+func SaveToEnv(values map[string]any) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	filePath, err := utils.CreatePath(
+		filepath.Join(utils.EnvironmentFolder, CurrentEnvName()+utils.DefaultEnvFileSuffix),
+	)
+	if err != nil {
+		return err
+	}
+
+	return UpdateEnvFile(filePath, values)
+}
+
+// UpdateEnvFile updates the keys in the env file with the values, while preserving comments,
+// empty lines and the order of existing keys. Keys missing in the file are appended in sorted order.
+func UpdateEnvFile(filePath string, values map[string]any) error {
+	formatted := make(map[string]string, len(values))
+
+	for key, val := range values {
+		str, err := formatEnvValue(val)
+		if err != nil {
+			return fmt.Errorf("can't save '%s' to env: %w", key, err)
+		}
+
+		formatted[key] = str
+	}
+
+	envFileMu.Lock()
+	defer envFileMu.Unlock()
+
+	unlock, err := lockFile(filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	content, err := os.ReadFile(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	updated := updateEnvContent(string(content), formatted)
+
+	return os.WriteFile(filePath, []byte(updated), utils.FilePer)
+}
+
+// updateEnvContent replaces the values of existing keys and appends the new ones
+func updateEnvContent(content string, values map[string]string) string {
+	var lines []string
+	if content != "" {
+		lines = strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+	}
+
+	written := make(map[string]bool)
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		separator := strings.Index(line, "=")
+		if separator < 0 {
+			continue
+		}
+
+		key := strings.TrimSpace(line[:separator])
+
+		val, ok := values[key]
+		if !ok {
+			continue
+		}
+
+		// keep the spacing around '=' used in the file
+		spacing := ""
+		if strings.HasPrefix(line[separator+1:], " ") {
+			spacing = " "
+		}
+
+		lines[i] = line[:separator+1] + spacing + val + inlineComment(line[separator+1:])
+		written[key] = true
+	}
+
+	newKeys := make([]string, 0, len(values))
+
+	for key := range values {
+		if !written[key] {
+			newKeys = append(newKeys, key)
+		}
+	}
+
+	slices.Sort(newKeys)
+
+	for _, key := range newKeys {
+		lines = append(lines, key+" = "+values[key])
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatEnvValue converts the value to the string LoadEnvVars reads back as the same value.
+// Strings that would otherwise be inferred as number or bool are quoted
+func formatEnvValue(val any) (string, error) {
+	var str string
+
+	switch typed := val.(type) {
+	case nil:
+		return "", nil
+	case string:
+		if strings.ContainsAny(typed, "\r\n") {
+			return "", errors.New("multi-line values are not supported in env files")
+		}
+
+		// LoadEnvVars only trims the outer quotes, so the value itself is not escaped
+		_, isString := inferType(typed, false).(string)
+		_, hasQuotes := trimQuotes(typed)
+
+		// quotes keep a ' #' in the value from being read as a comment
+		if !isString || hasQuotes || typed == "" || typed != strings.TrimSpace(typed) ||
+			inlineComment(typed) != "" {
+			return `"` + typed + `"`, nil
+		}
+
+		return typed, nil
+	case float64:
+		str = strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		str = fmt.Sprint(typed)
+	}
+
+	return str, nil
+}
+
+// inlineComment returns the '# comment' after the value, with the spaces before it.
+// LoadEnvVars leaves it out of the value, and updateEnvContent keeps it when the value is replaced.
+// A '#' inside quotes or without a space before it is part of the value
+func inlineComment(value string) string {
+	rest := strings.TrimLeft(value, " \t")
+	offset := len(value) - len(rest)
+
+	if len(rest) > 0 && (rest[0] == '"' || rest[0] == '\'') {
+		if end := strings.IndexByte(rest[1:], rest[0]); end >= 0 {
+			offset += end + 2
+		}
+	}
+
+	for i := offset; i < len(value); i++ {
+		if value[i] == '#' && i > 0 && (value[i-1] == ' ' || value[i-1] == '\t') {
+			start := strings.LastIndexFunc(value[:i], func(r rune) bool { return r != ' ' && r != '\t' }) + 1
+
+			return value[start:]
+		}
+	}
+
+	return ""
+}
+
+// lockFile creates a lock file next to the file and returns the function that removes it.
+// Lock files older than lockTimeout are considered stale and removed
+func lockFile(filePath string) (func(), error) {
+	lockPath := filePath + lockSuffix
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		lock, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, utils.FilePer)
+		if err == nil {
+			lock.Close()
+
+			return func() { os.Remove(lockPath) }, nil
+		}
+
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockTimeout {
+			os.Remove(lockPath)
+
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for the lock on '%s'", filePath)
+		}
+
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,180 @@
+package envparser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdateEnvFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "staging.env")
+	content := `# staging secrets
+baseUrl = https://staging.example.com
+
+# auth
+token=old-token
+retries = 3
+`
+	if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateEnvFile(filePath, map[string]any{
+		"token":   "new-token",
+		"userId":  42,
+		"count":   "7",
+		"enabled": true,
+		"apiKey":  "abc",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# staging secrets
+baseUrl = https://staging.example.com
+
+# auth
+token=new-token
+retries = 3
+apiKey = abc
+count = "7"
+enabled = true
+userId = 42
+`
+	if string(got) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, string(got))
+	}
+
+	if _, err := os.Stat(filePath + lockSuffix); !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed")
+	}
+}
+
+func TestUpdateEnvContentInlineComment(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"comment", "token=old  # refreshed by login", "token=new  # refreshed by login"},
+		{"tab before comment", "token = old\t# login", "token = new\t# login"},
+		{"quoted hash", `token="a #b" # login`, "token=new # login"},
+		{"hash in value", "token=a#b", "token=new"},
+		{"empty value", "token= # login", "token= new # login"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := updateEnvContent(tc.content, map[string]string{"token": "new"})
+			if got != tc.expected+"\n" {
+				t.Errorf("expected %q, got %q", tc.expected+"\n", got)
+			}
+		})
+	}
+}
+
+func TestUpdateEnvFileInlineCommentRoundTrip(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "global.env")
+	if err := os.WriteFile(filePath, []byte("token=old  # login token\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateEnvFile(filePath, map[string]any{"token": "newtok", "note": "a #b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "token=newtok  # login token\n") {
+		t.Errorf("expected the comment to be kept, got:\n%s", content)
+	}
+
+	loaded, err := LoadEnvVars(filePath)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if loaded["token"] != "newtok" || loaded["note"] != "a #b" {
+		t.Errorf("expected token 'newtok' and note 'a #b', got %q and %q", loaded["token"], loaded["note"])
+	}
+}
+
+func TestUpdateEnvFileRoundTrip(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "global.env")
+
+	values := map[string]any{
+		"str":        "hello world",
+		"numericStr": "123",
+		"boolStr":    "false",
+		"quotedStr":  `"quoted"`,
+		"padded":     " padded ",
+		"empty":      "",
+		"int":        7,
+		"float":      1.5,
+		"bool":       true,
+	}
+
+	if err := UpdateEnvFile(filePath, values); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := LoadEnvVars(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, val := range values {
+		if loaded[key] != val {
+			t.Errorf("expected %s to be %v (%T), got %v (%T)", key, val, val, loaded[key], loaded[key])
+		}
+	}
+}
+
+func TestUpdateEnvFileMultiline(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "global.env")
+
+	if err := UpdateEnvFile(filePath, map[string]any{"cert": "line1\nline2"}); err == nil {
+		t.Errorf("expected error for multi-line value")
+	}
+}
+
+func TestUpdateEnvFileConcurrent(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "global.env")
+
+	var wg sync.WaitGroup
+
+	for i := range 20 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			key := fmt.Sprintf("key%d", i)
+			if err := UpdateEnvFile(filePath, map[string]any{key: i}); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	loaded, err := LoadEnvVars(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded) != 20 {
+		t.Errorf("expected 20 keys, got %d: %v", len(loaded), loaded)
+	}
+}
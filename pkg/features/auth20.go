@@ -2,13 +2,19 @@
 package features
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"html"
 	"log"
+	"maps"
+	"net"
 	"net/http"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
@@ -16,12 +22,7 @@ import (
 	"github.com/xaaha/hulak/pkg/yamlparser"
 )
 
-const (
-	portNum      = ":2982"
-	timeout      = 60 * time.Second
-	redirectURI  = "http://localhost" + portNum + "/callback"
-	responseType = utils.ResponseType // for consistency
-)
+const responseType = utils.ResponseType // for consistency
 
 // OpenURL Opens the url in the brwoser based on the user's OS
 // copied from Github https://gist.github.com/sevkin/9798d67b2cb9d07cb05f89f14ba682f8?permalink_comment_id=5084817#gistcomment-5084817
@@ -58,93 +59,283 @@ func OpenURL(url string) error {
 	return exec.Command(cmd, args...).Start()
 }
 
-// Channel to communicate the received code
-var codeChan = make(chan string)
-
-// handle '/callback' to processes the OAuth server
-func callback(w http.ResponseWriter, r *http.Request) {
-	code := r.URL.Query().Get("code")
-	if code != "" {
-		authHtml := filepath.Join("assets", "auth.html")
-		http.ServeFile(w, r, authHtml)
-		// Send the code to the channel and close it
-		codeChan <- code
-		close(codeChan)
-	} else {
-		fmt.Fprint(w, "No 'code' query parameter found.")
+// page shown in the browser when the callback is rejected
+const callbackErrorPage = `<!doctype html>
+<html lang="en">
+  <head><meta charset="UTF-8" /><title>Authorization failed</title></head>
+  <body style="font-family: 'Helvetica Neue', Arial, sans-serif; text-align: center; margin-top: 20vh">
+    <h1>Authorization failed</h1>
+    <p>%s</p>
+    <p>Close this window, and run the auth file again.</p>
+  </body>
+</html>`
+
+// callbackResult is the code, or the error, the provider sends to the callback
+type callbackResult struct {
+	code string
+	err  error
+}
+
+// newCallback handles the callback path of the OAuth server. The code is accepted only when
+// the state matches the state sent to the authorization url, otherwise the request may be forged.
+// Only the first result is sent to results
+func newCallback(state string, results chan<- callbackResult) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+
+		var result callbackResult
+
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf(
+				"authorization failed with '%s': %s",
+				query.Get("error"),
+				query.Get("error_description"),
+			)
+		case query.Get("state") != state:
+			result.err = errors.New(
+				"state in the callback doesn't match the state hulak sent, so the code is rejected",
+			)
+		case query.Get(responseType) == "":
+			fmt.Fprint(w, "No 'code' query parameter found.")
+
+			return
+		default:
+			result.code = query.Get(responseType)
+		}
+
+		if result.err != nil {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, callbackErrorPage, html.EscapeString(result.err.Error()))
+		} else {
+			authHtml := filepath.Join("assets", "auth.html")
+			http.ServeFile(w, r, authHtml)
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
 	}
 }
 
-func server() {
-	// log.Println("Starting server on port", portNum)
-	http.HandleFunc("/callback", callback)
+// openBrowser opens the authorization url. Tests replace it to act as the browser
+var openBrowser = OpenURL
+
+// browserFlowMu lets one auth file at a time use the browser, so that auth files of a -dir run
+// don't fight over the callback port or the browser
+var browserFlowMu sync.Mutex
+
+// callbackServer receives the code on the redirect_uri. It has its own mux,
+// so that more than one auth file could run in the same process
+type callbackServer struct {
+	server      *http.Server
+	redirectURI string
+}
 
-	err := http.ListenAndServe(portNum, nil)
+// startCallbackServer listens on the address of the callback before the browser opens,
+// so that a port in use fails the run right away, rather than after the login
+func startCallbackServer(callback *yamlparser.Callback, handler http.HandlerFunc) (*callbackServer, error) {
+	listener, err := net.Listen("tcp", callback.Address())
 	if err != nil {
-		log.Fatal(err)
+		return nil, utils.ColorError("could not start the callback server. Set another auth.callback.port, or 0 for any free port", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(callback.URLPath(), handler)
+
+	// port 0 picks a free port, so the redirect_uri has the port the server got
+	host, _, _ := net.SplitHostPort(callback.Address())
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	srv := &callbackServer{
+		server:      &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second},
+		redirectURI: "http://" + net.JoinHostPort(host, port) + callback.URLPath(),
+	}
+
+	go func() {
+		if err := srv.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			utils.PrintRed("callback server stopped: " + err.Error())
+		}
+	}()
+
+	return srv, nil
+}
+
+// shutdown stops the server after the page for the browser is served
+func (s *callbackServer) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
 	}
 }
 
-// openBrowserAndGetCode starts the callback server and opens the browser for OAuth flow
-// Returns the code coming from the ur
-func openBrowserAndGetCode(filePath string, secretsMap map[string]any) (string, error) {
-	// Create and start the callback server
-	go server()
-	// Prepare the OAuth URL
-	authReqBody, err := yamlparser.FinalStructForOAuth2(filePath, secretsMap)
+// openBrowserAndGetCode starts the callback server and opens the browser for OAuth flow.
+// authParams, like state and code_challenge, are added to the authorization url.
+// Returns the code coming from the provider, and the redirect_uri it was sent to
+func openBrowserAndGetCode(
+	authReqBody yamlparser.AuthRequestFile,
+	authParams map[string]string,
+) (string, string, error) {
+	browserFlowMu.Lock()
+	defer browserFlowMu.Unlock()
+
+	results := make(chan callbackResult, 1)
+	callback := authReqBody.Auth.Callback
+
+	srv, err := startCallbackServer(callback, newCallback(authParams["state"], results))
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	defer srv.shutdown()
 
 	// required fields for oAuth web flow. This is true github and Okta.
 	// from my testing, extra field does not do any harm, if this is not the case, I'll revisit
 	reqField := make(map[string]string)
 	reqField["response_type"] = responseType
-	reqField["redirect_uri"] = redirectURI
+	reqField["redirect_uri"] = srv.redirectURI
+	maps.Copy(reqField, authParams)
 	authReqBody.URLParams = utils.MergeMaps(authReqBody.URLParams, reqField)
 	urlStr := apicalls.PrepareURL(string(authReqBody.URL), authReqBody.URLParams)
 
 	// Open the browser
 	log.Println("Opening browser for authentication...")
 
-	if err := OpenURL(urlStr); err != nil {
-		return "", utils.ColorError("error opening browser: %w", err)
+	if err := openBrowser(urlStr); err != nil {
+		return "", "", utils.ColorError("error opening browser: %w", err)
 	}
 	// Wait for the code or a timeout
 	select {
-	case code := <-codeChan:
-		return code, nil
-	case <-time.After(timeout):
-		return "", utils.ColorError("timeout waiting for the code")
+	case result := <-results:
+		if result.err != nil {
+			return "", "", utils.ColorError("error on the callback", result.err)
+		}
+
+		return result.code, srv.redirectURI, nil
+	case <-time.After(callback.WaitTimeout()):
+		return "", "", utils.ColorError("timeout waiting for the code")
 	}
 }
 
-// SendAPIRequestForAuth2  calls the PrepareStruct using the provided envMap
-// and makes the Api Call with StandardCall and prints the response in console
-func SendAPIRequestForAuth2(secretsMap map[string]any, filePath string, debug bool) error {
-	code, err := openBrowserAndGetCode(filePath, secretsMap)
+// grantParams runs the interactive part of the grant, if any, and returns the params
+// it adds to the token request. client_credentials grant needs none
+func grantParams(authReqConfig yamlparser.AuthRequestFile) (map[string]string, error) {
+	params := make(map[string]string)
+
+	switch authReqConfig.Auth.GrantType() {
+	case yamlparser.AuthorizationCode:
+		state, err := randomString()
+		if err != nil {
+			return nil, err
+		}
+
+		authParams := map[string]string{"state": state}
+
+		if authReqConfig.Auth.UsePKCE() {
+			proof, err := newPKCE()
+			if err != nil {
+				return nil, err
+			}
+
+			authParams["code_challenge"] = proof.challenge
+			authParams["code_challenge_method"] = pkceMethod
+			params["code_verifier"] = proof.verifier
+		}
+
+		code, redirectURI, err := openBrowserAndGetCode(authReqConfig, authParams)
+		if err != nil {
+			return nil, err
+		}
+
+		params[responseType] = code
+		// the spec requires the same redirect_uri in the token request, when it was in the authorization url
+		params["redirect_uri"] = redirectURI
+	case yamlparser.ClientCredentials, yamlparser.DeviceCode:
+		// client credentials in the auth section are all the token request needs.
+		// device_code polls for the token instead, see requestToken
+	}
+
+	return params, nil
+}
+
+// requestToken gets the token from the access_token_url with the grant of the auth file
+func requestToken(
+	authReqConfig yamlparser.AuthRequestFile,
+	client *yamlparser.ClientConfig,
+	debug bool,
+) (apicalls.CustomResponse, error) {
+	if authReqConfig.Auth.GrantType() == yamlparser.DeviceCode {
+		device, err := requestDeviceCode(authReqConfig, client, debug)
+		if err != nil {
+			return apicalls.CustomResponse{}, err
+		}
+
+		return pollDeviceToken(authReqConfig, device, client, debug)
+	}
+
+	params, err := grantParams(authReqConfig)
 	if err != nil {
-		return err
+		return apicalls.CustomResponse{}, err
 	}
 
-	authReqConfig, err := yamlparser.FinalStructForOAuth2(filePath, secretsMap)
+	apiInfo, err := authReqConfig.PrepareStruct(params)
 	if err != nil {
-		return err
+		return apicalls.CustomResponse{}, err
 	}
 
-	apiInfo, err := authReqConfig.PrepareStruct(code)
+	apiInfo.Client = client
+
+	return apicalls.StandardCall(apiInfo, debug)
+}
+
+// SendAPIRequestForAuth2  calls the PrepareStruct using the provided envMap
+// and makes the Api Call with StandardCall and prints the response in console.
+// Returns the values captured from the response
+func SendAPIRequestForAuth2(
+	secretsMap map[string]any,
+	filePath string,
+	debug bool,
+) (apicalls.RunResult, error) {
+	file, err := loadAuthFile(filePath, secretsMap)
 	if err != nil {
-		return err
+		return apicalls.RunResult{}, err
 	}
 
-	resp, err := apicalls.StandardCall(apiInfo, debug)
+	authReqConfig := file.config
+
+	resp, err := requestToken(authReqConfig, file.client, debug)
 	if err != nil {
-		return err
+		return apicalls.RunResult{Responses: apicalls.ResponsesOf(resp)}, err
 	}
 
+	idToken, idTokenErr := inspectIDToken(resp.Exchange, file, time.Now())
+	resp.IDToken = idToken
+
 	apicalls.PrintAndSaveFinalResp(resp, filePath)
 
-	return nil
+	// token that isn't signed by the issuer shouldn't be used
+	if idTokenErr != nil {
+		return apicalls.RunResult{Responses: apicalls.ResponsesOf(resp)}, idTokenErr
+	}
+
+	saveToken(filePath, resp.Exchange)
+
+	// values of a partial capture are not saved to env, or passed to other files
+	captured, err := apicalls.CaptureValues(authReqConfig.Capture, resp.Exchange)
+	if err != nil {
+		return apicalls.RunResult{Responses: apicalls.ResponsesOf(resp)}, &apicalls.CaptureError{Path: filePath, Err: err}
+	}
+
+	result := apicalls.RunResult{Responses: apicalls.ResponsesOf(resp), Captured: captured}
+
+	if err := apicalls.SaveCapturedToEnv(authReqConfig.SaveToEnv, captured); err != nil {
+		return result, &apicalls.CaptureError{Path: filePath, Err: err}
+	}
+
+	return result, nil
 }
 
 // isWSL checks if the Go program is running inside Windows Subsystem for Linux
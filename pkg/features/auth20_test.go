@@ -0,0 +1,291 @@
+package features
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+func TestSendAPIRequestForAuth2ClientCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "my_id" || secret != "my_secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("scope") != "read" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "t0k3n", "expires_in": 3600})
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "machine.yaml")
+	content := `kind: auth
+auth:
+  type: OAuth2.0
+  grant: client_credentials
+  access_token_url: "{{.tokenUrl}}"
+  client_id: "{{.clientId}}"
+  client_secret: "{{.clientSecret}}"
+  client_auth: basic
+  scope: read
+capture:
+  - name: accessToken
+    path: access_token
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	secretsMap := map[string]any{
+		"tokenUrl":     server.URL,
+		"clientId":     "my_id",
+		"clientSecret": "my_secret",
+	}
+
+	result, err := SendAPIRequestForAuth2(secretsMap, path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if result.Captured["accessToken"] != "t0k3n" {
+		t.Errorf("expected captured token, got %v", result.Captured)
+	}
+
+	if len(result.Responses) != 1 || result.Responses[0].Exchange.StatusCode != http.StatusOK {
+		t.Errorf("expected successful token response, got %+v", result.Responses)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "machine_response.json")); err != nil {
+		t.Errorf("expected the token response to be saved: %v", err)
+	}
+}
+
+func TestCallback(t *testing.T) {
+	// the page for the successful callback is served from assets in the project root
+	t.Chdir(filepath.Join("..", ".."))
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedCode   string
+		expectedErr    string
+		expectedStatus int
+		expectResult   bool
+	}{
+		{
+			name:           "code with matching state",
+			query:          "code=abc&state=s1",
+			expectedCode:   "abc",
+			expectedStatus: http.StatusOK,
+			expectResult:   true,
+		},
+		{
+			name:           "state mismatch is rejected",
+			query:          "code=abc&state=forged",
+			expectedErr:    "doesn't match",
+			expectedStatus: http.StatusBadRequest,
+			expectResult:   true,
+		},
+		{
+			name:           "missing state is rejected",
+			query:          "code=abc",
+			expectedErr:    "doesn't match",
+			expectedStatus: http.StatusBadRequest,
+			expectResult:   true,
+		},
+		{
+			name:           "error from the provider",
+			query:          "error=access_denied&error_description=user+denied&state=s1",
+			expectedErr:    "access_denied",
+			expectedStatus: http.StatusBadRequest,
+			expectResult:   true,
+		},
+		{
+			name:           "missing code keeps waiting",
+			query:          "state=s1",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := make(chan callbackResult, 1)
+			recorder := httptest.NewRecorder()
+
+			newCallback("s1", results)(recorder, httptest.NewRequest(http.MethodGet, "/callback?"+tc.query, nil))
+
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+
+			select {
+			case result := <-results:
+				if !tc.expectResult {
+					t.Fatalf("expected no result, got %+v", result)
+				}
+
+				if result.code != tc.expectedCode {
+					t.Errorf("expected code %q, got %q", tc.expectedCode, result.code)
+				}
+
+				if tc.expectedErr == "" && result.err != nil {
+					t.Errorf("unexpected error: %v", result.err)
+				}
+
+				if tc.expectedErr != "" && (result.err == nil || !strings.Contains(result.err.Error(), tc.expectedErr)) {
+					t.Errorf("expected error with %q, got %v", tc.expectedErr, result.err)
+				}
+			default:
+				if tc.expectResult {
+					t.Fatal("expected a result from the callback")
+				}
+			}
+		})
+	}
+}
+
+func TestSendAPIRequestForAuth2AuthorizationCode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		// the redirect_uri of the token request must be the one the code was sent to
+		code := r.PostForm.Get("code")
+		if code == "" || code != "code_for_"+r.PostForm.Get("redirect_uri") || r.PostForm.Get("code_verifier") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "token_" + r.URL.Path[1:]})
+	}))
+	defer server.Close()
+
+	// acts as the browser, and the provider redirects it to the callback with the code
+	openBrowser = func(authURL string) error {
+		parsed, err := url.Parse(authURL)
+		if err != nil {
+			return err
+		}
+
+		redirectURI := parsed.Query().Get("redirect_uri")
+		callback := redirectURI + "?" + url.Values{
+			"code":  {"code_for_" + redirectURI},
+			"state": {parsed.Query().Get("state")},
+		}.Encode()
+
+		go func() {
+			resp, err := http.Get(callback)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+
+		return nil
+	}
+	t.Cleanup(func() { openBrowser = OpenURL })
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	// two auth files of the same run, each on a free port
+	names := []string{"first", "second"}
+	paths := make([]string, len(names))
+
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name+".yaml")
+		content := `kind: auth
+auth:
+  type: OAuth2.0
+  access_token_url: "{{.tokenUrl}}/` + name + `"
+  callback:
+    host: 127.0.0.1
+    port: 0
+    path: /` + name + `/callback
+    timeout: 5s
+url: "{{.tokenUrl}}/authorize"
+urlparams:
+  client_id: my_id
+body:
+  urlencodedformdata:
+    client_id: my_id
+capture:
+  - name: ` + name + `Token
+    path: access_token
+`
+		if err := os.WriteFile(paths[i], []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	secretsMap := map[string]any{"tokenUrl": server.URL}
+
+	var wg sync.WaitGroup
+
+	results := make([]apicalls.RunResult, len(paths))
+	errs := make([]error, len(paths))
+
+	for i, path := range paths {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			results[i], errs[i] = SendAPIRequestForAuth2(secretsMap, path, false)
+		}()
+	}
+
+	wg.Wait()
+
+	for i, name := range names {
+		if errs[i] != nil {
+			t.Fatalf("unexpected error for %s: %v", name, errs[i])
+		}
+
+		if got := results[i].Captured[name+"Token"]; got != "token_"+name {
+			t.Errorf("expected token_%s, got %v", name, got)
+		}
+	}
+}
+
+func TestStartCallbackServerPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	callback := &yamlparser.Callback{Host: "127.0.0.1", Port: &port}
+
+	if _, err := startCallbackServer(callback, newCallback("s1", nil)); err == nil {
+		t.Fatal("expected an error when the port is in use")
+	}
+}
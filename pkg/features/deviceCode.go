@@ -0,0 +1,171 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// RFC 8628 defaults, when the provider doesn't send them
+const (
+	defaultPollInterval = 5
+	slowDownIncrease    = 5
+	defaultDeviceExpiry = 15 * 60
+)
+
+// pollUnit is the unit of the interval and expiry sent by the provider, replaced in tests
+var pollUnit = time.Second
+
+// deviceAuthorization is the response of the device_authorization_url
+type deviceAuthorization struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	// some providers, like Google, send verification_url instead
+	VerificationURL         string `json:"verification_url"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// requestDeviceCode asks the provider for the device and user codes, and prints where to enter the user code
+func requestDeviceCode(
+	authReqConfig yamlparser.AuthRequestFile,
+	client *yamlparser.ClientConfig,
+	debug bool,
+) (deviceAuthorization, error) {
+	apiInfo, err := authReqConfig.PrepareDeviceAuthorization()
+	if err != nil {
+		return deviceAuthorization{}, err
+	}
+
+	apiInfo.Client = client
+
+	resp, err := apicalls.StandardCall(apiInfo, debug)
+	if err != nil {
+		return deviceAuthorization{}, err
+	}
+
+	if status := resp.Exchange.StatusCode; status < 200 || status >= 300 {
+		return deviceAuthorization{}, deviceCodeError(resp.Exchange)
+	}
+
+	var device deviceAuthorization
+	if err := json.Unmarshal(resp.Exchange.ResponseBody, &device); err != nil || device.DeviceCode == "" {
+		return deviceAuthorization{}, utils.ColorError(fmt.Sprintf(
+			"unexpected response from the device_authorization_url with status %d: %s",
+			resp.Exchange.StatusCode,
+			resp.Exchange.ResponseBody,
+		))
+	}
+
+	if device.VerificationURI == "" {
+		device.VerificationURI = device.VerificationURL
+	}
+
+	if device.Interval <= 0 {
+		device.Interval = defaultPollInterval
+	}
+
+	if device.ExpiresIn <= 0 {
+		device.ExpiresIn = defaultDeviceExpiry
+	}
+
+	utils.PrintInfo(fmt.Sprintf(
+		"To authorize hulak, open %s and enter the code: %s",
+		device.VerificationURI,
+		device.UserCode,
+	))
+
+	if device.VerificationURIComplete != "" {
+		utils.PrintInfo("Or open " + device.VerificationURIComplete)
+	}
+
+	return device, nil
+}
+
+// pollDeviceToken polls the access_token_url until the user enters the code, honoring the interval and slow_down.
+// Returns the response with the token, or an error when the user denies the request or the code expires
+func pollDeviceToken(
+	authReqConfig yamlparser.AuthRequestFile,
+	device deviceAuthorization,
+	client *yamlparser.ClientConfig,
+	debug bool,
+) (apicalls.CustomResponse, error) {
+	interval := time.Duration(device.Interval) * pollUnit
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * pollUnit)
+
+	for {
+		time.Sleep(interval)
+
+		if time.Now().After(deadline) {
+			return apicalls.CustomResponse{}, utils.ColorError("device code expired before it was entered")
+		}
+
+		apiInfo, err := authReqConfig.PrepareStruct(map[string]string{"device_code": device.DeviceCode})
+		if err != nil {
+			return apicalls.CustomResponse{}, err
+		}
+
+		apiInfo.Client = client
+
+		resp, err := apicalls.StandardCall(apiInfo, debug)
+		if err != nil {
+			return apicalls.CustomResponse{}, err
+		}
+
+		// some providers, like Github, respond with 200 and an error in the body
+		errCode := deviceTokenError(resp.Exchange)
+
+		switch errCode {
+		case "":
+			return resp, nil
+		case "authorization_pending":
+		case "slow_down":
+			interval += slowDownIncrease * pollUnit
+		case "access_denied":
+			return resp, utils.ColorError("authorization was denied")
+		case "expired_token":
+			return resp, utils.ColorError("device code expired before it was entered")
+		default:
+			return resp, utils.ColorError(fmt.Sprintf(
+				"token request failed with '%s'", errCode))
+		}
+	}
+}
+
+// deviceTokenError returns the error code in the body of the token response, if any
+func deviceTokenError(exchange *apicalls.Exchange) string {
+	body, ok := exchange.Body.(map[string]any)
+	if !ok {
+		return ""
+	}
+
+	errCode, _ := body["error"].(string)
+
+	return errCode
+}
+
+// deviceCodeError describes the failed device authorization request with the OAuth error and error_description, if any
+func deviceCodeError(exchange *apicalls.Exchange) error {
+	msg := fmt.Sprintf("device authorization request failed with status %d", exchange.StatusCode)
+
+	errCode := deviceTokenError(exchange)
+	if errCode == "" {
+		return utils.ColorError(fmt.Sprintf("%s: %s", msg, exchange.ResponseBody))
+	}
+
+	msg += fmt.Sprintf(" and '%s'", errCode)
+
+	if body, ok := exchange.Body.(map[string]any); ok {
+		if description, _ := body["error_description"].(string); description != "" {
+			msg += ": " + description
+		}
+	}
+
+	return utils.ColorError(msg)
+}
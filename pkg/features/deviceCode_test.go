@@ -0,0 +1,206 @@
+package features
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// deviceServer is a provider that responds to the token request with the responses in order
+func deviceServer(t *testing.T, tokenResponses []map[string]any) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var polls atomic.Int32
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("client_id") != "my_id" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"device_code":      "dev123",
+			"user_code":        "ABCD-1234",
+			"verification_uri": "https://example.com/device",
+			"expires_in":       1000,
+			"interval":         1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil ||
+			r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:device_code" ||
+			r.PostForm.Get("device_code") != "dev123" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		poll := int(polls.Add(1)) - 1
+		resp := tokenResponses[min(poll, len(tokenResponses)-1)]
+
+		if _, isErr := resp["error"]; isErr {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+
+		json.NewEncoder(w).Encode(resp)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server, &polls
+}
+
+func TestSendAPIRequestForAuth2DeviceCode(t *testing.T) {
+	pollUnit = time.Millisecond
+	t.Cleanup(func() { pollUnit = time.Second })
+
+	tests := []struct {
+		name           string
+		tokenResponses []map[string]any
+		expectedPolls  int32
+		expectedErr    string
+	}{
+		{
+			name: "token after pending and slow_down",
+			tokenResponses: []map[string]any{
+				{"error": "authorization_pending"},
+				{"error": "slow_down"},
+				{"access_token": "t0k3n"},
+			},
+			expectedPolls: 3,
+		},
+		{
+			name:           "denied by the user",
+			tokenResponses: []map[string]any{{"error": "access_denied"}},
+			expectedPolls:  1,
+			expectedErr:    "denied",
+		},
+		{
+			name:           "expired code",
+			tokenResponses: []map[string]any{{"error": "authorization_pending"}, {"error": "expired_token"}},
+			expectedPolls:  2,
+			expectedErr:    "expired",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server, polls := deviceServer(t, tc.tokenResponses)
+
+			dir := t.TempDir()
+			t.Chdir(dir)
+
+			path := filepath.Join(dir, "device.yaml")
+			content := `kind: auth
+auth:
+  type: OAuth2.0
+  grant: device_code
+  device_authorization_url: "{{.baseUrl}}/device"
+  access_token_url: "{{.baseUrl}}/token"
+  client_id: my_id
+capture:
+  - name: accessToken
+    path: access_token
+`
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := SendAPIRequestForAuth2(map[string]any{"baseUrl": server.URL}, path, false)
+
+			if polls.Load() != tc.expectedPolls {
+				t.Errorf("expected %d polls, got %d", tc.expectedPolls, polls.Load())
+			}
+
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error with %q, got %v", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.Captured["accessToken"] != "t0k3n" {
+				t.Errorf("expected captured token, got %v", result.Captured)
+			}
+		})
+	}
+}
+
+func TestRequestDeviceCodeError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		expectedErr []string
+	}{
+		{
+			name:        "oauth error",
+			status:      http.StatusBadRequest,
+			body:        `{"error": "invalid_client", "error_description": "unknown client_id"}`,
+			expectedErr: []string{"status 400", "invalid_client", "unknown client_id"},
+		},
+		{
+			name:        "error without description",
+			status:      http.StatusUnauthorized,
+			body:        `{"error": "unauthorized_client"}`,
+			expectedErr: []string{"status 401", "unauthorized_client"},
+		},
+		{
+			name:        "body without oauth error",
+			status:      http.StatusInternalServerError,
+			body:        "upstream failed",
+			expectedErr: []string{"status 500", "upstream failed"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tc.status)
+				w.Write([]byte(tc.body))
+			}))
+			t.Cleanup(server.Close)
+
+			dir := t.TempDir()
+			t.Chdir(dir)
+
+			path := filepath.Join(dir, "device.yaml")
+			content := `kind: auth
+auth:
+  type: OAuth2.0
+  grant: device_code
+  device_authorization_url: "{{.baseUrl}}/device"
+  access_token_url: "{{.baseUrl}}/token"
+  client_id: my_id
+`
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := SendAPIRequestForAuth2(map[string]any{"baseUrl": server.URL}, path, false)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			for _, expected := range tc.expectedErr {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected %q in %v", expected, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package features
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// jsonWebKeySet is the response of the jwks_uri. RFC 7517
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// jsonWebKey is a public RSA or EC key of the issuer
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// decodeSegment decodes a base64url segment of a JWT as json
+func decodeSegment(segment string, target any) error {
+	content, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, target)
+}
+
+// hashOf is the hash used by the RS, PS and ES algorithms, by their size
+func hashOf(alg string) (crypto.Hash, bool) {
+	if len(alg) != 5 {
+		return 0, false
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, true
+	case "384":
+		return crypto.SHA384, true
+	case "512":
+		return crypto.SHA512, true
+	default:
+		return 0, false
+	}
+}
+
+// verifyJWS checks the signature of the compact JWS with the key with the same kid,
+// or any key of the right type when the token has no kid. RFC 7518
+func verifyJWS(raw, alg, kid string, keys []jsonWebKey) error {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return errors.New("token should have 3 parts")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return fmt.Errorf("invalid signature encoding: %w", err)
+	}
+
+	hash, ok := hashOf(alg)
+	if !ok || !strings.HasPrefix(alg, "RS") && !strings.HasPrefix(alg, "PS") && !strings.HasPrefix(alg, "ES") {
+		return fmt.Errorf("tokens signed with '%s' can't be verified with public keys", alg)
+	}
+
+	hasher := hash.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	digest := hasher.Sum(nil)
+
+	for _, key := range keys {
+		if kid != "" && key.Kid != kid {
+			continue
+		}
+
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		if verifyWithKey(alg, hash, digest, signature, key) {
+			return nil
+		}
+	}
+
+	if kid != "" {
+		return fmt.Errorf("signature doesn't match the key '%s' of the issuer", kid)
+	}
+
+	return errors.New("signature doesn't match any key of the issuer")
+}
+
+// verifyWithKey reports whether the signature of the digest is made with the private part of the key
+func verifyWithKey(alg string, hash crypto.Hash, digest, signature []byte, key jsonWebKey) bool {
+	switch {
+	case strings.HasPrefix(alg, "RS"), strings.HasPrefix(alg, "PS"):
+		publicKey, err := key.rsaKey()
+		if err != nil {
+			return false
+		}
+
+		if strings.HasPrefix(alg, "PS") {
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+			return rsa.VerifyPSS(publicKey, hash, digest, signature, opts) == nil
+		}
+
+		return rsa.VerifyPKCS1v15(publicKey, hash, digest, signature) == nil
+	case strings.HasPrefix(alg, "ES"):
+		publicKey, err := key.ecKey()
+		if err != nil {
+			return false
+		}
+
+		// JWS signature is r and s of the curve size, one after the other
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return false
+		}
+
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+
+		return ecdsa.Verify(publicKey, digest, r, s)
+	default:
+		return false
+	}
+}
+
+func (k jsonWebKey) rsaKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("key '%s' is %s, not RSA", k.Kid, k.Kty)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	exponent := new(big.Int).SetBytes(e)
+	if !exponent.IsInt64() || exponent.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("key '%s' has an invalid exponent", k.Kid)
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
+
+func (k jsonWebKey) ecKey() (*ecdsa.PublicKey, error) {
+	if k.Kty != "EC" {
+		return nil, fmt.Errorf("key '%s' is %s, not EC", k.Kid, k.Kty)
+	}
+
+	var curve elliptic.Curve
+
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("key '%s' has unsupported curve '%s'", k.Kid, k.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
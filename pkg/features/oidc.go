@@ -0,0 +1,248 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// wellKnownPath is where OpenID Connect issuers publish their discovery document
+const wellKnownPath = "/.well-known/openid-configuration"
+
+// providerMetadata is the part of the OpenID Connect discovery document hulak uses
+type providerMetadata struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+	JWKSURI                     string `json:"jwks_uri"`
+}
+
+// discoveryCache keeps the discovery document of each issuer for the rest of the run
+var discoveryCache sync.Map
+
+// preparedAuth is the templated auth file, with everything needed to run it
+type preparedAuth struct {
+	config yamlparser.AuthRequestFile
+	client *yamlparser.ClientConfig
+	// provider is the discovery document of the issuer, nil without issuer
+	provider *providerMetadata
+}
+
+// loadAuthFile templates the auth file, and fills the endpoints missing in it from the issuer
+func loadAuthFile(filePath string, secretsMap map[string]any) (preparedAuth, error) {
+	authReqConfig, err := yamlparser.FinalStructForOAuth2(filePath, secretsMap, Token)
+	if err != nil {
+		return preparedAuth{}, err
+	}
+
+	projectConfig, err := yamlparser.FinalStructForProject(secretsMap, Token)
+	if err != nil {
+		return preparedAuth{}, err
+	}
+
+	file := preparedAuth{config: authReqConfig, client: projectConfig.Client}
+
+	if authReqConfig.Auth.Issuer == "" {
+		return file, nil
+	}
+
+	provider, err := discoverProvider(string(authReqConfig.Auth.Issuer), file.client)
+	if err != nil {
+		return preparedAuth{}, err
+	}
+
+	file.provider = &provider
+
+	err = file.config.FillEndpoints(yamlparser.OIDCEndpoints{
+		Authorization:       provider.AuthorizationEndpoint,
+		Token:               provider.TokenEndpoint,
+		DeviceAuthorization: provider.DeviceAuthorizationEndpoint,
+	})
+
+	return file, err
+}
+
+// discoverProvider fetches the discovery document of the issuer. The issuer in the document
+// must be the same as the issuer in the file, as OpenID Connect Discovery requires
+func discoverProvider(issuer string, client *yamlparser.ClientConfig) (providerMetadata, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	if cached, ok := discoveryCache.Load(issuer); ok {
+		return cached.(providerMetadata), nil
+	}
+
+	var provider providerMetadata
+	if err := getJSON(issuer+wellKnownPath, client, &provider); err != nil {
+		return providerMetadata{}, utils.ColorError("OpenID Connect discovery failed", err)
+	}
+
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return providerMetadata{}, utils.ColorError(fmt.Sprintf(
+			"issuer '%s' in the discovery document doesn't match the issuer '%s' in the auth file",
+			provider.Issuer, issuer,
+		))
+	}
+
+	discoveryCache.Store(issuer, provider)
+
+	return provider, nil
+}
+
+// getJSON gets the url and decodes the json response in target
+func getJSON(urlStr string, client *yamlparser.ClientConfig, target any) error {
+	resp, err := apicalls.StandardCall(yamlparser.ApiInfo{
+		Method:  string(yamlparser.GET),
+		Url:     urlStr,
+		Headers: map[string]string{"accept": "application/json"},
+		Client:  client,
+	}, false)
+	if err != nil {
+		return err
+	}
+
+	if resp.Exchange.StatusCode < 200 || resp.Exchange.StatusCode > 299 {
+		return fmt.Errorf("'%s' responded with status %d", urlStr, resp.Exchange.StatusCode)
+	}
+
+	if err := json.Unmarshal(resp.Exchange.ResponseBody, target); err != nil {
+		return fmt.Errorf("'%s' didn't respond with json: %w", urlStr, err)
+	}
+
+	return nil
+}
+
+// inspectIDToken decodes the id_token of the token response, if any.
+// With verify_id_token, the signature is checked against the keys of the issuer,
+// and the token is rejected when it's not signed by the issuer, is issued to another client or is expired
+func inspectIDToken(exchange *apicalls.Exchange, file preparedAuth, now time.Time) (*apicalls.IDToken, error) {
+	body, ok := exchange.Body.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+
+	raw, _ := body["id_token"].(string)
+	if raw == "" {
+		return nil, nil
+	}
+
+	idToken, err := decodeIDToken(raw, now)
+	if err != nil {
+		if file.config.Auth.VerifyIDToken {
+			return nil, err
+		}
+
+		utils.PrintWarning("Could not decode the id_token: " + err.Error())
+
+		return nil, nil
+	}
+
+	if !file.config.Auth.VerifyIDToken {
+		return idToken, nil
+	}
+
+	err = verifyIDToken(raw, idToken, file, now)
+	verified := err == nil
+	idToken.Verified = &verified
+
+	return idToken, err
+}
+
+// decodeIDToken decodes the header and the claims of the id_token, without checking the signature
+func decodeIDToken(raw string, now time.Time) (*apicalls.IDToken, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("id_token should have 3 parts, it has %d", len(parts))
+	}
+
+	idToken := &apicalls.IDToken{}
+
+	if err := decodeSegment(parts[0], &idToken.Header); err != nil {
+		return nil, fmt.Errorf("invalid id_token header: %w", err)
+	}
+
+	if err := decodeSegment(parts[1], &idToken.Claims); err != nil {
+		return nil, fmt.Errorf("invalid id_token claims: %w", err)
+	}
+
+	if exp, ok := idToken.Claims["exp"].(float64); ok {
+		expiresAt := time.Unix(int64(exp), 0)
+		idToken.ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+		idToken.Expired = now.After(expiresAt)
+	}
+
+	return idToken, nil
+}
+
+// verifyIDToken checks the signature of the id_token with the keys in the jwks_uri of the issuer,
+// and that the token was issued by the issuer to the client_id of the auth file, and is still valid
+func verifyIDToken(raw string, idToken *apicalls.IDToken, file preparedAuth, now time.Time) error {
+	if file.provider == nil || file.provider.JWKSURI == "" {
+		return utils.ColorError("issuer didn't publish jwks_uri, so the id_token can't be verified")
+	}
+
+	var keySet jsonWebKeySet
+	if err := getJSON(file.provider.JWKSURI, file.client, &keySet); err != nil {
+		return utils.ColorError("could not get the keys of the issuer", err)
+	}
+
+	alg, _ := idToken.Header["alg"].(string)
+	kid, _ := idToken.Header["kid"].(string)
+
+	if err := verifyJWS(raw, alg, kid, keySet.Keys); err != nil {
+		return utils.ColorError("id_token signature is invalid", err)
+	}
+
+	return checkIDTokenClaims(idToken, file.provider.Issuer, file.config.Auth.ClientID, now)
+}
+
+// checkIDTokenClaims checks iss, aud, azp, exp and nbf claims of the id_token, as OpenID Connect Core 3.1.3.7 asks
+func checkIDTokenClaims(idToken *apicalls.IDToken, issuer, clientID string, now time.Time) error {
+	iss, _ := idToken.Claims["iss"].(string)
+	if strings.TrimSuffix(iss, "/") != strings.TrimSuffix(issuer, "/") {
+		return utils.ColorError(fmt.Sprintf("id_token is issued by '%s', not '%s'", iss, issuer))
+	}
+
+	// aud is a string, or a list of strings when the token has more than one audience
+	var audiences []string
+
+	switch aud := idToken.Claims["aud"].(type) {
+	case string:
+		audiences = []string{aud}
+	case []any:
+		for _, value := range aud {
+			if audience, ok := value.(string); ok {
+				audiences = append(audiences, audience)
+			}
+		}
+	}
+
+	if !slices.Contains(audiences, clientID) {
+		return utils.ColorError(fmt.Sprintf("id_token is issued to %v, not to the client_id '%s'", audiences, clientID))
+	}
+
+	if azp, ok := idToken.Claims["azp"].(string); ok && azp != clientID {
+		return utils.ColorError(fmt.Sprintf("id_token is authorized for '%s', not for the client_id '%s'", azp, clientID))
+	}
+
+	if _, ok := idToken.Claims["exp"].(float64); !ok {
+		return utils.ColorError("id_token has no exp claim")
+	}
+
+	if idToken.Expired {
+		return utils.ColorError("id_token expired at " + idToken.ExpiresAt)
+	}
+
+	if nbf, ok := idToken.Claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return utils.ColorError("id_token is not valid before " + time.Unix(int64(nbf), 0).UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package features
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func encodeSegment(t *testing.T, value any) string {
+	t.Helper()
+
+	content, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(content)
+}
+
+// signJWT signs the claims with RS256, PS256 or ES256
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]any) string {
+	t.Helper()
+
+	input := encodeSegment(t, map[string]any{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+
+	var err error
+
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case "PS256":
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		signature, err = rsa.SignPSS(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:], opts)
+	case "ES256":
+		var r, s *big.Int
+
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func rsaJWK(kid string, key *rsa.PrivateKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func TestVerifyJWS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []jsonWebKey{
+		rsaJWK("rsa1", rsaKey),
+		{
+			Kty: "EC",
+			Kid: "ec1",
+			Crv: "P-256",
+			X:   base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+			Y:   base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+		},
+	}
+
+	claims := map[string]any{"sub": "123"}
+
+	tests := []struct {
+		name    string
+		token   string
+		alg     string
+		kid     string
+		wantErr bool
+	}{
+		{"RS256", signJWT(t, "RS256", "rsa1", rsaKey, claims), "RS256", "rsa1", false},
+		{"PS256", signJWT(t, "PS256", "rsa1", rsaKey, claims), "PS256", "rsa1", false},
+		{"ES256", signJWT(t, "ES256", "ec1", ecKey, claims), "ES256", "ec1", false},
+		{"without kid", signJWT(t, "RS256", "", rsaKey, claims), "RS256", "", false},
+		{"signed by another key", signJWT(t, "RS256", "rsa1", otherKey, claims), "RS256", "rsa1", true},
+		{"unknown kid", signJWT(t, "RS256", "rsa2", rsaKey, claims), "RS256", "rsa2", true},
+		{"symmetric algorithm", signJWT(t, "RS256", "rsa1", rsaKey, claims), "HS256", "rsa1", true},
+		{"none algorithm", signJWT(t, "RS256", "rsa1", rsaKey, claims), "none", "rsa1", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyJWS(tc.token, tc.alg, tc.kid, keys)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDecodeIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	raw := signJWT(t, "RS256", "k1", key, map[string]any{"sub": "123", "exp": exp.Unix()})
+
+	idToken, err := decodeIDToken(raw, exp.Add(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if idToken.Header["kid"] != "k1" || idToken.Claims["sub"] != "123" {
+		t.Errorf("unexpected header or claims: %+v", idToken)
+	}
+
+	if idToken.ExpiresAt != "2025-01-01T00:00:00Z" || !idToken.Expired {
+		t.Errorf("expected expired token at 2025-01-01T00:00:00Z, got %s, %v", idToken.ExpiresAt, idToken.Expired)
+	}
+
+	if _, err := decodeIDToken("not.a-token", exp); err == nil {
+		t.Error("expected an error for a malformed token")
+	}
+}
+
+func TestSendAPIRequestForAuth2OIDC(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+
+	signer := key
+	audience := "my_id"
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.URL.Path {
+		case wellKnownPath:
+			json.NewEncoder(w).Encode(map[string]any{
+				"issuer":         server.URL,
+				"token_endpoint": server.URL + "/token",
+				"jwks_uri":       server.URL + "/jwks",
+			})
+		case "/jwks":
+			json.NewEncoder(w).Encode(jsonWebKeySet{Keys: []jsonWebKey{rsaJWK("k1", key)}})
+		case "/token":
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "t0k3n",
+				"id_token": signJWT(t, "RS256", "k1", signer, map[string]any{
+					"iss": server.URL,
+					"aud": audience,
+					"sub": "user1",
+					"exp": time.Now().Add(time.Hour).Unix(),
+				}),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "oidc.yaml")
+	content := `kind: auth
+auth:
+  type: OAuth2.0
+  grant: client_credentials
+  issuer: "{{.issuer}}"
+  client_id: my_id
+  client_secret: my_secret
+  verify_id_token: true
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	secretsMap := map[string]any{"issuer": server.URL}
+
+	result, err := SendAPIRequestForAuth2(secretsMap, path, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	idToken := result.Responses[0].IDToken
+	if idToken == nil || idToken.Claims["sub"] != "user1" || idToken.Verified == nil || !*idToken.Verified {
+		t.Fatalf("expected verified id_token of user1, got %+v", idToken)
+	}
+
+	saved, err := os.ReadFile(filepath.Join(dir, "oidc_response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(saved), `"signature_verified": true`) {
+		t.Errorf("expected the decoded id_token in the saved response, got %s", saved)
+	}
+
+	// id_token signed by someone else fails the run
+	signer = forged
+
+	result, err = SendAPIRequestForAuth2(secretsMap, path, false)
+	if err == nil || !strings.Contains(err.Error(), "signature is invalid") {
+		t.Fatalf("expected invalid signature error, got %v", err)
+	}
+
+	if idToken := result.Responses[0].IDToken; idToken == nil || idToken.Verified == nil || *idToken.Verified {
+		t.Errorf("expected unverified id_token, got %+v", idToken)
+	}
+
+	// id_token issued to another client fails the run
+	signer, audience = key, "other_client"
+
+	if _, err = SendAPIRequestForAuth2(secretsMap, path, false); err == nil || !strings.Contains(err.Error(), "other_client") {
+		t.Errorf("expected audience error, got %v", err)
+	}
+}
+
+func TestCheckIDTokenClaims(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	issuer := "https://id.example.com"
+
+	tests := []struct {
+		name    string
+		claims  map[string]any
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			claims: map[string]any{"aud": "my_id"},
+		},
+		{
+			name:   "client_id is one of the audiences",
+			claims: map[string]any{"aud": []any{"api", "my_id"}, "azp": "my_id"},
+		},
+		{
+			name:    "issued by another issuer",
+			claims:  map[string]any{"iss": "https://attacker.example.com", "aud": "my_id"},
+			wantErr: "issued by",
+		},
+		{
+			name:    "issued to another client",
+			claims:  map[string]any{"aud": "other_client"},
+			wantErr: "not to the client_id",
+		},
+		{
+			name:    "no audience",
+			claims:  map[string]any{"aud": nil},
+			wantErr: "not to the client_id",
+		},
+		{
+			name:    "authorized for another client",
+			claims:  map[string]any{"aud": []any{"my_id", "other_client"}, "azp": "other_client"},
+			wantErr: "authorized for",
+		},
+		{
+			name:    "expired",
+			claims:  map[string]any{"aud": "my_id", "exp": now.Add(-time.Minute).Unix()},
+			wantErr: "expired",
+		},
+		{
+			name:    "no exp",
+			claims:  map[string]any{"aud": "my_id", "exp": nil},
+			wantErr: "no exp",
+		},
+		{
+			name:    "not valid yet",
+			claims:  map[string]any{"aud": "my_id", "nbf": now.Add(time.Minute).Unix()},
+			wantErr: "not valid before",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := map[string]any{"iss": issuer, "exp": now.Add(time.Hour).Unix()}
+			for key, value := range tc.claims {
+				if value == nil {
+					delete(claims, key)
+				} else {
+					claims[key] = value
+				}
+			}
+
+			idToken, err := decodeIDToken(signJWT(t, "RS256", "k1", key, claims), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = checkIDTokenClaims(idToken, issuer, "my_id", now)
+			if tc.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Errorf("expected error with %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDiscoverProviderIssuerMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":         "https://attacker.example.com",
+			"token_endpoint": "https://attacker.example.com/token",
+		})
+	}))
+	defer server.Close()
+
+	if _, err := discoverProvider(server.URL, nil); err == nil || !strings.Contains(err.Error(), "doesn't match") {
+		t.Errorf("expected issuer mismatch error, got %v", err)
+	}
+}
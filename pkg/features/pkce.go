@@ -0,0 +1,48 @@
+package features
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// pkceMethod is the only code challenge method hulak sends. Plain is not secure, and not used
+const pkceMethod = "S256"
+
+// number of random bytes in the state and the code verifier.
+// 32 bytes encode to a 43 characters verifier, the minimum length in RFC 7636
+const randomBytes = 32
+
+// pkce is the Proof Key for Code Exchange, RFC 7636.
+// The challenge is sent to the authorization url, and the verifier to the access_token_url
+type pkce struct {
+	verifier  string
+	challenge string
+}
+
+// newPKCE generates a random code verifier and its S256 challenge
+func newPKCE() (pkce, error) {
+	verifier, err := randomString()
+	if err != nil {
+		return pkce{}, err
+	}
+
+	return pkce{verifier: verifier, challenge: codeChallenge(verifier)}, nil
+}
+
+// codeChallenge is BASE64URL(SHA256(verifier)) without padding
+func codeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// randomString returns a url safe random string, used for the state and the code verifier
+func randomString() (string, error) {
+	b := make([]byte, randomBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
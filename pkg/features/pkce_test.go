@@ -0,0 +1,45 @@
+package features
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestCodeChallenge(t *testing.T) {
+	// example from RFC 7636, Appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	expected := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	if got := codeChallenge(verifier); got != expected {
+		t.Errorf("expected challenge %s, got %s", expected, got)
+	}
+}
+
+func TestNewPKCE(t *testing.T) {
+	first, err := newPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := newPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.verifier == second.verifier {
+		t.Error("expected a new verifier for each run")
+	}
+
+	// RFC 7636 requires 43 to 128 characters from the unreserved set
+	if len(first.verifier) < 43 || len(first.verifier) > 128 {
+		t.Errorf("unexpected verifier length %d", len(first.verifier))
+	}
+
+	if _, err := base64.RawURLEncoding.DecodeString(first.verifier); err != nil {
+		t.Errorf("expected url safe verifier: %v", err)
+	}
+
+	if first.challenge != codeChallenge(first.verifier) {
+		t.Error("expected challenge of the verifier")
+	}
+}
@@ -0,0 +1,175 @@
+package features
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// authFileLocks makes files that need the token of the same auth file wait for each other,
+// so that the token is refreshed, or the auth file runs, only once
+var authFileLocks sync.Map
+
+func authFileLock(path string) *sync.Mutex {
+	mutex, _ := authFileLocks.LoadOrStore(path, &sync.Mutex{})
+
+	return mutex.(*sync.Mutex)
+}
+
+// Token returns the access token of the auth file, for {{token "auth_file"}}.
+// Stored token is used while it's valid. Expired token is renewed with its refresh_token,
+// and the auth file runs only when there is no token or the refresh fails
+func Token(authFile string, secretsMap map[string]any) (string, error) {
+	path, err := resolveAuthFile(authFile)
+	if err != nil {
+		return "", err
+	}
+
+	lock := authFileLock(path)
+	lock.Lock()
+	defer lock.Unlock()
+
+	token, ok, err := getToken(path)
+	if err != nil {
+		return "", err
+	}
+
+	if ok && token.valid(time.Now()) {
+		return token.AccessToken, nil
+	}
+
+	if ok && token.RefreshToken != "" {
+		refreshed, err := refreshToken(path, secretsMap, token)
+		if err == nil {
+			return refreshed.AccessToken, nil
+		}
+
+		utils.PrintWarning(fmt.Sprintf(
+			"Refreshing the token of '%s' failed, running the auth file: %v",
+			filepath.Base(path), err,
+		))
+	}
+
+	if _, err := SendAPIRequestForAuth2(secretsMap, path, false); err != nil {
+		return "", err
+	}
+
+	token, ok, err = getToken(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !ok {
+		return "", fmt.Errorf("'%s' didn't respond with an access_token", filepath.Base(path))
+	}
+
+	return token.AccessToken, nil
+}
+
+// resolveAuthFile finds the auth file like getValueOf finds files: a path when it has a separator,
+// otherwise the name of a yaml file anywhere in the project
+func resolveAuthFile(authFile string) (string, error) {
+	if authFile == "" {
+		return "", utils.ColorError("auth file for the token can't be empty")
+	}
+
+	cleanName := filepath.Clean(authFile)
+
+	if strings.Contains(cleanName, string(filepath.Separator)) || strings.HasPrefix(cleanName, "..") {
+		if !utils.FileExists(cleanName) {
+			return "", fmt.Errorf("auth file '%s' does not exist", authFile)
+		}
+
+		return filepath.Abs(cleanName)
+	}
+
+	matches, err := utils.ListMatchingFiles(cleanName)
+	if err != nil {
+		return "", err
+	}
+
+	var yamlFiles []string
+
+	for _, match := range matches {
+		ext := strings.ToLower(filepath.Ext(match))
+		if ext == utils.YAML || ext == utils.YML {
+			yamlFiles = append(yamlFiles, match)
+		}
+	}
+
+	if len(yamlFiles) == 0 {
+		return "", fmt.Errorf("could not find the auth file '%s'", authFile)
+	}
+
+	if len(yamlFiles) > 1 {
+		utils.PrintWarning(fmt.Sprintf("Multiple '%s'. Using %s", authFile, yamlFiles[0]))
+	}
+
+	return filepath.Abs(yamlFiles[0])
+}
+
+// refreshToken renews the token with the refresh_token grant, at the access_token_url of the auth file,
+// and saves the new token. Refresh token is kept when the provider doesn't rotate it
+func refreshToken(
+	path string,
+	secretsMap map[string]any,
+	token storedToken,
+) (storedToken, error) {
+	file, err := loadAuthFile(path, secretsMap)
+	if err != nil {
+		return storedToken{}, err
+	}
+
+	apiInfo, err := file.config.PrepareStruct(map[string]string{
+		"grant_type":    "refresh_token",
+		"refresh_token": token.RefreshToken,
+	})
+	if err != nil {
+		return storedToken{}, err
+	}
+
+	apiInfo.Client = file.client
+
+	resp, err := apicalls.StandardCall(apiInfo, false)
+	if err != nil {
+		return storedToken{}, err
+	}
+
+	refreshed, ok := tokenFromResponse(resp.Exchange, time.Now())
+	if !ok {
+		status := 0
+		if resp.Exchange != nil {
+			status = resp.Exchange.StatusCode
+		}
+
+		return storedToken{}, fmt.Errorf("refresh_token grant failed with status %d", status)
+	}
+
+	if refreshed.RefreshToken == "" {
+		refreshed.RefreshToken = token.RefreshToken
+	}
+
+	return refreshed, setToken(path, refreshed)
+}
+
+// saveToken keeps the token of a successful auth file run, for {{token "auth_file"}}
+func saveToken(path string, exchange *apicalls.Exchange) {
+	token, ok := tokenFromResponse(exchange, time.Now())
+	if !ok {
+		return
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	if err := setToken(absPath, token); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Could not save the token of '%s': %v", filepath.Base(path), err))
+	}
+}
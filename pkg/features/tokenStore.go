@@ -0,0 +1,193 @@
+package features
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+const (
+	// tokenStoreFile keeps the tokens of the auth files between runs, relative to the project root
+	tokenStoreFile = utils.StateDir + "/tokens.json"
+	// tokenStorePer keeps the tokens readable only by the user
+	tokenStorePer os.FileMode = 0o600
+	// expirySkew renews tokens a little before they expire, so they don't expire in flight
+	expirySkew = 30 * time.Second
+)
+
+// tokenStoreMu guards the token store between goroutines of the same run
+var tokenStoreMu sync.Mutex
+
+// storedToken is the token of an auth file, as saved in the token store
+type storedToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	TokenType    string    `json:"token_type,omitempty"`
+	ExpiresAt    time.Time `json:"expires_at,omitzero"`
+}
+
+// valid reports whether the token can still be used. Tokens without expires_in never expire
+func (t storedToken) valid(now time.Time) bool {
+	if t.AccessToken == "" {
+		return false
+	}
+
+	return t.ExpiresAt.IsZero() || now.Add(expirySkew).Before(t.ExpiresAt)
+}
+
+// tokenStore has the tokens by environment and then by the absolute path of the auth file
+type tokenStore map[string]map[string]storedToken
+
+func tokenStorePath() (string, error) {
+	return utils.CreatePath(tokenStoreFile)
+}
+
+// loadTokens reads the token store. Missing store is an empty store
+func loadTokens() (tokenStore, error) {
+	path, err := tokenStorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(tokenStore), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	store := make(tokenStore)
+	if err := json.Unmarshal(content, &store); err != nil {
+		return nil, fmt.Errorf("token store '%s' is corrupt: %w", path, err)
+	}
+
+	return store, nil
+}
+
+// getToken returns the stored token of the auth file in the current environment
+func getToken(authFile string) (storedToken, bool, error) {
+	tokenStoreMu.Lock()
+	defer tokenStoreMu.Unlock()
+
+	store, err := loadTokens()
+	if err != nil {
+		return storedToken{}, false, err
+	}
+
+	token, ok := store[envparser.CurrentEnvName()][authFile]
+
+	return token, ok, nil
+}
+
+// setToken saves the token of the auth file for the current environment
+func setToken(authFile string, token storedToken) error {
+	tokenStoreMu.Lock()
+	defer tokenStoreMu.Unlock()
+
+	store, err := loadTokens()
+	if err != nil {
+		return err
+	}
+
+	env := envparser.CurrentEnvName()
+	if store[env] == nil {
+		store[env] = make(map[string]storedToken)
+	}
+
+	store[env][authFile] = token
+
+	content, err := json.MarshalIndent(store, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path, err := tokenStorePath()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), utils.DirPer); err != nil {
+		return err
+	}
+
+	// the directory ignores itself, so the tokens are not committed, even without 'hulak init'
+	ignore := filepath.Join(filepath.Dir(path), ".gitignore")
+	if !utils.FileExists(ignore) {
+		if err := os.WriteFile(ignore, []byte("*\n"), utils.FilePer); err != nil {
+			return err
+		}
+	}
+
+	return os.WriteFile(path, content, tokenStorePer)
+}
+
+// tokenFromResponse reads the token from a successful token response.
+// Returns false when the response has no access_token
+func tokenFromResponse(exchange *apicalls.Exchange, now time.Time) (storedToken, bool) {
+	if exchange == nil || exchange.StatusCode < 200 || exchange.StatusCode > 299 {
+		return storedToken{}, false
+	}
+
+	var body map[string]any
+
+	switch parsed := exchange.Body.(type) {
+	case map[string]any:
+		body = parsed
+	case string:
+		// some providers, like GitHub, reply with a form unless json is asked for
+		form, err := url.ParseQuery(parsed)
+		if err != nil {
+			return storedToken{}, false
+		}
+
+		body = make(map[string]any, len(form))
+		for key := range form {
+			body[key] = form.Get(key)
+		}
+	default:
+		return storedToken{}, false
+	}
+
+	accessToken, _ := body["access_token"].(string)
+	if accessToken == "" {
+		return storedToken{}, false
+	}
+
+	token := storedToken{AccessToken: accessToken}
+	token.RefreshToken, _ = body["refresh_token"].(string)
+	token.TokenType, _ = body["token_type"].(string)
+
+	if seconds, ok := expiresIn(body["expires_in"]); ok {
+		token.ExpiresAt = now.Add(time.Duration(seconds) * time.Second)
+	}
+
+	return token, true
+}
+
+// expiresIn reads expires_in, which is a number, or a string with some providers
+func expiresIn(val any) (int64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return int64(v), v > 0
+	case int:
+		return int64(v), v > 0
+	case string:
+		seconds, err := strconv.ParseInt(v, 10, 64)
+
+		return seconds, err == nil && seconds > 0
+	default:
+		return 0, false
+	}
+}
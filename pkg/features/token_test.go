@@ -0,0 +1,291 @@
+package features
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+func TestTokenFromResponse(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		exchange *apicalls.Exchange
+		ok       bool
+		expected storedToken
+	}{
+		{
+			name: "json body",
+			exchange: &apicalls.Exchange{StatusCode: 200, Body: map[string]any{
+				"access_token":  "abc",
+				"refresh_token": "def",
+				"token_type":    "Bearer",
+				"expires_in":    float64(60),
+			}},
+			ok: true,
+			expected: storedToken{
+				AccessToken:  "abc",
+				RefreshToken: "def",
+				TokenType:    "Bearer",
+				ExpiresAt:    now.Add(time.Minute),
+			},
+		},
+		{
+			name: "form body with expires_in as string",
+			exchange: &apicalls.Exchange{
+				StatusCode: 200,
+				Body:       "access_token=abc&expires_in=120&token_type=bearer",
+			},
+			ok: true,
+			expected: storedToken{
+				AccessToken: "abc",
+				TokenType:   "bearer",
+				ExpiresAt:   now.Add(2 * time.Minute),
+			},
+		},
+		{
+			name:     "no expires_in",
+			exchange: &apicalls.Exchange{StatusCode: 200, Body: map[string]any{"access_token": "abc"}},
+			ok:       true,
+			expected: storedToken{AccessToken: "abc"},
+		},
+		{
+			name: "error status",
+			exchange: &apicalls.Exchange{
+				StatusCode: 400,
+				Body:       map[string]any{"access_token": "abc"},
+			},
+		},
+		{
+			name:     "no access token",
+			exchange: &apicalls.Exchange{StatusCode: 200, Body: map[string]any{"error": "invalid_grant"}},
+		},
+		{name: "no exchange"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			token, ok := tokenFromResponse(tc.exchange, now)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %v, got %v", tc.ok, ok)
+			}
+
+			if token != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, token)
+			}
+		})
+	}
+}
+
+func TestStoredTokenValid(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token storedToken
+		valid bool
+	}{
+		{"never expires", storedToken{AccessToken: "abc"}, true},
+		{"expires later", storedToken{AccessToken: "abc", ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", storedToken{AccessToken: "abc", ExpiresAt: now.Add(-time.Minute)}, false},
+		{"expires within the skew", storedToken{AccessToken: "abc", ExpiresAt: now.Add(expirySkew / 2)}, false},
+		{"empty", storedToken{}, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.token.valid(now); got != tc.valid {
+				t.Errorf("expected %v, got %v", tc.valid, got)
+			}
+		})
+	}
+}
+
+func TestToken(t *testing.T) {
+	requests := map[string]int{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Fatal(err)
+		}
+
+		grant := r.PostForm.Get("grant_type")
+		requests[grant]++
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case grant == "client_credentials":
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token":  "login_token",
+				"refresh_token": "refresh1",
+				"expires_in":    3600,
+			})
+		case grant == "refresh_token" && r.PostForm.Get("refresh_token") == "refresh1":
+			json.NewEncoder(w).Encode(map[string]any{
+				"access_token": "refreshed_token",
+				"expires_in":   3600,
+			})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]any{"error": "invalid_grant"})
+		}
+	}))
+	defer server.Close()
+
+	// the store is keyed by the path from the working directory, which may be a symlink of the temp dir
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+	t.Setenv(utils.EnvKey, "global")
+
+	path := filepath.Join(dir, "machine.yaml")
+	content := `kind: auth
+auth:
+  type: OAuth2.0
+  grant: client_credentials
+  access_token_url: "{{.tokenUrl}}"
+  client_id: my_id
+  client_secret: my_secret
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	secretsMap := map[string]any{"tokenUrl": server.URL}
+
+	assertToken := func(expected string, grants map[string]int) {
+		t.Helper()
+
+		token, err := Token("machine", secretsMap)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if token != expected {
+			t.Errorf("expected token '%s', got '%s'", expected, token)
+		}
+
+		for grant, count := range grants {
+			if requests[grant] != count {
+				t.Errorf("expected %d '%s' requests, got %d", count, grant, requests[grant])
+			}
+		}
+	}
+
+	// no stored token, so the auth file runs
+	assertToken("login_token", map[string]int{"client_credentials": 1})
+
+	// valid stored token is reused
+	assertToken("login_token", map[string]int{"client_credentials": 1, "refresh_token": 0})
+
+	info, err := os.Stat(filepath.Join(dir, tokenStoreFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != tokenStorePer {
+		t.Errorf("expected token store permission %v, got %v", tokenStorePer, info.Mode().Perm())
+	}
+
+	// expired token is refreshed, and the refresh token is kept since it wasn't rotated
+	expired := storedToken{
+		AccessToken:  "login_token",
+		RefreshToken: "refresh1",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+	}
+	if err := setToken(path, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	assertToken("refreshed_token", map[string]int{"client_credentials": 1, "refresh_token": 1})
+
+	stored, _, err := getToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.RefreshToken != "refresh1" {
+		t.Errorf("expected the refresh token to be kept, got '%s'", stored.RefreshToken)
+	}
+
+	// failed refresh falls back to the auth file
+	expired.RefreshToken = "revoked"
+	if err := setToken(path, expired); err != nil {
+		t.Fatal(err)
+	}
+
+	assertToken("login_token", map[string]int{"client_credentials": 2, "refresh_token": 2})
+
+	// tokens are kept by environment
+	t.Setenv(utils.EnvKey, "staging")
+	assertToken("login_token", map[string]int{"client_credentials": 3})
+}
+
+func TestSetTokenIgnoresStore(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	if err := setToken(filepath.Join(dir, "auth.yaml"), storedToken{AccessToken: "t0k3n"}); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, utils.StateDir, ".gitignore"))
+	if err != nil {
+		t.Fatalf("expected a .gitignore in the token store directory: %v", err)
+	}
+
+	if string(content) != "*\n" {
+		t.Errorf("expected the directory to ignore everything, got %q", content)
+	}
+}
+
+func TestResolveAuthFile(t *testing.T) {
+	// the store is keyed by the path from the working directory, which may be a symlink of the temp dir
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+
+	if err := os.MkdirAll(filepath.Join(dir, "auth"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "auth", "login.yml")
+	if err := os.WriteFile(path, []byte("kind: auth"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// response of the auth file shouldn't be mistaken for it
+	if err := os.WriteFile(filepath.Join(dir, "login.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"login", filepath.Join("auth", "login.yml")} {
+		got, err := resolveAuthFile(name)
+		if err != nil {
+			t.Fatalf("unexpected error for '%s': %v", name, err)
+		}
+
+		if got != path {
+			t.Errorf("expected '%s', got '%s'", path, got)
+		}
+	}
+
+	if _, err := resolveAuthFile("missing"); err == nil {
+		t.Error("expected an error for a missing auth file")
+	}
+}
@@ -0,0 +1,259 @@
+// Package features have all the additional features hulak supports
+package features
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// outcome of a workflow step, shown in the summary
+const (
+	stepPassed  = "passed"
+	stepFailed  = "failed"
+	stepSkipped = "skipped"
+	stepNotRun  = "not run"
+)
+
+// values of the 'if' condition that skip the step
+var falsyConditions = map[string]bool{
+	"":           true,
+	"false":      true,
+	"0":          true,
+	"<no value>": true,
+}
+
+// stepResult is the outcome of a single step in the workflow
+type stepResult struct {
+	name       string
+	outcome    string
+	status     int
+	iterations int
+	duration   time.Duration
+	responses  []apicalls.CustomResponse
+	err        error
+}
+
+// RunWorkflow runs the steps of the workflow file one after the other.
+// Values captured by a step are available to the steps after it as {{.name}}, and the status
+// and body of each step as {{.steps.stepName.status}}, {{.steps.stepName.body}}, or {{.prev.status}} for the previous step.
+// Workflow stops at the first failed step. A failed step with continue_on_error doesn't stop or fail the workflow.
+// Returns the responses and captured values of all steps, and the error of the first failed step
+func RunWorkflow(secretsMap map[string]any, path string, debug, failNon2xx bool) (apicalls.RunResult, error) {
+	workflow, err := yamlparser.FinalStructForWorkflow(path)
+	if err != nil {
+		return apicalls.RunResult{}, err
+	}
+
+	name := workflow.Name
+	if name == "" {
+		name = filepath.Base(path)
+	}
+
+	utils.PrintInfo(fmt.Sprintf("Running workflow '%s'", name))
+
+	vars := utils.CopyEnvMap(secretsMap)
+	steps := make(map[string]any)
+	vars["steps"] = steps
+
+	runResult := apicalls.RunResult{Captured: make(map[string]any)}
+	results := make([]stepResult, 0, len(workflow.Steps))
+
+	var firstErr error
+
+	for i := range workflow.Steps {
+		step := &workflow.Steps[i]
+
+		if firstErr != nil {
+			results = append(results, stepResult{name: step.Name, outcome: stepNotRun})
+
+			continue
+		}
+
+		result, state, captured := runStep(step, path, vars, debug, failNon2xx)
+		results = append(results, result)
+		runResult.Responses = append(runResult.Responses, result.responses...)
+
+		steps[step.Name] = state
+		vars["prev"] = state
+
+		if result.err != nil {
+			utils.PrintRed(fmt.Sprintf("Step '%s' failed: %v", step.Name, result.err))
+
+			if !step.ContinueOnError {
+				firstErr = fmt.Errorf("workflow '%s' failed at step '%s': %w", name, step.Name, result.err)
+			}
+
+			// values captured by a failed step are not passed to the steps after it
+			continue
+		}
+
+		maps.Copy(vars, captured)
+		maps.Copy(runResult.Captured, captured)
+	}
+
+	printWorkflowSummary(os.Stdout, name, results)
+
+	return runResult, firstErr
+}
+
+// runStep runs the step once, or once for each item when the step has foreach.
+// Returns the result for the summary, the state available to the next steps, and the captured values
+func runStep(
+	step *yamlparser.WorkflowStep,
+	workflowPath string,
+	vars map[string]any,
+	debug, failNon2xx bool,
+) (stepResult, map[string]any, map[string]any) {
+	result := stepResult{name: step.Name, outcome: stepPassed}
+	state := map[string]any{"status": 0, "body": nil, "skipped": false, "failed": false}
+	captured := make(map[string]any)
+
+	fail := func(err error) (stepResult, map[string]any, map[string]any) {
+		result.outcome = stepFailed
+		result.err = err
+		state["failed"] = true
+
+		return result, state, captured
+	}
+
+	if step.If != "" {
+		run, err := conditionMet(step.If, vars)
+		if err != nil {
+			return fail(fmt.Errorf("error evaluating 'if': %w", err))
+		}
+
+		if !run {
+			result.outcome = stepSkipped
+			state["skipped"] = true
+
+			return result, state, captured
+		}
+	}
+
+	items := []any{nil}
+
+	if step.HasLoop() {
+		loopItems, err := step.LoopItems(vars)
+		if err != nil {
+			return fail(err)
+		}
+
+		items = loopItems
+	}
+
+	responsePath := step.ResponsePath(workflowPath)
+	start := time.Now()
+
+	for index, item := range items {
+		iterVars := utils.CopyEnvMap(vars)
+		maps.Copy(iterVars, captured)
+
+		iterPath := responsePath
+		if step.HasLoop() {
+			iterVars[step.As] = item
+			iterVars["index"] = index
+			iterPath = loopResponsePath(responsePath, index+1)
+		}
+
+		apiConfig, err := step.APICallFile(workflowPath, iterVars, Token)
+		if err != nil {
+			result.duration = time.Since(start)
+
+			return fail(err)
+		}
+
+		resp, iterCaptured, err := apicalls.RunAPICall(apiConfig, iterVars, Token, iterPath, debug, failNon2xx)
+		result.iterations++
+		result.responses = append(result.responses, apicalls.ResponsesOf(resp)...)
+
+		maps.Copy(captured, iterCaptured)
+
+		if resp.Exchange != nil {
+			result.status = resp.Exchange.StatusCode
+			state["status"] = resp.Exchange.StatusCode
+			state["body"] = yamlparser.TemplateValue(resp.Exchange.Body)
+		}
+
+		if err != nil {
+			result.duration = time.Since(start)
+
+			return fail(err)
+		}
+	}
+
+	result.duration = time.Since(start)
+
+	return result, state, captured
+}
+
+// conditionMet evaluates the 'if' of the step. Empty, false, 0 and missing values are false
+func conditionMet(condition string, vars map[string]any) (bool, error) {
+	evaluated, err := envparser.SubstituteVariables(condition, vars, Token)
+	if err != nil {
+		return false, err
+	}
+
+	value := strings.ToLower(strings.TrimSpace(fmt.Sprint(evaluated)))
+
+	return !falsyConditions[value], nil
+}
+
+// loopResponsePath adds the iteration number to the path, so that each iteration saves its own response
+func loopResponsePath(path string, iteration int) string {
+	ext := filepath.Ext(path)
+
+	return fmt.Sprintf("%s_%d%s", strings.TrimSuffix(path, ext), iteration, ext)
+}
+
+// printWorkflowSummary prints the outcome of each step as a table
+func printWorkflowSummary(out io.Writer, name string, results []stepResult) {
+	passed := 0
+
+	for _, result := range results {
+		if result.outcome == stepPassed {
+			passed++
+		}
+	}
+
+	fmt.Fprintf(out, "\nWorkflow '%s': %d of %d steps passed\n", name, passed, len(results))
+
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "STEP\tRESULT\tSTATUS\tRUNS\tDURATION")
+
+	for _, result := range results {
+		mark := utils.CheckMark
+		if result.outcome == stepFailed {
+			mark = utils.CrossMark
+		}
+
+		status := "-"
+		if result.status != 0 {
+			status = fmt.Sprint(result.status)
+		}
+
+		duration := "-"
+		if result.iterations > 0 {
+			duration = result.duration.Round(time.Millisecond).String()
+		}
+
+		if result.outcome == stepSkipped || result.outcome == stepNotRun {
+			mark = "-"
+		}
+
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%d\t%s\n",
+			mark, result.name, result.outcome, status, result.iterations, duration)
+	}
+
+	w.Flush()
+}
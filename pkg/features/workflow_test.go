@@ -0,0 +1,131 @@
+package features
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConditionMet(t *testing.T) {
+	vars := map[string]any{
+		"steps": map[string]any{
+			"create": map[string]any{"status": 201, "body": map[string]any{"id": 4}},
+		},
+		"prev":    map[string]any{"status": 404},
+		"enabled": "false",
+		"userId":  "abc",
+	}
+
+	tests := []struct {
+		condition string
+		expected  bool
+		expectErr bool
+	}{
+		{condition: "{{eq .steps.create.status 201}}", expected: true},
+		{condition: "{{eq .steps.create.body.id 4}}", expected: true},
+		{condition: "{{eq .prev.status 200}}", expected: false},
+		{condition: "{{.enabled}}", expected: false},
+		{condition: "{{.userId}}", expected: true},
+		{condition: "{{if .userId}}1{{else}}0{{end}}", expected: true},
+		{condition: "{{.missing}}", expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.condition, func(t *testing.T) {
+			got, err := conditionMet(tc.condition, vars)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestLoopResponsePath(t *testing.T) {
+	if got := loopResponsePath("collection/flow_create.yaml", 2); got != "collection/flow_create_2.yaml" {
+		t.Errorf("unexpected path %s", got)
+	}
+}
+
+func TestPrintWorkflowSummary(t *testing.T) {
+	var out bytes.Buffer
+
+	printWorkflowSummary(&out, "users", []stepResult{
+		{name: "create", outcome: stepPassed, status: 201, iterations: 1},
+		{name: "update", outcome: stepSkipped},
+		{name: "delete", outcome: stepFailed, status: 500, iterations: 1},
+		{name: "verify", outcome: stepNotRun},
+	})
+
+	summary := out.String()
+
+	for _, expected := range []string{"1 of 4 steps passed", "create", "skipped", "500", "not run"} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary to contain '%s', got:\n%s", expected, summary)
+		}
+	}
+}
+
+func TestRunWorkflowFailedStepCaptures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id": "` + strings.TrimPrefix(r.URL.Path, "/") + `"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "workflow.yaml")
+	content := `kind: Workflow
+Steps:
+  - Name: create
+    request:
+      method: GET
+      url: "{{.baseUrl}}/created"
+    capture:
+      - name: createdId
+        path: id
+    assert:
+      status: 201
+    continue_on_error: true
+  - name: fetch
+    request:
+      method: GET
+      url: "{{.baseUrl}}/fetched"
+    capture:
+      - name: fetchedId
+        path: id
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runResult, err := RunWorkflow(map[string]any{"baseUrl": server.URL}, path, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := runResult.Captured["createdId"]; ok {
+		t.Errorf("expected no values from the failed step, got %v", runResult.Captured)
+	}
+
+	if runResult.Captured["fetchedId"] != "fetched" {
+		t.Errorf("expected fetchedId from the passed step, got %v", runResult.Captured)
+	}
+}
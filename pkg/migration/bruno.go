@@ -0,0 +1,481 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// Files and directories of a Bruno collection
+const (
+	brunoConfig       = "bruno.json"
+	brunoCollection   = "collection.bru"
+	brunoFolder       = "folder.bru"
+	brunoEnvironments = "environments"
+	brunoExt          = ".bru"
+)
+
+// brunoBlock is a block of a .bru file, like `headers { ... }` or `vars:secret [ ... ]`.
+// Text keeps the content of the block without its indentation, for bodies and scripts.
+// Pairs are the `key: value` lines, and the lines of a list
+type brunoBlock struct {
+	Text  string
+	Pairs []brunoPair
+}
+
+// brunoPair is a line of a block. Lines starting with ~ are disabled
+type brunoPair struct {
+	Key      string
+	Value    string
+	Disabled bool
+}
+
+// brunoFile maps the name of each block to the block
+type brunoFile map[string]brunoBlock
+
+// value returns the value of the key in the block, or an empty string
+func (b brunoFile) value(block, key string) string {
+	for _, pair := range b[block].Pairs {
+		if pair.Key == key && !pair.Disabled {
+			return pair.Value
+		}
+	}
+
+	return ""
+}
+
+// isBruno determines if the path is a Bruno collection, a directory with bruno.json
+func isBruno(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.IsDir() {
+		return false
+	}
+
+	_, err = os.Stat(filepath.Join(path, brunoConfig))
+
+	return err == nil
+}
+
+// parseBru splits the content of a .bru file into blocks.
+// Blocks open with `name {` or `name [` and close with `}` or `]` at the start of a line
+func parseBru(content string) brunoFile {
+	blocks := make(brunoFile)
+
+	var (
+		name  string
+		lines []string
+		open  bool
+	)
+
+	for line := range strings.SplitSeq(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		if !open {
+			trimmed := strings.TrimSpace(line)
+			if strings.HasSuffix(trimmed, "{") || strings.HasSuffix(trimmed, "[") {
+				name = strings.TrimSpace(trimmed[:len(trimmed)-1])
+				lines = nil
+				open = true
+			}
+
+			continue
+		}
+
+		if line == "}" || line == "]" {
+			blocks[name] = brunoBlockOf(lines)
+			open = false
+
+			continue
+		}
+
+		lines = append(lines, strings.TrimPrefix(line, "  "))
+	}
+
+	return blocks
+}
+
+func brunoBlockOf(lines []string) brunoBlock {
+	block := brunoBlock{Text: strings.TrimSpace(strings.Join(lines, "\n"))}
+
+	for _, line := range lines {
+		line = strings.TrimSuffix(strings.TrimSpace(line), ",")
+		if line == "" {
+			continue
+		}
+
+		pair := brunoPair{}
+		if strings.HasPrefix(line, "~") {
+			pair.Disabled = true
+			line = line[1:]
+		}
+
+		key, value, _ := strings.Cut(line, ":")
+		pair.Key = strings.TrimSpace(key)
+		pair.Value = strings.TrimSpace(value)
+
+		block.Pairs = append(block.Pairs, pair)
+	}
+
+	return block
+}
+
+func readBru(path string) (brunoFile, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading '%s': %w", path, err)
+	}
+
+	return parseBru(string(content)), nil
+}
+
+// migrateBruno migrates a Bruno collection directory like a Postman collection.
+// Each environment in the environments directory is appended to its own env file
+func migrateBruno(collectionDir string) error {
+	var config struct {
+		Name string `json:"name"`
+	}
+
+	content, err := os.ReadFile(filepath.Join(collectionDir, brunoConfig))
+	if err != nil {
+		return fmt.Errorf("error reading '%s': %w", brunoConfig, err)
+	}
+
+	if err := json.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("error parsing '%s': %w", brunoConfig, err)
+	}
+
+	if config.Name == "" {
+		config.Name = filepath.Base(collectionDir)
+	}
+
+	if err := migrateBrunoEnvironments(filepath.Join(collectionDir, brunoEnvironments), config.Name); err != nil {
+		return err
+	}
+
+	var collectionAuth *PMAuth
+
+	if collection, err := readBru(filepath.Join(collectionDir, brunoCollection)); err == nil {
+		collectionAuth = brunoAuthOf(collection, collection.value("auth", "mode"), nil)
+	}
+
+	items, err := brunoItems(collectionDir, collectionAuth)
+	if err != nil {
+		return err
+	}
+
+	dirPath, err := utils.CreatePath(sanitizeKey(config.Name))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CreateDir(dirPath); err != nil {
+		return err
+	}
+
+	if err := processItems(items, dirPath); err != nil {
+		return err
+	}
+
+	utils.PrintGreen("Bruno Migration Successful! " + utils.CheckMark)
+
+	return nil
+}
+
+func migrateBrunoEnvironments(dir, collectionName string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("error reading '%s': %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != brunoExt {
+			continue
+		}
+
+		file, err := readBru(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+
+		env := Environment{Name: strings.TrimSuffix(entry.Name(), brunoExt)}
+		for _, pair := range file["vars"].Pairs {
+			env.Values = append(env.Values, EnvValues{Key: pair.Key, Value: pair.Value, Enabled: !pair.Disabled})
+		}
+
+		// values of secrets are not in the collection, so they are added as comments
+		for _, pair := range file["vars:secret"].Pairs {
+			env.Values = append(env.Values, EnvValues{Key: pair.Key, Enabled: !pair.Disabled})
+		}
+
+		if err := migrateEnv(env, collectionName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// brunoItems converts the folders and requests of the directory, in the order of their seq.
+// Requests with `auth: inherit` use the auth of the closest folder.bru or collection.bru
+func brunoItems(dir string, parentAuth *PMAuth) ([]ItemOrReq, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading '%s': %w", dir, err)
+	}
+
+	type sortedItem struct {
+		seq  float64
+		item ItemOrReq
+	}
+
+	var items []sortedItem
+
+	for _, entry := range entries {
+		name := entry.Name()
+		path := filepath.Join(dir, name)
+
+		if strings.HasPrefix(name, ".") || name == "node_modules" {
+			continue
+		}
+
+		if entry.IsDir() {
+			if name == brunoEnvironments {
+				continue
+			}
+
+			folder := ItemOrReq{Name: name}
+			folderAuth := parentAuth
+			seq := 0.0
+
+			if file, err := readBru(filepath.Join(path, brunoFolder)); err == nil {
+				if folderName := file.value("meta", "name"); folderName != "" {
+					folder.Name = folderName
+				}
+
+				seq, _ = strconv.ParseFloat(file.value("meta", "seq"), 64)
+				// folders without an auth block inherit the auth
+				if mode := file.value("auth", "mode"); mode != "" {
+					folderAuth = brunoAuthOf(file, mode, parentAuth)
+				}
+			}
+
+			folder.Item, err = brunoItems(path, folderAuth)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, sortedItem{seq: seq, item: folder})
+
+			continue
+		}
+
+		if filepath.Ext(name) != brunoExt || name == brunoFolder || name == brunoCollection {
+			continue
+		}
+
+		file, err := readBru(path)
+		if err != nil {
+			return nil, err
+		}
+
+		request, ok := brunoRequestOf(file, parentAuth)
+		if !ok {
+			continue
+		}
+
+		requestName := file.value("meta", "name")
+		if requestName == "" {
+			requestName = strings.TrimSuffix(name, brunoExt)
+		}
+
+		seq, _ := strconv.ParseFloat(file.value("meta", "seq"), 64)
+		items = append(items, sortedItem{seq: seq, item: ItemOrReq{Name: requestName, Request: request}})
+	}
+
+	slices.SortStableFunc(items, func(a, b sortedItem) int {
+		switch {
+		case a.seq < b.seq:
+			return -1
+		case a.seq > b.seq:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	result := make([]ItemOrReq, 0, len(items))
+	for _, each := range items {
+		result = append(result, each.item)
+	}
+
+	return result, nil
+}
+
+// replacePathParam replaces the path param :key of the url with the value.
+// Only whole segments are replaced, so :id doesn't change /:idx/
+func replacePathParam(rawURL, key, value string) string {
+	re := regexp.MustCompile(`:` + regexp.QuoteMeta(key) + `([/?]|$)`)
+
+	return re.ReplaceAllStringFunc(rawURL, func(match string) string {
+		return value + match[len(key)+1:]
+	})
+}
+
+// brunoRequestOf converts the http request of the .bru file. Files of graphql requests
+// also use the method blocks, but other types of requests, like grpc, are skipped
+func brunoRequestOf(file brunoFile, parentAuth *PMAuth) (*Request, bool) {
+	if requestType := file.value("meta", "type"); requestType != "" && requestType != "http" &&
+		requestType != "graphql" {
+		return nil, false
+	}
+
+	var method string
+
+	for _, each := range []string{"get", "post", "put", "patch", "delete", "options", "head", "connect", "trace"} {
+		if _, ok := file[each]; ok {
+			method = each
+
+			break
+		}
+	}
+
+	if method == "" {
+		return nil, false
+	}
+
+	rawURL := file.value(method, "url")
+
+	for _, param := range file["params:path"].Pairs {
+		rawURL = replacePathParam(rawURL, param.Key, param.Value)
+	}
+
+	pmURL := &PMURL{Raw: yamlparser.URL(rawURL), Query: queryOf(rawURL)}
+
+	for _, block := range []string{"params:query", "query"} {
+		if query, ok := file[block]; ok {
+			pmURL.Query = nil
+
+			for _, param := range query.Pairs {
+				if !param.Disabled {
+					pmURL.Query = append(pmURL.Query, KeyValuePair{Key: param.Key, Value: param.Value})
+				}
+			}
+		}
+	}
+
+	request := &Request{
+		Method: yamlparser.HTTPMethodType(strings.ToUpper(method)),
+		URL:    pmURL,
+		Auth:   brunoAuthOf(file, file.value(method, "auth"), parentAuth),
+	}
+
+	for _, header := range file["headers"].Pairs {
+		if !header.Disabled {
+			request.Header = append(request.Header, KeyValuePair{Key: header.Key, Value: header.Value})
+		}
+	}
+
+	request.Body = brunoBodyOf(file, file.value(method, "body"))
+
+	contentTypes := map[string]string{"json": "application/json", "xml": "application/xml"}
+	if contentType, ok := contentTypes[file.value(method, "body")]; ok && !slices.ContainsFunc(
+		request.Header,
+		func(header KeyValuePair) bool { return strings.EqualFold(header.Key, "content-type") },
+	) {
+		request.Header = append(request.Header, KeyValuePair{Key: "Content-Type", Value: contentType})
+	}
+
+	return request, true
+}
+
+func brunoBodyOf(file brunoFile, mode string) *Body {
+	pairsOf := func(block string) []KeyValuePair {
+		var pairs []KeyValuePair
+
+		for _, pair := range file[block].Pairs {
+			if pair.Disabled {
+				continue
+			}
+
+			value := pair.Value
+			if strings.HasPrefix(value, "@file(") && strings.HasSuffix(value, ")") {
+				value = strings.TrimSuffix(strings.TrimPrefix(value, "@file("), ")")
+			}
+
+			pairs = append(pairs, KeyValuePair{Key: pair.Key, Value: value})
+		}
+
+		return pairs
+	}
+
+	switch mode {
+	case "json", "text", "xml", "sparql":
+		return &Body{Mode: "raw", Raw: file["body:"+mode].Text}
+	case "formUrlEncoded":
+		return &Body{Mode: "urlencoded", URLEncoded: pairsOf("body:form-urlencoded")}
+	case "multipartForm":
+		return &Body{Mode: "formdata", FormData: pairsOf("body:multipart-form")}
+	case "graphql":
+		return &Body{Mode: "graphql", GraphQL: &graphQl{
+			Query:     file["body:graphql"].Text,
+			Variables: file["body:graphql:vars"].Text,
+		}}
+	default:
+		return nil
+	}
+}
+
+// brunoAuthOf converts the auth of the mode to Postman's auth, so authToYaml converts both
+func brunoAuthOf(file brunoFile, mode string, parentAuth *PMAuth) *PMAuth {
+	params := func(block string, keys map[string]string) []PMAuthParam {
+		var result []PMAuthParam
+		for _, pair := range file[block].Pairs {
+			if key, ok := keys[pair.Key]; ok && !pair.Disabled {
+				result = append(result, PMAuthParam{Key: key, Value: pair.Value})
+			}
+		}
+
+		return result
+	}
+
+	credentials := map[string]string{"username": "username", "password": "password"}
+
+	switch mode {
+	case "inherit":
+		return parentAuth
+	case "basic":
+		return &PMAuth{Type: "basic", Basic: params("auth:basic", credentials)}
+	case "digest":
+		return &PMAuth{Type: "digest", Digest: params("auth:digest", credentials)}
+	case "bearer":
+		return &PMAuth{Type: "bearer", Bearer: params("auth:bearer", map[string]string{"token": "token"})}
+	case "apikey":
+		in := yamlparser.APIKeyInHeader
+		if file.value("auth:apikey", "placement") == "queryparams" {
+			in = yamlparser.APIKeyInQuery
+		}
+
+		return &PMAuth{Type: "apikey", APIKey: append(
+			params("auth:apikey", map[string]string{"key": "key", "value": "value"}),
+			PMAuthParam{Key: "in", Value: in},
+		)}
+	case "awsv4":
+		return &PMAuth{Type: "awsv4", AWSV4: params("auth:awsv4", map[string]string{
+			"accessKeyId":     "accessKey",
+			"secretAccessKey": "secretKey",
+			"sessionToken":    "sessionToken",
+			"region":          "region",
+			"service":         "service",
+		})}
+	default:
+		return nil
+	}
+}
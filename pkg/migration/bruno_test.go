@@ -0,0 +1,223 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseBru(t *testing.T) {
+	content := `meta {
+  name: Get user
+  seq: 2
+}
+
+get {
+  url: {{baseUrl}}/users/:id?page=1
+  body: json
+}
+
+params:query {
+  page: 1
+  ~debug: true
+}
+
+body:json {
+  {
+    "name": "{{name}}"
+  }
+}
+
+vars:secret [
+  token,
+  apiKey
+]
+`
+
+	file := parseBru(content)
+
+	if got := file.value("get", "url"); got != "{{baseUrl}}/users/:id?page=1" {
+		t.Errorf("expected the url with its colons, got %q", got)
+	}
+
+	expectedQuery := []brunoPair{{Key: "page", Value: "1"}, {Key: "debug", Value: "true", Disabled: true}}
+	if !reflect.DeepEqual(file["params:query"].Pairs, expectedQuery) {
+		t.Errorf("expected %+v, got %+v", expectedQuery, file["params:query"].Pairs)
+	}
+
+	if expected := "{\n  \"name\": \"{{name}}\"\n}"; file["body:json"].Text != expected {
+		t.Errorf("expected body %q, got %q", expected, file["body:json"].Text)
+	}
+
+	expectedSecrets := []brunoPair{{Key: "token"}, {Key: "apiKey"}}
+	if !reflect.DeepEqual(file["vars:secret"].Pairs, expectedSecrets) {
+		t.Errorf("expected %+v, got %+v", expectedSecrets, file["vars:secret"].Pairs)
+	}
+}
+
+func TestReplacePathParam(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		key      string
+		value    string
+		expected string
+	}{
+		{"last segment", "{{baseUrl}}/users/:id", "id", "{{id}}", "{{baseUrl}}/users/{{id}}"},
+		{"before query", "{{baseUrl}}/users/:id?page=1", "id", "7", "{{baseUrl}}/users/7?page=1"},
+		{"longer param", "{{baseUrl}}/:idx/:id/", "id", "7", "{{baseUrl}}/:idx/7/"},
+		{"prefix of param", "{{baseUrl}}/:id/:idx", "idx", "3", "{{baseUrl}}/:id/3"},
+		{"repeated", "/:id/items/:id", "id", "7", "/7/items/7"},
+		{"value with dollar", "/users/:id", "id", "$1", "/users/$1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := replacePathParam(tc.url, tc.key, tc.value); got != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestMigrateBruno(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+
+	files := map[string]string{
+		"bruno/bruno.json":     `{"version": "1", "name": "Pet API", "type": "collection"}`,
+		"bruno/collection.bru": "auth {\n  mode: bearer\n}\n\nauth:bearer {\n  token: {{token}}\n}\n",
+		"bruno/environments/staging.bru": "vars {\n  baseUrl: https://staging.pets.io\n  ~old: 1\n}\n" +
+			"vars:secret [\n  token\n]\n",
+		"bruno/Pets/folder.bru": "meta {\n  name: All Pets\n  seq: 1\n}\n",
+		"bruno/Pets/get pet.bru": `meta {
+  name: Get pet
+  type: http
+  seq: 1
+}
+
+get {
+  url: {{baseUrl}}/pets/:petId?verbose=true
+  body: none
+  auth: inherit
+}
+
+params:query {
+  verbose: true
+  ~debug: 1
+}
+
+params:path {
+  petId: {{petId}}
+}
+
+headers {
+  Accept: application/json
+}
+`,
+		"bruno/Pets/add pet.bru": `meta {
+  name: Add pet
+  type: http
+  seq: 2
+}
+
+post {
+  url: {{baseUrl}}/pets
+  body: json
+  auth: apikey
+}
+
+auth:apikey {
+  key: x-api-key
+  value: {{apiKey}}
+  placement: header
+}
+
+body:json {
+  {
+    "name": "Rex"
+  }
+}
+`,
+		"bruno/login.bru": `meta {
+  name: Login
+  seq: 1
+}
+
+post {
+  url: {{baseUrl}}/login
+  body: formUrlEncoded
+  auth: none
+}
+
+body:form-urlencoded {
+  user: {{user}}
+}
+`,
+	}
+
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := CompleteMigration([]string{"bruno"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedFiles := map[string][]string{
+		"PetAPI/AllPets/Getpet.yaml": {
+			"# Request: Get pet",
+			"method: GET",
+			`url: "{{.baseUrl}}/pets/{{.petId}}"`,
+			`verbose: "true"`,
+			"Accept: application/json",
+			`token: "{{.token}}"`,
+		},
+		"PetAPI/AllPets/Addpet.yaml": {
+			"Content-Type: application/json",
+			"type: apikey",
+			"key: x-api-key",
+			`raw: "{\n  \"name\": \"Rex\"\n}"`,
+		},
+		"PetAPI/Login.yaml": {
+			"method: POST",
+			`user: "{{.user}}"`,
+		},
+	}
+
+	for path, expectedLines := range expectedFiles {
+		content := readFile(t, filepath.Join(dir, path))
+		for _, line := range expectedLines {
+			if !strings.Contains(content, line) {
+				t.Errorf("expected %q in %s:\n%s", line, path, content)
+			}
+		}
+	}
+
+	if getPet := readFile(t, filepath.Join(dir, "PetAPI/AllPets/Getpet.yaml")); strings.Contains(getPet, "debug") {
+		t.Errorf("expected no disabled params:\n%s", getPet)
+	}
+
+	if strings.Contains(readFile(t, filepath.Join(dir, "PetAPI/Login.yaml")), "auth:") {
+		t.Error("expected no auth for auth: none")
+	}
+
+	staging := readFile(t, filepath.Join(dir, "env/staging.env"))
+	for _, line := range []string{"### Pet API ###", "baseUrl = https://staging.pets.io", "# old = 1", "# token = "} {
+		if !strings.Contains(staging, line) {
+			t.Errorf("expected %q in staging.env:\n%s", line, staging)
+		}
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/utils"
 )
 
 // readJSON reads a JSON file and checks whether file exists, is empty,
-// or if an error occurs while reading the file. It returns the parsed content
+// or if an error occurs while reading the file. It returns the parsed content.
+// YAML files, like OpenAPI specs, are converted to JSON first
 func readJSON(filePath string) (map[string]any, error) {
 	// Check if the file exists and get its info
 	fileInfo, err := os.Stat(filePath)
@@ -32,6 +37,13 @@ func readJSON(filePath string) (map[string]any, error) {
 		return nil, fmt.Errorf("\n error reading the JSON file: %w", err)
 	}
 
+	if ext := strings.ToLower(filepath.Ext(filePath)); ext == utils.YAML || ext == utils.YML {
+		jsonByteVal, err = yaml.YAMLToJSON(jsonByteVal)
+		if err != nil {
+			return nil, fmt.Errorf("\n error converting the YAML file: %w", err)
+		}
+	}
+
 	err = json.Unmarshal(jsonByteVal, &jsonStrFile)
 	if err != nil {
 		return nil, fmt.Errorf("\n error unmarshalling the file: %w", err)
@@ -0,0 +1,587 @@
+package migration
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// options of curl that take a value, but have no place in the api file
+var curlIgnoredWithValue = map[string]bool{
+	"-o": true, "--output": true, "-w": true, "--write-out": true, "--connect-timeout": true,
+	"--retry": true, "-c": true, "--cookie-jar": true, "-D": true, "--dump-header": true,
+	"-r": true, "--range": true, "--resolve": true, "--interface": true, "-T": true, "--upload-file": true,
+}
+
+// short options of curl that take a value, like -X POST or -XPOST
+const curlShortWithValue = "XHdFuAbeoxmwcDrT"
+
+// curlRequest is everything the curl command sets, before it's converted to an api file
+type curlRequest struct {
+	method     string
+	rawURL     string
+	headers    []KeyValuePair
+	data       []string
+	urlencoded []KeyValuePair
+	form       []KeyValuePair
+	user       string
+	digest     bool
+	get        bool
+	client     yaml.MapSlice
+}
+
+// ImportCurl converts the curl command to an api file, saved to outPath, or printed when outPath is empty.
+// A single arg is split like the shell splits it, so the command can be pasted in quotes.
+// Multiple args are the words of the command, already split by the shell
+func ImportCurl(args []string, outPath string) error {
+	if len(args) == 0 {
+		return utils.ColorError("please provide a curl command")
+	}
+
+	words := args
+	if len(args) == 1 {
+		var err error
+
+		words, err = splitShellWords(args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	content, err := curlToYaml(words)
+	if err != nil {
+		return err
+	}
+
+	if outPath == "" {
+		fmt.Print(content)
+
+		return nil
+	}
+
+	if err := os.WriteFile(outPath, []byte(content), utils.FilePer); err != nil {
+		return utils.ColorError("error writing the api file", err)
+	}
+
+	utils.PrintGreen(fmt.Sprintf("Created '%s' %s", outPath, utils.CheckMark))
+
+	return nil
+}
+
+// curlToYaml converts the words of a curl command to the content of an api file
+func curlToYaml(words []string) (string, error) {
+	cmd, err := parseCurl(words)
+	if err != nil {
+		return "", err
+	}
+
+	request := cmd.request()
+
+	name := string(request.Method)
+	if requestURL, err := url.Parse(cmd.rawURL); err == nil {
+		name = harFileName(name, requestURL.Path)
+	}
+
+	content, err := requestToYaml(ItemOrReq{Name: name, Request: request})
+	if err != nil {
+		return "", err
+	}
+
+	if len(cmd.client) > 0 {
+		clientYAML, err := yaml.Marshal(map[string]any{"client": cmd.client})
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal client to YAML: %w", err)
+		}
+
+		content += string(clientYAML)
+	}
+
+	return content, nil
+}
+
+// parseCurl reads the options of the curl command. Options curl prints or saves with,
+// like -s, -v and -o, are skipped
+func parseCurl(words []string) (curlRequest, error) {
+	if len(words) > 0 && (words[0] == "curl" || strings.HasSuffix(words[0], "/curl")) {
+		words = words[1:]
+	}
+
+	var cmd curlRequest
+
+	for i := 0; i < len(words); i++ {
+		// grouped short options, like -sSk, are read one by one
+		if grouped := splitShortOptions(words[i]); len(grouped) > 1 {
+			words = append(append(append([]string{}, words[:i]...), grouped...), words[i+1:]...)
+		}
+
+		word := words[i]
+
+		name, value, hasValue := word, "", false
+
+		switch {
+		case strings.HasPrefix(word, "--") && strings.Contains(word, "="):
+			name, value, hasValue = strings.Cut(word, "=")
+		case len(word) > 2 && word[0] == '-' && word[1] != '-' && strings.Contains(curlShortWithValue, word[1:2]):
+			// short options with the value attached, like -XPOST
+			name, value, hasValue = word[:2], word[2:], true
+		}
+
+		next := func() (string, error) {
+			if hasValue {
+				return value, nil
+			}
+
+			if i+1 >= len(words) {
+				return "", utils.ColorError(fmt.Sprintf("curl option '%s' needs a value", name))
+			}
+
+			i++
+
+			return words[i], nil
+		}
+
+		var err error
+
+		switch name {
+		case "-X", "--request":
+			cmd.method, err = next()
+		case "-H", "--header":
+			var header string
+			if header, err = next(); err == nil {
+				key, val, _ := strings.Cut(header, ":")
+				cmd.headers = append(cmd.headers, KeyValuePair{Key: strings.TrimSpace(key), Value: strings.TrimSpace(val)})
+			}
+		case "-d", "--data", "--data-raw", "--data-binary", "--data-ascii", "--json":
+			var data string
+			if data, err = next(); err == nil {
+				if data, err = curlData(name, data); err == nil {
+					cmd.data = append(cmd.data, data)
+				}
+			}
+
+			if name == "--json" {
+				cmd.headers = append(cmd.headers,
+					KeyValuePair{Key: "Content-Type", Value: "application/json"},
+					KeyValuePair{Key: "Accept", Value: "application/json"},
+				)
+			}
+		case "--data-urlencode":
+			var data string
+			if data, err = next(); err == nil {
+				key, val, found := strings.Cut(data, "=")
+				if !found {
+					key, val = "", data
+				}
+
+				cmd.urlencoded = append(cmd.urlencoded, KeyValuePair{Key: key, Value: val})
+			}
+		case "-F", "--form", "--form-string":
+			var field string
+			if field, err = next(); err == nil {
+				key, val, _ := strings.Cut(field, "=")
+				if name != "--form-string" {
+					// files of the form are replaced by their path, and ;type= is dropped
+					val, _, _ = strings.Cut(strings.TrimPrefix(strings.TrimPrefix(val, "@"), "<"), ";")
+				}
+
+				cmd.form = append(cmd.form, KeyValuePair{Key: key, Value: val})
+			}
+		case "-u", "--user":
+			cmd.user, err = next()
+		case "--digest":
+			cmd.digest = true
+		case "-G", "--get":
+			cmd.get = true
+		case "-I", "--head":
+			cmd.method = "HEAD"
+		case "-b", "--cookie":
+			var cookie string
+			if cookie, err = next(); err == nil {
+				cmd.headers = append(cmd.headers, KeyValuePair{Key: "Cookie", Value: cookie})
+			}
+		case "-A", "--user-agent":
+			var agent string
+			if agent, err = next(); err == nil {
+				cmd.headers = append(cmd.headers, KeyValuePair{Key: "User-Agent", Value: agent})
+			}
+		case "-e", "--referer":
+			var referer string
+			if referer, err = next(); err == nil {
+				cmd.headers = append(cmd.headers, KeyValuePair{Key: "Referer", Value: referer})
+			}
+		case "--url":
+			cmd.rawURL, err = next()
+		case "-k", "--insecure":
+			cmd.client = append(cmd.client, yaml.MapItem{Key: "insecure_skip_verify", Value: true})
+		case "-x", "--proxy":
+			var proxy string
+			if proxy, err = next(); err == nil {
+				cmd.client = append(cmd.client, yaml.MapItem{Key: "proxy", Value: proxy})
+			}
+		case "-m", "--max-time":
+			var seconds string
+			if seconds, err = next(); err == nil {
+				cmd.client = append(cmd.client, yaml.MapItem{Key: "timeout", Value: seconds + "s"})
+			}
+		case "--cacert", "--cert", "--key":
+			var file string
+			if file, err = next(); err == nil {
+				cmd.client = append(cmd.client, yaml.MapItem{Key: strings.ReplaceAll(name[2:], "cacert", "ca_cert"), Value: file})
+			}
+		case "--http1.1":
+			cmd.client = append(cmd.client, yaml.MapItem{Key: "http_version", Value: yamlparser.HTTP1})
+		case "--http2":
+			cmd.client = append(cmd.client, yaml.MapItem{Key: "http_version", Value: yamlparser.HTTP2})
+		case "--compressed":
+			// the http client asks for compressed responses and decompresses them on its own
+		default:
+			switch {
+			case curlIgnoredWithValue[name]:
+				_, err = next()
+			case strings.HasPrefix(word, "-") && word != "-":
+				// flags without a value, like -s, -v, -L and -i
+			case cmd.rawURL == "":
+				cmd.rawURL = word
+			default:
+				return cmd, utils.ColorError(fmt.Sprintf("unexpected argument '%s' in the curl command", word))
+			}
+		}
+
+		if err != nil {
+			return cmd, err
+		}
+	}
+
+	if cmd.rawURL == "" {
+		return cmd, utils.ColorError("curl command has no url")
+	}
+
+	if !strings.Contains(cmd.rawURL, "://") {
+		cmd.rawURL = "http://" + cmd.rawURL
+	}
+
+	return cmd, nil
+}
+
+// curlData returns the data of the option. Like curl, @file is replaced by the content of the file,
+// without its line breaks unless the option is --data-binary or --json. --data-raw is used as is
+func curlData(name, data string) (string, error) {
+	if name == "--data-raw" || !strings.HasPrefix(data, "@") {
+		return data, nil
+	}
+
+	path := data[1:]
+	if path == "-" {
+		return "", utils.ColorError("data from stdin, '@-', can't be imported. Put the data in the command or in a file")
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", utils.ColorError(fmt.Sprintf("error reading the data file '%s'", path), err)
+	}
+
+	if name == "--data-binary" || name == "--json" {
+		return string(content), nil
+	}
+
+	return strings.NewReplacer("\r", "", "\n", "").Replace(string(content)), nil
+}
+
+// splitShortOptions splits grouped short options, like -sSL, to -s -S -L.
+// The first option that takes a value ends the group, and the rest of the word is its value, like -sXPOST
+func splitShortOptions(word string) []string {
+	if len(word) <= 2 || word[0] != '-' || word[1] == '-' {
+		return []string{word}
+	}
+
+	var options []string
+
+	for j := 1; j < len(word); j++ {
+		if strings.Contains(curlShortWithValue, word[j:j+1]) {
+			return append(options, "-"+word[j:])
+		}
+
+		options = append(options, "-"+word[j:j+1])
+	}
+
+	return options
+}
+
+// request converts the curl command to a request, like curl would send it
+func (cmd curlRequest) request() *Request {
+	request := &Request{URL: &PMURL{Raw: yamlparser.URL(cmd.rawURL), Query: queryOf(cmd.rawURL)}}
+
+	hasBody := len(cmd.data) > 0 || len(cmd.urlencoded) > 0 || len(cmd.form) > 0
+
+	switch {
+	case cmd.method != "":
+		request.Method = yamlparser.HTTPMethodType(strings.ToUpper(cmd.method))
+	case hasBody && !cmd.get:
+		request.Method = yamlparser.POST
+	default:
+		request.Method = yamlparser.GET
+	}
+
+	request.Header = cmd.headers
+
+	if cmd.user != "" {
+		username, password, _ := strings.Cut(cmd.user, ":")
+		params := []PMAuthParam{{Key: "username", Value: username}, {Key: "password", Value: password}}
+
+		if cmd.digest {
+			request.Auth = &PMAuth{Type: "digest", Digest: params}
+		} else {
+			request.Auth = &PMAuth{Type: "basic", Basic: params}
+		}
+	}
+
+	contentType := ""
+
+	for _, header := range cmd.headers {
+		if strings.EqualFold(header.Key, "content-type") {
+			contentType = strings.ToLower(header.Value)
+		}
+	}
+
+	switch {
+	case len(cmd.form) > 0:
+		request.Body = &Body{Mode: "formdata", FormData: cmd.form}
+	case cmd.get:
+		// -G sends the data as the query of the url
+		request.URL.Query = append(request.URL.Query, cmd.formPairs()...)
+	case len(cmd.data) == 0 && len(cmd.urlencoded) > 0:
+		request.Body = &Body{Mode: "urlencoded", URLEncoded: cmd.formPairs()}
+	case len(cmd.data) > 0:
+		raw := strings.Join(cmd.data, "&")
+
+		pairs := cmd.formPairs()
+		if (contentType == "" || strings.HasPrefix(contentType, "application/x-www-form-urlencoded")) &&
+			isFormData(raw) {
+			request.Body = &Body{Mode: "urlencoded", URLEncoded: pairs}
+
+			break
+		}
+
+		if len(cmd.urlencoded) > 0 {
+			raw = strings.Join(append(cmd.data, encodePairs(cmd.urlencoded)), "&")
+		}
+
+		// curl sends the data as a form, unless the command says otherwise
+		if contentType == "" {
+			request.Header = append(request.Header, KeyValuePair{
+				Key:   "Content-Type",
+				Value: "application/x-www-form-urlencoded",
+			})
+		}
+
+		request.Body = &Body{Mode: "raw", Raw: raw}
+	}
+
+	return request
+}
+
+// formPairs are the fields of -d and --data-urlencode, in the order of the command
+func (cmd curlRequest) formPairs() []KeyValuePair {
+	var pairs []KeyValuePair
+
+	for _, data := range cmd.data {
+		pairs = append(pairs, queryOf("?"+data)...)
+	}
+
+	for _, pair := range cmd.urlencoded {
+		if pair.Key == "" {
+			pairs = append(pairs, KeyValuePair{Key: pair.Value})
+
+			continue
+		}
+
+		pairs = append(pairs, pair)
+	}
+
+	return pairs
+}
+
+// isFormData checks if the data looks like name=value&name=value
+func isFormData(data string) bool {
+	if data == "" || strings.ContainsAny(data, " \n{}[]\"<>") {
+		return false
+	}
+
+	for part := range strings.SplitSeq(data, "&") {
+		if name, _, found := strings.Cut(part, "="); !found || name == "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+func encodePairs(pairs []KeyValuePair) string {
+	encoded := make([]string, 0, len(pairs))
+
+	for _, pair := range pairs {
+		if pair.Key == "" {
+			encoded = append(encoded, url.QueryEscape(pair.Value))
+		} else {
+			encoded = append(encoded, pair.Key+"="+url.QueryEscape(pair.Value))
+		}
+	}
+
+	return strings.Join(encoded, "&")
+}
+
+// splitShellWords splits the command like a POSIX shell splits it. Single and double quotes,
+// backslashes, line continuations and $'...' strings, used by browsers for "Copy as cURL", are supported
+func splitShellWords(command string) ([]string, error) {
+	var (
+		words   []string
+		current strings.Builder
+		inWord  bool
+	)
+
+	runes := []rune(command)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			if runes[i] != '\n' {
+				current.WriteRune(runes[i])
+
+				inWord = true
+			}
+		case r == '\'':
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, utils.ColorError("unterminated single quote in the curl command")
+			}
+
+			current.WriteString(string(runes[i+1 : end]))
+
+			i, inWord = end, true
+		case r == '$' && i+1 < len(runes) && runes[i+1] == '\'':
+			value, end, err := ansiCString(runes, i+2)
+			if err != nil {
+				return nil, err
+			}
+
+			current.WriteString(value)
+
+			i, inWord = end, true
+		case r == '"':
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`\n", runes[i+1]) {
+					i++
+					if runes[i] == '\n' {
+						continue
+					}
+				}
+
+				current.WriteRune(runes[i])
+			}
+
+			if i >= len(runes) {
+				return nil, utils.ColorError("unterminated double quote in the curl command")
+			}
+
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, current.String())
+	}
+
+	return words, nil
+}
+
+func indexRune(runes []rune, from int, target rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == target {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// ansiCString reads a $'...' string starting after the opening quote.
+// Returns the value and the index of the closing quote
+func ansiCString(runes []rune, from int) (string, int, error) {
+	var value strings.Builder
+
+	escapes := map[rune]string{
+		'n': "\n", 't': "\t", 'r': "\r", '\\': "\\", '\'': "'", '"': "\"", '?': "?",
+		'a': "\a", 'b': "\b", 'e': "\x1b", 'f': "\f", 'v': "\v",
+	}
+
+	for i := from; i < len(runes); i++ {
+		r := runes[i]
+
+		if r == '\'' {
+			return value.String(), i, nil
+		}
+
+		if r != '\\' || i+1 >= len(runes) {
+			value.WriteRune(r)
+
+			continue
+		}
+
+		i++
+		if escaped, ok := escapes[runes[i]]; ok {
+			value.WriteString(escaped)
+
+			continue
+		}
+
+		// \xHH, \uHHHH and \UHHHHHHHH
+		digits := map[rune]int{'x': 2, 'u': 4, 'U': 8}[runes[i]]
+		if digits == 0 {
+			value.WriteRune('\\')
+			value.WriteRune(runes[i])
+
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && end-i-1 < digits && strings.ContainsRune("0123456789abcdefABCDEF", runes[end]) {
+			end++
+		}
+
+		code, err := strconv.ParseUint(string(runes[i+1:end]), 16, 32)
+		if err != nil {
+			return "", 0, utils.ColorError(fmt.Sprintf("invalid escape in the curl command: %v", err))
+		}
+
+		if runes[i] == 'x' {
+			value.WriteByte(byte(code))
+		} else {
+			value.WriteRune(rune(code))
+		}
+
+		i = end - 1
+	}
+
+	return "", 0, utils.ColorError("unterminated $' quote in the curl command")
+}
@@ -0,0 +1,213 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "quotes",
+			command:  `curl 'https://a.io/x' -H "X-Name: \"bob\"" -d 'it'\''s'`,
+			expected: []string{"curl", "https://a.io/x", "-H", `X-Name: "bob"`, "-d", "it's"},
+		},
+		{
+			name:     "line continuations",
+			command:  "curl https://a.io \\\n  -X PUT \\\n  -k",
+			expected: []string{"curl", "https://a.io", "-X", "PUT", "-k"},
+		},
+		{
+			name:     "ansi-c quotes",
+			command:  `curl $'https://a.io' --data-raw $'{"a":"b\'c\n\x41é"}'`,
+			expected: []string{"curl", "https://a.io", "--data-raw", "{\"a\":\"b'c\nAé\"}"},
+		},
+		{name: "unterminated quote", command: `curl 'https://a.io`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			words, err := splitShellWords(tc.command)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			if !tc.wantErr && !reflect.DeepEqual(words, tc.expected) {
+				t.Errorf("expected %q, got %q", tc.expected, words)
+			}
+		})
+	}
+}
+
+func TestCurlToYaml(t *testing.T) {
+	tests := []struct {
+		name        string
+		command     string
+		contains    []string
+		notContains []string
+		wantErr     bool
+	}{
+		{
+			name:    "json body",
+			command: `curl -X PUT 'https://api.io/users/42?verbose=1' -H 'Content-Type: application/json' -d '{"name":"bob"}' --compressed`,
+			contains: []string{
+				"# Request: put_users_42",
+				"method: PUT",
+				"url: https://api.io/users/42\n",
+				`verbose: "1"`,
+				"Content-Type: application/json",
+				`raw: "{\"name\":\"bob\"}"`,
+			},
+		},
+		{
+			name:    "form data and basic auth",
+			command: `curl https://api.io/login -d user=bob -d tag=a --data-urlencode 'q=hello world' -u bob:secret`,
+			contains: []string{
+				"method: POST",
+				"type: basic",
+				"username: bob",
+				"password: secret",
+				"urlencodedformdata:",
+				"user: bob",
+				"q: hello world",
+			},
+			notContains: []string{"Content-Type"},
+		},
+		{
+			name:     "digest auth",
+			command:  `curl --digest -u bob:secret https://api.io`,
+			contains: []string{"method: GET", "type: digest", "username: bob"},
+		},
+		{
+			name:     "multipart form",
+			command:  `curl -F 'file=@photo.png;type=image/png' -F name=bob https://api.io/upload`,
+			contains: []string{"method: POST", "formdata:", "file: photo.png", "name: bob"},
+		},
+		{
+			name:     "raw data is sent as a form by curl",
+			command:  `curl https://api.io -d 'plain text'`,
+			contains: []string{"Content-Type: application/x-www-form-urlencoded", "raw: plain text"},
+		},
+		{
+			name:        "get moves the data to the query",
+			command:     `curl -G https://api.io/search -d q=go`,
+			contains:    []string{"method: GET", "urlparams:", "q: go"},
+			notContains: []string{"body:"},
+		},
+		{
+			name:     "client options",
+			command:  `curl -k -sS -o out.json -m 5 -x http://proxy:8080 https://api.io`,
+			contains: []string{"client:", "insecure_skip_verify: true", "timeout: 5s", "proxy: http://proxy:8080"},
+		},
+		{
+			name:     "grouped short options",
+			command:  `curl -sk https://api.io`,
+			contains: []string{"insecure_skip_verify: true"},
+		},
+		{
+			name:     "grouped short options with a value",
+			command:  `curl -kL -sXDELETE https://api.io/users/1 -sSm 5`,
+			contains: []string{"method: DELETE", "insecure_skip_verify: true", "timeout: 5s"},
+		},
+		{
+			name:     "url without a scheme",
+			command:  `curl -XDELETE api.io/users/1`,
+			contains: []string{"method: DELETE", "url: http://api.io/users/1"},
+		},
+		{name: "no url", command: `curl -X GET`, wantErr: true},
+		{name: "option without a value", command: `curl https://api.io -H`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			words, err := splitShellWords(tc.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := curlToYaml(words)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			for _, expected := range tc.contains {
+				if !strings.Contains(content, expected) {
+					t.Errorf("expected %q in:\n%s", expected, content)
+				}
+			}
+
+			for _, unexpected := range tc.notContains {
+				if strings.Contains(content, unexpected) {
+					t.Errorf("expected no %q in:\n%s", unexpected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestCurlDataFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	if err := os.WriteFile("body.json", []byte("{\"name\":\n\"bob\"}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		contains string
+		wantErr  bool
+	}{
+		{name: "file", command: `curl https://api.io -d @body.json`, contains: `raw: "{\"name\":\"bob\"}"`},
+		{name: "binary keeps line breaks", command: `curl https://api.io --data-binary @body.json`, contains: `\"name\":\n`},
+		{name: "raw is used as is", command: `curl https://api.io --data-raw @body.json`, contains: `raw: "@body.json"`},
+		{name: "missing file", command: `curl https://api.io -d @missing.json`, wantErr: true},
+		{name: "stdin", command: `curl https://api.io -d @-`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			words, err := splitShellWords(tc.command)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := curlToYaml(words)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+
+			if !tc.wantErr && !strings.Contains(content, tc.contains) {
+				t.Errorf("expected %q in:\n%s", tc.contains, content)
+			}
+		})
+	}
+}
+
+func TestImportCurl(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.yaml")
+
+	if err := ImportCurl([]string{"curl", "https://api.io/users", "-H", "Accept: application/json"}, path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content := readFile(t, path)
+	for _, expected := range []string{"method: GET", "url: https://api.io/users", "Accept: application/json"} {
+		if !strings.Contains(content, expected) {
+			t.Errorf("expected %q in:\n%s", expected, content)
+		}
+	}
+
+	if err := ImportCurl(nil, path); err == nil {
+		t.Errorf("expected an error without a curl command")
+	}
+}
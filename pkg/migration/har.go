@@ -0,0 +1,307 @@
+package migration
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// headers the http client sets on its own, so they are left out of the api files.
+// Cookie is built from the cookies of the request
+var harSkippedHeaders = map[string]bool{
+	"host":              true,
+	"content-length":    true,
+	"connection":        true,
+	"accept-encoding":   true,
+	"cookie":            true,
+	"transfer-encoding": true,
+}
+
+// HARFile represents a HAR 1.2 file, like the ones saved from the network tab of the browser
+type HARFile struct {
+	Log struct {
+		Entries []HAREntry `json:"entries"`
+	} `json:"log"`
+}
+
+// HAREntry is a request and its response
+type HAREntry struct {
+	Request  HARRequest  `json:"request"`
+	Response HARResponse `json:"response"`
+}
+
+// HARRequest is the recorded request. URL has the query string
+type HARRequest struct {
+	Method   string       `json:"method"`
+	URL      string       `json:"url"`
+	Headers  []HARPair    `json:"headers"`
+	Cookies  []HARPair    `json:"cookies"`
+	PostData *HARPostData `json:"postData,omitempty"`
+}
+
+// HARPostData is the body of the request. Params are the fields of forms
+type HARPostData struct {
+	MimeType string     `json:"mimeType"`
+	Text     string     `json:"text"`
+	Params   []HARParam `json:"params,omitempty"`
+}
+
+// HARParam is a field of a form
+type HARParam struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	FileName string `json:"fileName,omitempty"`
+}
+
+// HARPair is a header or a cookie
+type HARPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARResponse is the recorded response. Content has no text when the body was not recorded
+type HARResponse struct {
+	Status     int       `json:"status"`
+	StatusText string    `json:"statusText"`
+	Headers    []HARPair `json:"headers"`
+	Content    struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Encoding string `json:"encoding"`
+	} `json:"content"`
+}
+
+// isHAR determines if the JSON contains a HAR file
+func isHAR(jsonString map[string]any) bool {
+	log, ok := jsonString["log"].(map[string]any)
+	if !ok {
+		return false
+	}
+
+	_, entriesExists := log["entries"]
+
+	return entriesExists
+}
+
+// migrateHAR converts each entry of the HAR file to an api file, in a directory for each host.
+// Directories are in a directory named after the HAR file.
+// Recorded responses are saved next to the api file as <file>_response.json
+func migrateHAR(jsonStr map[string]any, path string) error {
+	jsonBytes, err := json.Marshal(jsonStr)
+	if err != nil {
+		return fmt.Errorf("failed to marshal content: %w", err)
+	}
+
+	var har HARFile
+	if err := json.Unmarshal(jsonBytes, &har); err != nil {
+		return fmt.Errorf("failed to parse the HAR file: %w", err)
+	}
+
+	dirPath, err := utils.CreatePath(sanitizeKey(utils.FileNameWithoutExtension(path)))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CreateDir(dirPath); err != nil {
+		return err
+	}
+
+	var hosts []string
+
+	folders := make(map[string]*ItemOrReq)
+	names := make(map[string]int)
+	responses := make(map[string]HARResponse)
+
+	for _, entry := range har.Log.Entries {
+		requestURL, err := url.Parse(entry.Request.URL)
+		if err != nil || (requestURL.Scheme != "http" && requestURL.Scheme != "https") {
+			continue
+		}
+
+		host := sanitizeKey(requestURL.Host)
+
+		folder, ok := folders[host]
+		if !ok {
+			folder = &ItemOrReq{Name: host}
+			folders[host] = folder
+			hosts = append(hosts, host)
+		}
+
+		name := harFileName(entry.Request.Method, requestURL.Path)
+
+		names[host+"/"+name]++
+		if count := names[host+"/"+name]; count > 1 {
+			name = fmt.Sprintf("%s_%d", name, count)
+		}
+
+		folder.Item = append(folder.Item, ItemOrReq{Name: name, Request: harRequestOf(entry.Request)})
+
+		if entry.Response.Content.Text != "" {
+			responses[filepath.Join(dirPath, host, name)] = entry.Response
+		}
+	}
+
+	items := make([]ItemOrReq, 0, len(hosts))
+	for _, host := range hosts {
+		items = append(items, *folders[host])
+	}
+
+	if err := processItems(items, dirPath); err != nil {
+		return err
+	}
+
+	for filePath, response := range responses {
+		if err := saveHARResponse(filePath, response); err != nil {
+			return err
+		}
+	}
+
+	utils.PrintGreen("HAR Migration Successful! " + utils.CheckMark)
+
+	return nil
+}
+
+// harFileName is the method and the words of the path, like get_api_users_42.
+// It's sanitized like the file names of the requests, so /café is get_caf
+func harFileName(method, path string) string {
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return sanitizeKey(strings.Join(append([]string{strings.ToLower(method)}, words...), "_"))
+}
+
+func harRequestOf(harRequest HARRequest) *Request {
+	request := &Request{
+		Method: yamlparser.HTTPMethodType(strings.ToUpper(harRequest.Method)),
+		URL:    &PMURL{Raw: yamlparser.URL(harRequest.URL), Query: queryOf(harRequest.URL)},
+	}
+
+	var cookieHeader string
+
+	for _, header := range harRequest.Headers {
+		name := strings.ToLower(header.Name)
+		if name == "cookie" {
+			cookieHeader = header.Value
+		}
+
+		// pseudo headers of HTTP/2, like :authority
+		if strings.HasPrefix(name, ":") || harSkippedHeaders[name] {
+			continue
+		}
+
+		request.Header = append(request.Header, KeyValuePair{Key: header.Name, Value: header.Value})
+	}
+
+	if len(harRequest.Cookies) > 0 {
+		cookies := make([]string, 0, len(harRequest.Cookies))
+		for _, cookie := range harRequest.Cookies {
+			cookies = append(cookies, cookie.Name+"="+cookie.Value)
+		}
+
+		cookieHeader = strings.Join(cookies, "; ")
+	}
+
+	if cookieHeader != "" {
+		request.Header = append(request.Header, KeyValuePair{Key: "Cookie", Value: cookieHeader})
+	}
+
+	request.Body = harBodyOf(harRequest.PostData)
+
+	return request
+}
+
+func harBodyOf(postData *HARPostData) *Body {
+	if postData == nil {
+		return nil
+	}
+
+	pairsOf := func() []KeyValuePair {
+		pairs := make([]KeyValuePair, 0, len(postData.Params))
+
+		for _, param := range postData.Params {
+			value := param.Value
+			if param.FileName != "" {
+				value = param.FileName
+			}
+
+			pairs = append(pairs, KeyValuePair{Key: param.Name, Value: value})
+		}
+
+		return pairs
+	}
+
+	mimeType, _, _ := strings.Cut(strings.ToLower(postData.MimeType), ";")
+
+	switch strings.TrimSpace(mimeType) {
+	case "application/x-www-form-urlencoded":
+		pairs := pairsOf()
+		if len(pairs) == 0 {
+			pairs = queryOf("?" + postData.Text)
+		}
+
+		return &Body{Mode: "urlencoded", URLEncoded: pairs}
+	case "multipart/form-data":
+		return &Body{Mode: "formdata", FormData: pairsOf()}
+	default:
+		if postData.Text == "" {
+			return nil
+		}
+
+		return &Body{Mode: "raw", Raw: postData.Text}
+	}
+}
+
+// saveHARResponse saves the recorded response like hulak saves the responses of the api files
+func saveHARResponse(filePath string, harResponse HARResponse) error {
+	// binary bodies, like images, stay base64 encoded
+	text := harResponse.Content.Text
+	if harResponse.Content.Encoding == "base64" {
+		if decoded, err := base64.StdEncoding.DecodeString(text); err == nil && utf8.Valid(decoded) {
+			text = string(decoded)
+		}
+	}
+
+	var body any
+	if err := json.Unmarshal([]byte(text), &body); err != nil {
+		body = text
+	}
+
+	headers := make(map[string]string, len(harResponse.Headers))
+	for _, header := range harResponse.Headers {
+		if existing, ok := headers[header.Name]; ok {
+			headers[header.Name] = existing + ", " + header.Value
+		} else {
+			headers[header.Name] = header.Value
+		}
+	}
+
+	response := apicalls.CustomResponse{Response: &apicalls.ResponseInfo{
+		StatusCode: harResponse.Status,
+		Status:     strings.TrimSpace(fmt.Sprintf("%d %s", harResponse.Status, harResponse.StatusText)),
+		Headers:    headers,
+		Body:       body,
+	}}
+
+	content, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the response of '%s': %w", filePath, err)
+	}
+
+	responsePath := filePath + utils.ResponseFileName
+	if err := os.WriteFile(responsePath, content, utils.FilePer); err != nil {
+		return fmt.Errorf("failed to write response file '%s': %w", responsePath, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const captureHAR = `{
+  "log": {
+    "version": "1.2",
+    "creator": {"name": "WebInspector", "version": "537.36"},
+    "entries": [
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.shop.io/v1/orders?draft=true",
+          "httpVersion": "HTTP/2",
+          "headers": [
+            {"name": ":authority", "value": "api.shop.io"},
+            {"name": "content-type", "value": "application/json"},
+            {"name": "accept-encoding", "value": "gzip"},
+            {"name": "cookie", "value": "session=abc; theme=dark"},
+            {"name": "x-request-id", "value": "42"}
+          ],
+          "cookies": [{"name": "session", "value": "abc"}, {"name": "theme", "value": "dark"}],
+          "postData": {"mimeType": "application/json", "text": "{\"sku\":\"A1\"}"}
+        },
+        "response": {
+          "status": 201,
+          "statusText": "Created",
+          "headers": [{"name": "content-type", "value": "application/json"}],
+          "content": {"mimeType": "application/json", "text": "eyJpZCI6N30=", "encoding": "base64"}
+        }
+      },
+      {
+        "request": {
+          "method": "POST",
+          "url": "https://api.shop.io/v1/orders",
+          "headers": [],
+          "cookies": [],
+          "postData": {"mimeType": "application/x-www-form-urlencoded; charset=UTF-8", "text": "sku=B2&qty=3"}
+        },
+        "response": {"status": 400, "statusText": "", "headers": [], "content": {"mimeType": "", "text": ""}}
+      },
+      {
+        "request": {"method": "GET", "url": "data:image/png;base64,AAAA", "headers": [], "cookies": []},
+        "response": {"status": 200, "headers": [], "content": {}}
+      },
+      {
+        "request": {"method": "GET", "url": "https://cdn.shop.io/app.js", "headers": [], "cookies": []},
+        "response": {"status": 200, "headers": [], "content": {"mimeType": "text/javascript", "text": "alert(1)"}}
+      }
+    ]
+  }
+}`
+
+func TestIsHAR(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  map[string]any
+		expected bool
+	}{
+		{"har", map[string]any{"log": map[string]any{"entries": []any{}}}, true},
+		{"log without entries", map[string]any{"log": map[string]any{}}, false},
+		{"log is not an object", map[string]any{"log": "entries"}, false},
+	}
+
+	for _, tc := range tests {
+		if got := isHAR(tc.content); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestMigrateHAR(t *testing.T) {
+	dir := migrateSpec(t, "capture.har", captureHAR)
+
+	expectedFiles := map[string][]string{
+		"capture/api_shop_io/post_v1_orders.yaml": {
+			"# Request: post_v1_orders",
+			"method: POST",
+			"url: https://api.shop.io/v1/orders\n",
+			`draft: "true"`,
+			"x-request-id: \"42\"",
+			"Cookie: session=abc; theme=dark",
+			`raw: "{\"sku\":\"A1\"}"`,
+		},
+		"capture/api_shop_io/post_v1_orders_2.yaml": {
+			"urlencodedformdata:",
+			"sku: B2",
+			`qty: "3"`,
+		},
+		"capture/api_shop_io/post_v1_orders_response.json": {
+			`"status_code": 201`,
+			`"status": "201 Created"`,
+			`"id": 7`,
+		},
+		"capture/cdn_shop_io/get_app_js.yaml":          {"method: GET"},
+		"capture/cdn_shop_io/get_app_js_response.json": {`"body": "alert(1)"`},
+	}
+
+	for path, expectedLines := range expectedFiles {
+		content := readFile(t, filepath.Join(dir, path))
+		for _, line := range expectedLines {
+			if !strings.Contains(content, line) {
+				t.Errorf("expected %q in %s:\n%s", line, path, content)
+			}
+		}
+	}
+
+	orders := readFile(t, filepath.Join(dir, "capture/api_shop_io/post_v1_orders.yaml"))
+	for _, header := range []string{":authority", "accept-encoding", "cookie:"} {
+		if strings.Contains(orders, header) {
+			t.Errorf("expected no %s header:\n%s", header, orders)
+		}
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, "capture", "*"))
+	if err != nil || len(entries) != 2 {
+		t.Errorf("expected a directory for each http host, got %v", entries)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "capture/api_shop_io/post_v1_orders_2_response.json")); !os.IsNotExist(err) {
+		t.Errorf("expected no response file without a recorded body, got %v", err)
+	}
+}
+
+func TestMigrateHARNonASCIIPath(t *testing.T) {
+	const content = `{"log": {"entries": [
+  {
+    "request": {"method": "GET", "url": "https://shop.io/caf\u00e9", "headers": [], "cookies": []},
+    "response": {"status": 200, "headers": [], "content": {"mimeType": "text/plain", "text": "with accent"}}
+  },
+  {
+    "request": {"method": "GET", "url": "https://shop.io/caf", "headers": [], "cookies": []},
+    "response": {"status": 200, "headers": [], "content": {"mimeType": "text/plain", "text": "without accent"}}
+  }
+]}}`
+
+	dir := migrateSpec(t, "cafe.har", content)
+
+	expectedFiles := map[string]string{
+		"cafe/shop_io/get_caf.yaml":            "url: https://shop.io/café",
+		"cafe/shop_io/get_caf_response.json":   `"body": "with accent"`,
+		"cafe/shop_io/get_caf_2.yaml":          "url: https://shop.io/caf\n",
+		"cafe/shop_io/get_caf_2_response.json": `"body": "without accent"`,
+	}
+
+	for path, expected := range expectedFiles {
+		if content := readFile(t, filepath.Join(dir, path)); !strings.Contains(content, expected) {
+			t.Errorf("expected %q in %s:\n%s", expected, path, content)
+		}
+	}
+
+	entries, err := filepath.Glob(filepath.Join(dir, "cafe/shop_io", "*"))
+	if err != nil || len(entries) != len(expectedFiles) {
+		t.Errorf("expected only %d files, got %v", len(expectedFiles), entries)
+	}
+}
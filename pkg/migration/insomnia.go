@@ -0,0 +1,390 @@
+package migration
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+// Types of the resources in an Insomnia export
+const (
+	insomniaWorkspace   = "workspace"
+	insomniaFolder      = "request_group"
+	insomniaRequest     = "request"
+	insomniaEnvironment = "environment"
+)
+
+// insomniaVariable matches {{ _.key }} and {{ key }} of Insomnia's templates
+var insomniaVariable = regexp.MustCompile(`{{\s*(?:_\.)?([^{}\s]+)\s*}}`)
+
+// InsomniaExport represents an Insomnia v4 export. Workspaces, folders, requests and
+// environments are all resources, linked to their parent with ParentID
+type InsomniaExport struct {
+	Type      string             `json:"_type"`
+	Format    int                `json:"__export_format"`
+	Resources []InsomniaResource `json:"resources"`
+}
+
+// InsomniaResource is a workspace, folder, request or environment
+type InsomniaResource struct {
+	ID             string              `json:"_id"`
+	Type           string              `json:"_type"`
+	ParentID       string              `json:"parentId"`
+	Name           string              `json:"name"`
+	Description    string              `json:"description,omitempty"`
+	MetaSortKey    float64             `json:"metaSortKey,omitempty"`
+	Method         string              `json:"method,omitempty"`
+	URL            string              `json:"url,omitempty"`
+	Body           InsomniaBody        `json:"body"`
+	Parameters     []InsomniaPair      `json:"parameters,omitempty"`
+	Headers        []InsomniaPair      `json:"headers,omitempty"`
+	Authentication map[string]any      `json:"authentication,omitempty"`
+	Data           map[string]any      `json:"data,omitempty"`
+	Children       []*InsomniaResource `json:"-"`
+	Environments   []*InsomniaResource `json:"-"`
+}
+
+// InsomniaBody is the body of a request. Text is used by json, xml, plain text and graphql,
+// and Params by the forms
+type InsomniaBody struct {
+	MimeType string         `json:"mimeType,omitempty"`
+	Text     string         `json:"text,omitempty"`
+	Params   []InsomniaPair `json:"params,omitempty"`
+}
+
+// InsomniaPair is a header, url param or form field
+type InsomniaPair struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Type     string `json:"type,omitempty"`
+	FileName string `json:"fileName,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"`
+}
+
+// isInsomnia determines if the JSON contains an Insomnia v4 export
+func isInsomnia(jsonString map[string]any) bool {
+	exportType, _ := jsonString["_type"].(string)
+	format, _ := jsonString["__export_format"].(float64)
+	_, resourcesExists := jsonString["resources"]
+
+	return exportType == "export" && format == 4 && resourcesExists
+}
+
+// insomniaTemplate converts Insomnia's {{ _.key }} to {{key}}, which addDotToTemplate understands
+func insomniaTemplate(value string) string {
+	return insomniaVariable.ReplaceAllString(value, "{{$1}}")
+}
+
+// migrateInsomnia migrates each workspace of the export like a Postman collection.
+// Base environment is appended to global.env, and the sub environments to their own env files
+func migrateInsomnia(jsonStr map[string]any) error {
+	jsonBytes, err := json.Marshal(jsonStr)
+	if err != nil {
+		return fmt.Errorf("failed to marshal content: %w", err)
+	}
+
+	var export InsomniaExport
+	if err := json.Unmarshal(jsonBytes, &export); err != nil {
+		return fmt.Errorf("failed to parse the Insomnia export: %w", err)
+	}
+
+	byID := make(map[string]*InsomniaResource, len(export.Resources))
+	for i := range export.Resources {
+		byID[export.Resources[i].ID] = &export.Resources[i]
+	}
+
+	var workspaces []*InsomniaResource
+
+	for i := range export.Resources {
+		resource := &export.Resources[i]
+
+		if resource.Type == insomniaWorkspace {
+			workspaces = append(workspaces, resource)
+
+			continue
+		}
+
+		parent, ok := byID[resource.ParentID]
+		if !ok {
+			continue
+		}
+
+		switch resource.Type {
+		case insomniaFolder, insomniaRequest:
+			parent.Children = append(parent.Children, resource)
+		case insomniaEnvironment:
+			parent.Environments = append(parent.Environments, resource)
+		}
+	}
+
+	for _, workspace := range workspaces {
+		if err := migrateInsomniaWorkspace(workspace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func migrateInsomniaWorkspace(workspace *InsomniaResource) error {
+	for _, baseEnv := range workspace.Environments {
+		if err := migrateEnv(insomniaEnvironmentOf("", baseEnv), workspace.Name); err != nil {
+			return err
+		}
+
+		for _, subEnv := range baseEnv.Environments {
+			if err := migrateEnv(insomniaEnvironmentOf(subEnv.Name, subEnv), workspace.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	dirPath, err := utils.CreatePath(sanitizeKey(workspace.Name))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CreateDir(dirPath); err != nil {
+		return err
+	}
+
+	if err := processItems(insomniaItems(workspace.Children), dirPath); err != nil {
+		return err
+	}
+
+	utils.PrintGreen("Insomnia Migration Successful! " + utils.CheckMark)
+
+	return nil
+}
+
+// insomniaEnvironmentOf converts the data of the environment. Nested objects are flattened,
+// so {"api": {"url": ""}} becomes api_url, like {{ _.api.url }} does with sanitizeKey
+func insomniaEnvironmentOf(name string, environment *InsomniaResource) Environment {
+	env := Environment{Name: name}
+
+	var flatten func(prefix string, data map[string]any)
+
+	flatten = func(prefix string, data map[string]any) {
+		keys := make([]string, 0, len(data))
+		for key := range data {
+			keys = append(keys, key)
+		}
+
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			switch value := data[key].(type) {
+			case map[string]any:
+				flatten(prefix+key+".", value)
+			case nil:
+				env.Values = append(env.Values, EnvValues{Key: prefix + key, Enabled: true})
+			default:
+				env.Values = append(env.Values, EnvValues{
+					Key:     prefix + key,
+					Value:   insomniaTemplate(scalarString(value)),
+					Enabled: true,
+				})
+			}
+		}
+	}
+
+	flatten("", environment.Data)
+
+	return env
+}
+
+// insomniaItems converts the folders and requests in the order of the Insomnia sidebar
+func insomniaItems(resources []*InsomniaResource) []ItemOrReq {
+	slices.SortStableFunc(resources, func(a, b *InsomniaResource) int {
+		switch {
+		case a.MetaSortKey < b.MetaSortKey:
+			return -1
+		case a.MetaSortKey > b.MetaSortKey:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	items := make([]ItemOrReq, 0, len(resources))
+
+	for _, resource := range resources {
+		if resource.Type == insomniaFolder {
+			items = append(items, ItemOrReq{Name: resource.Name, Item: insomniaItems(resource.Children)})
+
+			continue
+		}
+
+		items = append(items, ItemOrReq{Name: resource.Name, Request: insomniaRequestOf(resource)})
+	}
+
+	return items
+}
+
+func insomniaRequestOf(resource *InsomniaResource) *Request {
+	rawURL := insomniaTemplate(resource.URL)
+
+	pmURL := &PMURL{Raw: yamlparser.URL(rawURL), Query: queryOf(rawURL)}
+	for _, param := range resource.Parameters {
+		if !param.Disabled {
+			pmURL.Query = append(pmURL.Query, KeyValuePair{
+				Key:   insomniaTemplate(param.Name),
+				Value: insomniaTemplate(param.Value),
+			})
+		}
+	}
+
+	request := &Request{
+		Method: yamlparser.HTTPMethodType(strings.ToUpper(resource.Method)),
+		URL:    pmURL,
+		Body:   insomniaBodyOf(resource.Body),
+		Auth:   insomniaAuthOf(resource.Authentication),
+	}
+
+	for _, header := range resource.Headers {
+		if !header.Disabled && header.Name != "" {
+			request.Header = append(request.Header, KeyValuePair{
+				Key:   insomniaTemplate(header.Name),
+				Value: insomniaTemplate(header.Value),
+			})
+		}
+	}
+
+	return request
+}
+
+// queryOf returns the url params written in the url, since urlToYaml drops the query of the url
+func queryOf(rawURL string) []KeyValuePair {
+	_, query, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return nil
+	}
+
+	var pairs []KeyValuePair
+
+	for part := range strings.SplitSeq(query, "&") {
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		pairs = append(pairs, KeyValuePair{Key: key, Value: value})
+	}
+
+	return pairs
+}
+
+func insomniaBodyOf(body InsomniaBody) *Body {
+	pairsOf := func(params []InsomniaPair) []KeyValuePair {
+		var pairs []KeyValuePair
+
+		for _, param := range params {
+			if param.Disabled {
+				continue
+			}
+
+			value := param.Value
+			if param.Type == "file" {
+				value = param.FileName
+			}
+
+			pairs = append(pairs, KeyValuePair{Key: insomniaTemplate(param.Name), Value: insomniaTemplate(value)})
+		}
+
+		return pairs
+	}
+
+	switch body.MimeType {
+	case "":
+		return nil
+	case "application/x-www-form-urlencoded":
+		return &Body{Mode: "urlencoded", URLEncoded: pairsOf(body.Params)}
+	case "multipart/form-data":
+		return &Body{Mode: "formdata", FormData: pairsOf(body.Params)}
+	case "application/graphql":
+		var graphql struct {
+			Query     string `json:"query"`
+			Variables any    `json:"variables"`
+		}
+
+		if err := json.Unmarshal([]byte(body.Text), &graphql); err != nil {
+			return &Body{Mode: "raw", Raw: insomniaTemplate(body.Text)}
+		}
+
+		result := &Body{Mode: "graphql", GraphQL: &graphQl{Query: insomniaTemplate(graphql.Query)}}
+		if variables, ok := graphql.Variables.(map[string]any); ok && len(variables) > 0 {
+			result.GraphQL.Variables = insomniaTemplate(scalarString(variables))
+		}
+
+		return result
+	default:
+		return &Body{Mode: "raw", Raw: insomniaTemplate(body.Text)}
+	}
+}
+
+// insomniaAuthOf converts the authentication to Postman's auth, so authToYaml converts both
+func insomniaAuthOf(authentication map[string]any) *PMAuth {
+	if len(authentication) == 0 {
+		return nil
+	}
+
+	if disabled, _ := authentication["disabled"].(bool); disabled {
+		return nil
+	}
+
+	value := func(key string) string {
+		str, _ := authentication[key].(string)
+
+		return insomniaTemplate(str)
+	}
+
+	params := func(pairs ...string) []PMAuthParam {
+		var result []PMAuthParam
+		for i := 0; i+1 < len(pairs); i += 2 {
+			result = append(result, PMAuthParam{Key: pairs[i], Value: pairs[i+1]})
+		}
+
+		return result
+	}
+
+	switch authType, _ := authentication["type"].(string); authType {
+	case "basic":
+		return &PMAuth{Type: "basic", Basic: params("username", value("username"), "password", value("password"))}
+	case "digest":
+		return &PMAuth{Type: "digest", Digest: params("username", value("username"), "password", value("password"))}
+	case "bearer":
+		return &PMAuth{Type: "bearer", Bearer: params("token", value("token"))}
+	case "apikey":
+		in := yamlparser.APIKeyInHeader
+		if value("addTo") == "queryParams" {
+			in = yamlparser.APIKeyInQuery
+		}
+
+		return &PMAuth{Type: "apikey", APIKey: params("key", value("key"), "value", value("value"), "in", in)}
+	case "iam":
+		return &PMAuth{Type: "awsv4", AWSV4: params(
+			"accessKey", value("accessKeyId"),
+			"secretKey", value("secretAccessKey"),
+			"sessionToken", value("sessionToken"),
+			"region", value("region"),
+			"service", value("service"),
+		)}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,126 @@
+package migration
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const insomniaExport = `{
+  "_type": "export",
+  "__export_format": 4,
+  "resources": [
+    {"_id": "wrk_1", "_type": "workspace", "parentId": null, "name": "Shop API"},
+    {"_id": "env_base", "_type": "environment", "parentId": "wrk_1", "name": "Base Environment",
+     "data": {"baseUrl": "https://shop.io", "auth": {"token": ""}}},
+    {"_id": "env_staging", "_type": "environment", "parentId": "env_base", "name": "staging",
+     "data": {"baseUrl": "https://staging.shop.io"}},
+    {"_id": "fld_1", "_type": "request_group", "parentId": "wrk_1", "name": "Orders", "metaSortKey": -2},
+    {"_id": "req_1", "_type": "request", "parentId": "fld_1", "name": "Create order", "method": "post",
+     "url": "{{ _.baseUrl }}/orders?draft=true",
+     "parameters": [{"name": "page", "value": "1"}, {"name": "debug", "value": "1", "disabled": true}],
+     "headers": [{"name": "Content-Type", "value": "application/json"}, {"name": "X-Old", "value": "1", "disabled": true}],
+     "authentication": {"type": "bearer", "token": "{{ _.auth.token }}"},
+     "body": {"mimeType": "application/json", "text": "{\"sku\": \"{{ _.sku }}\"}"}},
+    {"_id": "req_2", "_type": "request", "parentId": "fld_1", "name": "Upload", "method": "POST",
+     "url": "{{ baseUrl }}/upload",
+     "authentication": {"type": "apikey", "key": "api_key", "value": "{{ _.key }}", "addTo": "queryParams"},
+     "body": {"mimeType": "multipart/form-data", "params": [{"name": "file", "type": "file", "fileName": "/tmp/a.png"}]}},
+    {"_id": "req_3", "_type": "request", "parentId": "wrk_1", "name": "Products", "method": "POST",
+     "url": "{{ _.baseUrl }}/graphql",
+     "authentication": {"type": "basic", "username": "john", "password": "{{ _.password }}", "disabled": true},
+     "body": {"mimeType": "application/graphql", "text": "{\"query\": \"{ products { id } }\", \"variables\": {\"first\": 10}}"}}
+  ]
+}`
+
+func TestIsInsomnia(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  map[string]any
+		expected bool
+	}{
+		{"v4 export", map[string]any{"_type": "export", "__export_format": 4.0, "resources": []any{}}, true},
+		{"v3 export", map[string]any{"_type": "export", "__export_format": 3.0, "resources": []any{}}, false},
+		{"postman collection", map[string]any{"info": map[string]any{}, "item": []any{}}, false},
+	}
+
+	for _, tc := range tests {
+		if got := isInsomnia(tc.content); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestInsomniaTemplate(t *testing.T) {
+	tests := map[string]string{
+		"{{ _.baseUrl }}/users": "{{baseUrl}}/users",
+		"{{baseUrl}}/users":     "{{baseUrl}}/users",
+		"Bearer {{ token }}":    "Bearer {{token}}",
+		"{{ _.auth.token }}":    "{{auth.token}}",
+	}
+
+	for input, expected := range tests {
+		if got := insomniaTemplate(input); got != expected {
+			t.Errorf("expected %q for %q, got %q", expected, input, got)
+		}
+	}
+}
+
+func TestMigrateInsomnia(t *testing.T) {
+	dir := migrateSpec(t, "insomnia.json", insomniaExport)
+
+	expectedFiles := map[string][]string{
+		"ShopAPI/Orders/Createorder.yaml": {
+			"# Request: Create order",
+			"method: POST",
+			`url: "{{.baseUrl}}/orders"`,
+			`draft: "true"`,
+			`page: "1"`,
+			"Content-Type: application/json",
+			"type: bearer",
+			`token: "{{.auth_token}}"`,
+			`raw: "{\"sku\": \"{{.sku}}\"}"`,
+		},
+		"ShopAPI/Orders/Upload.yaml": {
+			`url: "{{.baseUrl}}/upload"`,
+			"in: query",
+			"formdata:\n    file: /tmp/a.png",
+		},
+		"ShopAPI/Products.yaml": {
+			`query: "{ products { id } }"`,
+			"first: 10",
+		},
+	}
+
+	for path, expectedLines := range expectedFiles {
+		content := readFile(t, filepath.Join(dir, path))
+		for _, line := range expectedLines {
+			if !strings.Contains(content, line) {
+				t.Errorf("expected %q in %s:\n%s", line, path, content)
+			}
+		}
+	}
+
+	createOrder := readFile(t, filepath.Join(dir, "ShopAPI/Orders/Createorder.yaml"))
+	if strings.Contains(createOrder, "debug") || strings.Contains(createOrder, "X-Old") {
+		t.Errorf("expected no disabled params or headers:\n%s", createOrder)
+	}
+
+	if strings.Contains(readFile(t, filepath.Join(dir, "ShopAPI/Products.yaml")), "auth:") {
+		t.Error("expected no auth for disabled authentication")
+	}
+
+	global := readFile(t, filepath.Join(dir, "env/global.env"))
+	for _, line := range []string{"### Shop API ###", "baseUrl = https://shop.io", "# auth_token = "} {
+		if !strings.Contains(global, line) {
+			t.Errorf("expected %q in global.env:\n%s", line, global)
+		}
+	}
+
+	if staging := readFile(t, filepath.Join(dir, "env/staging.env")); !strings.Contains(
+		staging,
+		"baseUrl = https://staging.shop.io",
+	) {
+		t.Errorf("expected the sub environment in staging.env:\n%s", staging)
+	}
+}
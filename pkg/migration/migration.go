@@ -10,10 +10,22 @@ import (
 // CompleteMigration processes all files for migration
 func CompleteMigration(filePaths []string) error {
 	if len(filePaths) == 0 {
-		return utils.ColorError("please provide a valid json file for migration")
+		return utils.ColorError("please provide a valid json file or Bruno collection for migration")
 	}
 
 	for _, path := range filePaths {
+		if isBruno(path) {
+			if err := migrateBruno(path); err != nil {
+				utils.PrintWarning("Bruno migration did not work for: " + path)
+
+				return err
+			}
+
+			utils.PrintGreen(fmt.Sprintf("migrated '%s': ", path))
+
+			continue
+		}
+
 		jsonStr, err := readJSON(path)
 		if err != nil {
 			return err
@@ -41,8 +53,32 @@ func CompleteMigration(filePaths []string) error {
 
 				return err
 			}
+		} else if isOpenAPI(jsonStr) {
+			if err := migrateOpenAPI(jsonStr); err != nil {
+				utils.PrintWarning("OpenAPI migration did not work for: " + path)
+
+				return err
+			}
+
+			utils.PrintGreen(fmt.Sprintf("migrated '%s': ", path))
+		} else if isInsomnia(jsonStr) {
+			if err := migrateInsomnia(jsonStr); err != nil {
+				utils.PrintWarning("Insomnia migration did not work for: " + path)
+
+				return err
+			}
+
+			utils.PrintGreen(fmt.Sprintf("migrated '%s': ", path))
+		} else if isHAR(jsonStr) {
+			if err := migrateHAR(jsonStr, path); err != nil {
+				utils.PrintWarning("HAR migration did not work for: " + path)
+
+				return err
+			}
+
+			utils.PrintGreen(fmt.Sprintf("migrated '%s': ", path))
 		} else {
-			utils.PrintWarning("Unknown Postman file format: " + path)
+			utils.PrintWarning("Unknown file format: " + path)
 		}
 	}
 
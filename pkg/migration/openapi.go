@@ -0,0 +1,837 @@
+package migration
+
+// OpenAPI 3.x and Swagger 2.0 specs, in JSON or YAML
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// baseURLKey is the env key of the server url, used by all the requests of the spec
+const baseURLKey = "baseUrl"
+
+// maxExampleDepth stops the example of recursive schemas, like a tree of nodes
+const maxExampleDepth = 8
+
+// openAPIMethods are the operations of a path item, in the order they are migrated
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// OpenAPISpec is the part of an OpenAPI 3.x or Swagger 2.0 spec used by the migration
+type OpenAPISpec struct {
+	OpenAPI    string                                `json:"openapi"`
+	Swagger    string                                `json:"swagger"`
+	Info       OpenAPIInfo                           `json:"info"`
+	Servers    []OpenAPIServer                       `json:"servers,omitempty"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Security   []map[string][]string                 `json:"security,omitempty"`
+	Components struct {
+		SecuritySchemes map[string]OpenAPISecurityScheme `json:"securitySchemes,omitempty"`
+	} `json:"components"`
+	// Swagger 2.0
+	Host                string                           `json:"host,omitempty"`
+	BasePath            string                           `json:"basePath,omitempty"`
+	Schemes             []string                         `json:"schemes,omitempty"`
+	Consumes            []string                         `json:"consumes,omitempty"`
+	SecurityDefinitions map[string]OpenAPISecurityScheme `json:"securityDefinitions,omitempty"`
+
+	// root is the whole spec, where $ref points to
+	root map[string]any
+}
+
+// OpenAPIInfo is the info object of the spec
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIServer is a server of OpenAPI 3.x. Url could have {variables}
+type OpenAPIServer struct {
+	URL       string `json:"url"`
+	Variables map[string]struct {
+		Default string `json:"default"`
+	} `json:"variables,omitempty"`
+}
+
+// OpenAPIOperation is a single request of the spec, like 'get /pets'
+type OpenAPIOperation struct {
+	OperationID string                 `json:"operationId,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter     `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody    `json:"requestBody,omitempty"`
+	Security    *[]map[string][]string `json:"security,omitempty"`
+	// Swagger 2.0
+	Consumes []string `json:"consumes,omitempty"`
+}
+
+// OpenAPIParameter is a path, query, header or cookie parameter.
+// Swagger 2.0 also has body and formData parameters
+type OpenAPIParameter struct {
+	Ref      string         `json:"$ref,omitempty"`
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   map[string]any `json:"schema,omitempty"`
+	Example  any            `json:"example,omitempty"`
+	// Swagger 2.0 declares the type on the parameter
+	Type    string `json:"type,omitempty"`
+	Default any    `json:"default,omitempty"`
+	Enum    []any  `json:"enum,omitempty"`
+}
+
+// OpenAPIRequestBody is the body of an OpenAPI 3.x operation, by media type
+type OpenAPIRequestBody struct {
+	Ref     string                      `json:"$ref,omitempty"`
+	Content map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIMediaType is the schema and the examples of a body
+type OpenAPIMediaType struct {
+	Schema   map[string]any `json:"schema,omitempty"`
+	Example  any            `json:"example,omitempty"`
+	Examples map[string]struct {
+		Value any `json:"value"`
+	} `json:"examples,omitempty"`
+}
+
+// OpenAPISecurityScheme is a security scheme of OpenAPI 3.x, or a security definition of Swagger 2.0
+type OpenAPISecurityScheme struct {
+	Ref    string `json:"$ref,omitempty"`
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+// isOpenAPI determines if the JSON contains an OpenAPI 3.x or Swagger 2.0 spec
+func isOpenAPI(jsonString map[string]any) bool {
+	openapi, _ := jsonString["openapi"].(string)
+	swagger, _ := jsonString["swagger"].(string)
+	_, pathsExists := jsonString["paths"]
+
+	return pathsExists && (strings.HasPrefix(openapi, "3.") || swagger == "2.0")
+}
+
+// envCollector keeps the env keys of the requests, in the order they are found.
+// The first value of a key is kept
+type envCollector struct {
+	values []EnvValues
+	seen   map[string]bool
+}
+
+func (e *envCollector) add(key, value string) {
+	if e.seen == nil {
+		e.seen = make(map[string]bool)
+	}
+
+	if key == "" || e.seen[key] {
+		return
+	}
+
+	e.seen[key] = true
+	e.values = append(e.values, EnvValues{Key: key, Value: value, Enabled: true})
+}
+
+// migrateOpenAPI creates a request file for each operation of the spec, in a directory for each tag,
+// and appends the server url, parameters and credentials to global.env
+func migrateOpenAPI(jsonStr map[string]any) error {
+	jsonBytes, err := json.Marshal(jsonStr)
+	if err != nil {
+		return fmt.Errorf("failed to marshal content: %w", err)
+	}
+
+	var spec OpenAPISpec
+	if err := json.Unmarshal(jsonBytes, &spec); err != nil {
+		return fmt.Errorf("failed to parse the OpenAPI spec: %w", err)
+	}
+
+	spec.root = jsonStr
+
+	title := spec.Info.Title
+	if title == "" {
+		title = "openapi"
+	}
+
+	dirPath, err := utils.CreatePath(sanitizeKey(title))
+	if err != nil {
+		return err
+	}
+
+	if err = utils.CreateDir(dirPath); err != nil {
+		return err
+	}
+
+	if spec.Info.Description != "" {
+		descFilePath := filepath.Join(dirPath, "description.md")
+		if err = os.WriteFile(descFilePath, []byte(spec.Info.Description), utils.FilePer); err != nil {
+			return err
+		}
+	}
+
+	env := &envCollector{}
+	env.add(baseURLKey, spec.baseURL())
+
+	fileNames := make(map[string]int)
+
+	for _, path := range slices.Sorted(maps.Keys(spec.Paths)) {
+		pathItem := spec.Paths[path]
+
+		var sharedParams []OpenAPIParameter
+		if raw, ok := pathItem["parameters"]; ok {
+			if err := json.Unmarshal(raw, &sharedParams); err != nil {
+				return fmt.Errorf("invalid parameters of '%s': %w", path, err)
+			}
+		}
+
+		for _, method := range openAPIMethods {
+			raw, ok := pathItem[method]
+			if !ok {
+				continue
+			}
+
+			var operation OpenAPIOperation
+			if err := json.Unmarshal(raw, &operation); err != nil {
+				return fmt.Errorf("invalid operation '%s %s': %w", method, path, err)
+			}
+
+			content, err := spec.requestYaml(method, path, operation, sharedParams, env)
+			if err != nil {
+				return fmt.Errorf("failed to convert '%s %s': %w", method, path, err)
+			}
+
+			opDir := dirPath
+			if len(operation.Tags) > 0 && sanitizeKey(operation.Tags[0]) != "" {
+				opDir = filepath.Join(dirPath, sanitizeKey(operation.Tags[0]))
+				if err := os.MkdirAll(opDir, os.ModePerm); err != nil {
+					return fmt.Errorf("failed to create directory '%s': %w", opDir, err)
+				}
+			}
+
+			name := operationFileName(method, path, operation)
+
+			// operations with the same name in a directory get a number
+			fileNames[filepath.Join(opDir, name)]++
+			if count := fileNames[filepath.Join(opDir, name)]; count > 1 {
+				name = fmt.Sprintf("%s_%d", name, count)
+			}
+
+			reqFilePath := filepath.Join(opDir, name+utils.YAML)
+			if err := os.WriteFile(reqFilePath, []byte(content), utils.FilePer); err != nil {
+				return fmt.Errorf("failed to write request file '%s': %w", reqFilePath, err)
+			}
+		}
+	}
+
+	if err := migrateEnv(Environment{Name: "", Scope: "globals", Values: env.values}, title); err != nil {
+		utils.PrintRed("Error occurred while migrating the OpenAPI variables")
+
+		return err
+	}
+
+	utils.PrintGreen("OpenAPI Migration Successful! " + utils.CheckMark)
+
+	return nil
+}
+
+// baseURL is the first server of OpenAPI 3.x with the default of its variables,
+// or the scheme, host and basePath of Swagger 2.0
+func (s *OpenAPISpec) baseURL() string {
+	if s.Swagger != "" {
+		if s.Host == "" {
+			return ""
+		}
+
+		scheme := "https"
+		if len(s.Schemes) > 0 && !slices.Contains(s.Schemes, "https") {
+			scheme = s.Schemes[0]
+		}
+
+		return strings.TrimSuffix(scheme+"://"+s.Host+s.BasePath, "/")
+	}
+
+	if len(s.Servers) == 0 {
+		return ""
+	}
+
+	server := s.Servers[0]
+
+	serverURL := server.URL
+	for name, variable := range server.Variables {
+		serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+	}
+
+	if !strings.Contains(serverURL, "://") {
+		utils.PrintWarning("Server url '" + serverURL + "' is relative. Update baseUrl in global.env")
+	}
+
+	return strings.TrimSuffix(serverURL, "/")
+}
+
+// operationFileName is the operationId, or the method and the path, like get_pets_petId
+func operationFileName(method, path string, operation OpenAPIOperation) string {
+	if name := sanitizeKey(operation.OperationID); name != "" {
+		return name
+	}
+
+	words := strings.FieldsFunc(path, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(append([]string{method}, words...), "_")
+}
+
+// placeholder is the template of the env key for a parameter
+func placeholder(key string) string {
+	return "{{." + key + "}}"
+}
+
+// requestYaml converts the operation to an api file
+func (s *OpenAPISpec) requestYaml(
+	method, path string,
+	operation OpenAPIOperation,
+	sharedParams []OpenAPIParameter,
+	env *envCollector,
+) (string, error) {
+	params, err := s.parameters(sharedParams, operation.Parameters)
+	if err != nil {
+		return "", err
+	}
+
+	urlPath := path
+
+	var urlParams, headers yaml.MapSlice
+
+	var bodyParams []OpenAPIParameter
+
+	for _, param := range params {
+		key := sanitizeKey(param.Name)
+
+		switch param.In {
+		case "path":
+			urlPath = strings.ReplaceAll(urlPath, "{"+param.Name+"}", placeholder(key))
+			env.add(key, s.parameterValue(param))
+		case "query":
+			urlParams = append(urlParams, yaml.MapItem{Key: param.Name, Value: placeholder(key)})
+			env.add(key, s.parameterValue(param))
+		case "header":
+			// OpenAPI ignores these header parameters, they come from the body and the security
+			switch strings.ToLower(param.Name) {
+			case "accept", "content-type", "authorization":
+				continue
+			}
+
+			headers = append(headers, yaml.MapItem{Key: param.Name, Value: placeholder(key)})
+			env.add(key, s.parameterValue(param))
+		case "body", "formData":
+			bodyParams = append(bodyParams, param)
+		}
+	}
+
+	request := yaml.MapSlice{
+		{Key: "method", Value: strings.ToUpper(method)},
+		{Key: "url", Value: placeholder(baseURLKey) + urlPath},
+	}
+
+	if len(urlParams) > 0 {
+		request = append(request, yaml.MapItem{Key: "urlparams", Value: urlParams})
+	}
+
+	body, contentType, err := s.body(operation, bodyParams)
+	if err != nil {
+		return "", err
+	}
+
+	if contentType != "" {
+		headers = append(headers, yaml.MapItem{Key: "content-type", Value: contentType})
+	}
+
+	if len(headers) > 0 {
+		request = append(request, yaml.MapItem{Key: "headers", Value: headers})
+	}
+
+	if auth := s.auth(operation, env); auth != nil {
+		request = append(request, yaml.MapItem{Key: "auth", Value: auth})
+	}
+
+	if body != nil {
+		request = append(request, yaml.MapItem{Key: "body", Value: body})
+	}
+
+	yamlBytes, err := yaml.MarshalWithOptions(request, yaml.UseLiteralStyleIfMultiline(true))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request to YAML: %w", err)
+	}
+
+	header := fmt.Sprintf("---\n# Request: %s %s\n", strings.ToUpper(method), path)
+	if operation.Summary != "" {
+		header += fmt.Sprintf("# Summary: %s\n", operation.Summary)
+	}
+
+	return header + string(yamlBytes), nil
+}
+
+// parameters resolves the $ref of the parameters. Parameters of the operation
+// replace the parameters of the path with the same name and location
+func (s *OpenAPISpec) parameters(shared, own []OpenAPIParameter) ([]OpenAPIParameter, error) {
+	var result []OpenAPIParameter
+
+	for _, param := range slices.Concat(shared, own) {
+		if param.Ref != "" {
+			var resolved OpenAPIParameter
+			if err := s.resolveInto(param.Ref, &resolved); err != nil {
+				return nil, err
+			}
+
+			param = resolved
+		}
+
+		result = slices.DeleteFunc(result, func(existing OpenAPIParameter) bool {
+			return existing.Name == param.Name && existing.In == param.In
+		})
+		result = append(result, param)
+	}
+
+	return result, nil
+}
+
+// parameterValue is the example, default or first enum value of the parameter for the env file
+func (s *OpenAPISpec) parameterValue(param OpenAPIParameter) string {
+	for _, value := range []any{param.Example, param.Default, firstOf(param.Enum)} {
+		if value != nil {
+			return scalarString(value)
+		}
+	}
+
+	if param.Schema != nil {
+		for _, key := range []string{"example", "default"} {
+			if value, ok := param.Schema[key]; ok {
+				return scalarString(value)
+			}
+		}
+
+		if enum, ok := param.Schema["enum"].([]any); ok && len(enum) > 0 {
+			return scalarString(enum[0])
+		}
+	}
+
+	return ""
+}
+
+// body is the example body of the operation in hulak's format, and its content type.
+// Json is preferred, then url encoded form, multipart form and anything else
+func (s *OpenAPISpec) body(operation OpenAPIOperation, bodyParams []OpenAPIParameter) (map[string]any, string, error) {
+	if s.Swagger != "" {
+		return s.swaggerBody(operation, bodyParams)
+	}
+
+	requestBody := operation.RequestBody
+	if requestBody == nil {
+		return nil, "", nil
+	}
+
+	if requestBody.Ref != "" {
+		requestBody = &OpenAPIRequestBody{}
+		if err := s.resolveInto(operation.RequestBody.Ref, requestBody); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if len(requestBody.Content) == 0 {
+		return nil, "", nil
+	}
+
+	mediaType := preferredMediaType(slices.Collect(maps.Keys(requestBody.Content)))
+	content := requestBody.Content[mediaType]
+
+	example := content.Example
+	if example == nil {
+		for _, name := range slices.Sorted(maps.Keys(content.Examples)) {
+			example = content.Examples[name].Value
+
+			break
+		}
+	}
+
+	if example == nil {
+		example = s.example(content.Schema, map[string]bool{}, 0)
+	}
+
+	return bodyFor(mediaType, example)
+}
+
+// swaggerBody is the body of the body parameter, or the form of the formData parameters
+func (s *OpenAPISpec) swaggerBody(operation OpenAPIOperation, bodyParams []OpenAPIParameter) (map[string]any, string, error) {
+	consumes := operation.Consumes
+	if len(consumes) == 0 {
+		consumes = s.Consumes
+	}
+
+	form := make(map[string]any)
+
+	for _, param := range bodyParams {
+		if param.In == "body" {
+			mediaType := "application/json"
+			if len(consumes) > 0 {
+				mediaType = preferredMediaType(consumes)
+			}
+
+			return bodyFor(mediaType, s.example(param.Schema, map[string]bool{}, 0))
+		}
+
+		value := s.parameterValue(param)
+		if value == "" {
+			value = param.Name
+		}
+
+		form[param.Name] = value
+	}
+
+	if len(form) == 0 {
+		return nil, "", nil
+	}
+
+	if slices.Contains(consumes, "multipart/form-data") {
+		return bodyFor("multipart/form-data", form)
+	}
+
+	return bodyFor("application/x-www-form-urlencoded", form)
+}
+
+// preferredMediaType picks json, then url encoded form, multipart form, and then the first one
+func preferredMediaType(mediaTypes []string) string {
+	slices.Sort(mediaTypes)
+
+	for _, preferred := range []func(string) bool{
+		func(m string) bool { return m == "application/json" },
+		func(m string) bool { return strings.HasSuffix(m, "+json") || strings.Contains(m, "json") },
+		func(m string) bool { return m == "application/x-www-form-urlencoded" },
+		func(m string) bool { return m == "multipart/form-data" },
+	} {
+		if index := slices.IndexFunc(mediaTypes, preferred); index >= 0 {
+			return mediaTypes[index]
+		}
+	}
+
+	return mediaTypes[0]
+}
+
+// bodyFor converts the example to hulak's body. Forms set their own content type,
+// so the content type is only returned for raw bodies
+func bodyFor(mediaType string, example any) (map[string]any, string, error) {
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		fields := make(map[string]string)
+
+		if object, ok := example.(map[string]any); ok {
+			for key, value := range object {
+				fields[key] = scalarString(value)
+			}
+		}
+
+		if len(fields) == 0 {
+			return nil, "", nil
+		}
+
+		if mediaType == "multipart/form-data" {
+			return map[string]any{"formdata": fields}, "", nil
+		}
+
+		return map[string]any{"urlencodedformdata": fields}, "", nil
+	}
+
+	if example == nil {
+		return nil, "", nil
+	}
+
+	if str, ok := example.(string); ok && !strings.Contains(mediaType, "json") {
+		return map[string]any{"raw": str}, mediaType, nil
+	}
+
+	raw, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal the example body: %w", err)
+	}
+
+	return map[string]any{"raw": string(raw)}, mediaType, nil
+}
+
+// example builds an example value from the schema. Examples and defaults in the schema are used as they are
+func (s *OpenAPISpec) example(schema map[string]any, seen map[string]bool, depth int) any {
+	if schema == nil || depth > maxExampleDepth {
+		return nil
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		// recursive schemas stop at the second visit
+		if seen[ref] {
+			return nil
+		}
+
+		var resolved map[string]any
+		if err := s.resolveInto(ref, &resolved); err != nil {
+			return nil
+		}
+
+		seen[ref] = true
+		defer delete(seen, ref)
+
+		return s.example(resolved, seen, depth+1)
+	}
+
+	if value, ok := schema["example"]; ok {
+		return value
+	}
+
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+
+	if value, ok := schema["default"]; ok {
+		return value
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		return enum[0]
+	}
+
+	if allOf, ok := schema["allOf"].([]any); ok {
+		merged := make(map[string]any)
+
+		for _, item := range allOf {
+			subSchema, _ := item.(map[string]any)
+			if object, ok := s.example(subSchema, seen, depth+1).(map[string]any); ok {
+				maps.Copy(merged, object)
+			}
+		}
+
+		return merged
+	}
+
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]any); ok && len(options) > 0 {
+			subSchema, _ := options[0].(map[string]any)
+
+			return s.example(subSchema, seen, depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		object := make(map[string]any)
+
+		properties, _ := schema["properties"].(map[string]any)
+		for name, property := range properties {
+			propertySchema, _ := property.(map[string]any)
+			if readOnly, _ := propertySchema["readOnly"].(bool); readOnly {
+				continue
+			}
+
+			// recursive properties have no example
+			if value := s.example(propertySchema, seen, depth+1); value != nil {
+				object[name] = value
+			}
+		}
+
+		return object
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if item := s.example(items, seen, depth+1); item != nil {
+			return []any{item}
+		}
+
+		return []any{}
+	case "integer", "number":
+		return 0
+	case "boolean":
+		return false
+	case "string":
+		return stringExample(schema)
+	default:
+		return nil
+	}
+}
+
+// schemaType is the type of the schema. OpenAPI 3.1 types could be a list, like [string, "null"]
+func schemaType(schema map[string]any) string {
+	switch value := schema["type"].(type) {
+	case string:
+		return value
+	case []any:
+		for _, each := range value {
+			if str, ok := each.(string); ok && str != "null" {
+				return str
+			}
+		}
+	}
+
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+
+	return ""
+}
+
+func stringExample(schema map[string]any) string {
+	format, _ := schema["format"].(string)
+
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "email":
+		return "user@example.com"
+	case "uuid":
+		return "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		return "https://example.com"
+	default:
+		return "string"
+	}
+}
+
+// auth converts the first security requirement of the operation, or of the spec, to hulak's auth section.
+// OAuth 2.0 and OpenID Connect become bearer tokens
+func (s *OpenAPISpec) auth(operation OpenAPIOperation, env *envCollector) yaml.MapSlice {
+	requirements := s.Security
+	if operation.Security != nil {
+		requirements = *operation.Security
+	}
+
+	schemes := s.Components.SecuritySchemes
+	if s.Swagger != "" {
+		schemes = s.SecurityDefinitions
+	}
+
+	for _, requirement := range requirements {
+		for _, name := range slices.Sorted(maps.Keys(requirement)) {
+			scheme, ok := schemes[name]
+			if !ok {
+				continue
+			}
+
+			if scheme.Ref != "" {
+				if err := s.resolveInto(scheme.Ref, &scheme); err != nil {
+					continue
+				}
+			}
+
+			if auth := schemeToAuth(name, scheme, env); auth != nil {
+				return auth
+			}
+		}
+	}
+
+	return nil
+}
+
+func schemeToAuth(name string, scheme OpenAPISecurityScheme, env *envCollector) yaml.MapSlice {
+	key := sanitizeKey(name)
+
+	switch strings.ToLower(scheme.Type) {
+	case "http", "basic":
+		httpScheme := strings.ToLower(scheme.Scheme)
+		if scheme.Type == "basic" {
+			httpScheme = "basic"
+		}
+
+		switch httpScheme {
+		case "basic", "digest":
+			env.add(key+"_username", "")
+			env.add(key+"_password", "")
+
+			return yaml.MapSlice{
+				{Key: "type", Value: httpScheme},
+				{Key: "username", Value: placeholder(key + "_username")},
+				{Key: "password", Value: placeholder(key + "_password")},
+			}
+		case "bearer":
+			env.add(key, "")
+
+			return yaml.MapSlice{{Key: "type", Value: "bearer"}, {Key: "token", Value: placeholder(key)}}
+		}
+	case "apikey":
+		if scheme.In != "header" && scheme.In != "query" {
+			return nil
+		}
+
+		env.add(key, "")
+
+		return yaml.MapSlice{
+			{Key: "type", Value: "apikey"},
+			{Key: "key", Value: scheme.Name},
+			{Key: "value", Value: placeholder(key)},
+			{Key: "in", Value: scheme.In},
+		}
+	case "oauth2", "openidconnect":
+		env.add(key, "")
+
+		return yaml.MapSlice{{Key: "type", Value: "bearer"}, {Key: "token", Value: placeholder(key)}}
+	}
+
+	return nil
+}
+
+// resolveInto decodes the part of the spec the local $ref points to, like #/components/schemas/Pet
+func (s *OpenAPISpec) resolveInto(ref string, target any) error {
+	if !strings.HasPrefix(ref, "#/") {
+		return fmt.Errorf("only local $ref are supported, got '%s'", ref)
+	}
+
+	var node any = s.root
+
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	for part := range strings.SplitSeq(ref[2:], "/") {
+		object, ok := node.(map[string]any)
+		if !ok {
+			return fmt.Errorf("could not resolve '%s'", ref)
+		}
+
+		if node, ok = object[unescape.Replace(part)]; !ok {
+			return fmt.Errorf("could not resolve '%s'", ref)
+		}
+	}
+
+	content, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(content, target)
+}
+
+func firstOf(values []any) any {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values[0]
+}
+
+// scalarString is the value for env files and forms. Lists and objects are kept as json
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		content, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+
+		return string(content)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,313 @@
+package migration
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xaaha/hulak/pkg/yamlparser"
+)
+
+const petStoreSpec = `
+openapi: 3.0.3
+info:
+  title: Pet Store
+servers:
+  - url: "https://{region}.petstore.io/v1/"
+    variables:
+      region:
+        default: eu
+security:
+  - bearerAuth: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      summary: List all pets
+      tags: [pets]
+      parameters:
+        - name: limit
+          in: query
+          schema: {type: integer, default: 20}
+        - $ref: "#/components/parameters/TraceId"
+    post:
+      operationId: createPet
+      tags: [pets]
+      requestBody:
+        $ref: "#/components/requestBodies/PetBody"
+  /pets/{petId}:
+    parameters:
+      - name: petId
+        in: path
+        required: true
+        schema: {type: string, example: "42"}
+    get:
+      tags: [pets]
+      security:
+        - apiKey: []
+    delete:
+      tags: [pets]
+      security: []
+  /login:
+    post:
+      operationId: login
+      security:
+        - basicAuth: []
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                username: {type: string, example: john}
+components:
+  parameters:
+    TraceId:
+      name: X-Trace-Id
+      in: header
+      schema: {type: string, format: uuid}
+  requestBodies:
+    PetBody:
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer, readOnly: true}
+        name: {type: string, example: Rex}
+        parent: {$ref: "#/components/schemas/Pet"}
+  securitySchemes:
+    bearerAuth: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+    basicAuth: {type: http, scheme: basic}
+`
+
+const legacySpec = `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy API"},
+  "host": "api.legacy.io",
+  "basePath": "/v2",
+  "schemes": ["http", "https"],
+  "securityDefinitions": {"key": {"type": "apiKey", "in": "query", "name": "api_key"}},
+  "security": [{"key": []}],
+  "paths": {
+    "/users/{id}": {
+      "put": {
+        "tags": ["users"],
+        "consumes": ["application/json"],
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "type": "integer"},
+          {"name": "body", "in": "body", "schema": {"$ref": "#/definitions/User"}}
+        ]
+      }
+    },
+    "/upload": {
+      "post": {
+        "consumes": ["multipart/form-data"],
+        "parameters": [{"name": "note", "in": "formData", "type": "string", "default": "hi"}]
+      }
+    }
+  },
+  "definitions": {"User": {"type": "object", "properties": {"name": {"type": "string"}, "age": {"type": "integer"}}}}
+}`
+
+func TestIsOpenAPI(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  map[string]any
+		expected bool
+	}{
+		{"openapi 3", map[string]any{"openapi": "3.1.0", "paths": map[string]any{}}, true},
+		{"swagger 2", map[string]any{"swagger": "2.0", "paths": map[string]any{}}, true},
+		{"without paths", map[string]any{"openapi": "3.0.0"}, false},
+		{"postman collection", map[string]any{"info": map[string]any{}, "item": []any{}}, false},
+	}
+
+	for _, tc := range tests {
+		if got := isOpenAPI(tc.content); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestOpenAPIExample(t *testing.T) {
+	spec := &OpenAPISpec{root: map[string]any{
+		"components": map[string]any{
+			"schemas": map[string]any{
+				"Node": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"id":       map[string]any{"type": "string", "format": "uuid"},
+						"children": map[string]any{"type": "array", "items": map[string]any{"$ref": "#/components/schemas/Node"}},
+					},
+				},
+			},
+		},
+	}}
+
+	tests := []struct {
+		name     string
+		schema   map[string]any
+		expected any
+	}{
+		{"example wins", map[string]any{"type": "string", "example": "hulak"}, "hulak"},
+		{"first enum value", map[string]any{"type": "string", "enum": []any{"asc", "desc"}}, "asc"},
+		{"nullable type of 3.1", map[string]any{"type": []any{"null", "boolean"}}, false},
+		{"date-time", map[string]any{"type": "string", "format": "date-time"}, "2024-01-01T00:00:00Z"},
+		{
+			"allOf is merged",
+			map[string]any{"allOf": []any{
+				map[string]any{"properties": map[string]any{"a": map[string]any{"type": "integer"}}},
+				map[string]any{"properties": map[string]any{"b": map[string]any{"type": "string", "default": "x"}}},
+			}},
+			map[string]any{"a": 0, "b": "x"},
+		},
+		{
+			"recursive ref stops",
+			map[string]any{"$ref": "#/components/schemas/Node"},
+			map[string]any{"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "children": []any{}},
+		},
+		{"missing ref", map[string]any{"$ref": "#/components/schemas/Missing"}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := spec.example(tc.schema, map[string]bool{}, 0)
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %#v, got %#v", tc.expected, got)
+			}
+		})
+	}
+}
+
+// migrateSpec writes the spec in a temporary project and migrates it
+func migrateSpec(t *testing.T, fileName, content string) string {
+	t.Helper()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+
+	if err := os.WriteFile(fileName, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CompleteMigration([]string{fileName}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return dir
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("expected %s: %v", path, err)
+	}
+
+	return string(content)
+}
+
+func TestMigrateOpenAPI(t *testing.T) {
+	dir := migrateSpec(t, "petstore.yaml", petStoreSpec)
+
+	expectedFiles := map[string][]string{
+		"PetStore/pets/listPets.yaml": {
+			"# Summary: List all pets",
+			"method: GET",
+			`url: "{{.baseUrl}}/pets"`,
+			`limit: "{{.limit}}"`,
+			`X-Trace-Id: "{{.XTraceId}}"`,
+			`token: "{{.bearerAuth}}"`,
+		},
+		"PetStore/pets/createPet.yaml": {
+			"content-type: application/json",
+			`"name": "Rex"`,
+		},
+		"PetStore/pets/get_pets_petId.yaml": {
+			`url: "{{.baseUrl}}/pets/{{.petId}}"`,
+			"type: apikey",
+			"key: X-API-Key",
+		},
+		"PetStore/pets/delete_pets_petId.yaml": {"method: DELETE"},
+		"PetStore/login.yaml": {
+			"type: basic",
+			`username: "{{.basicAuth_username}}"`,
+			"urlencodedformdata:",
+			"username: john",
+		},
+	}
+
+	for path, expectedLines := range expectedFiles {
+		content := readFile(t, filepath.Join(dir, path))
+		for _, line := range expectedLines {
+			if !strings.Contains(content, line) {
+				t.Errorf("expected %q in %s:\n%s", line, path, content)
+			}
+		}
+	}
+
+	createPet := readFile(t, filepath.Join(dir, "PetStore/pets/createPet.yaml"))
+	if strings.Contains(createPet, `"id"`) || strings.Contains(createPet, `"parent"`) {
+		t.Errorf("expected no readOnly or recursive properties in the body:\n%s", createPet)
+	}
+
+	if strings.Contains(readFile(t, filepath.Join(dir, "PetStore/pets/delete_pets_petId.yaml")), "auth:") {
+		t.Error("expected no auth for an operation with empty security")
+	}
+
+	env := readFile(t, filepath.Join(dir, "env/global.env"))
+	for _, line := range []string{"### Pet Store ###", "baseUrl = https://eu.petstore.io/v1\n", "limit = 20", "petId = 42", "# bearerAuth = "} {
+		if !strings.Contains(env, line) {
+			t.Errorf("expected %q in global.env:\n%s", line, env)
+		}
+	}
+
+	secrets := map[string]any{"baseUrl": "https://eu.petstore.io/v1", "petId": "42", "apiKey": "k"}
+
+	file, valid, err := yamlparser.FinalStructForAPI(filepath.Join(dir, "PetStore/pets/get_pets_petId.yaml"), secrets, nil)
+	if err != nil || !valid {
+		t.Fatalf("expected a valid api file, got %v", err)
+	}
+
+	if file.URL != "https://eu.petstore.io/v1/pets/42" || file.Auth == nil || file.Auth.Value != "k" {
+		t.Errorf("unexpected api file %+v", file)
+	}
+}
+
+func TestMigrateSwagger(t *testing.T) {
+	dir := migrateSpec(t, "legacy.json", legacySpec)
+
+	user := readFile(t, filepath.Join(dir, "LegacyAPI/users/put_users_id.yaml"))
+	for _, line := range []string{
+		"method: PUT",
+		`url: "{{.baseUrl}}/users/{{.id}}"`,
+		"key: api_key",
+		"in: query",
+		`"age": 0`,
+	} {
+		if !strings.Contains(user, line) {
+			t.Errorf("expected %q in put_users_id.yaml:\n%s", line, user)
+		}
+	}
+
+	upload := readFile(t, filepath.Join(dir, "LegacyAPI/post_upload.yaml"))
+	if !strings.Contains(upload, "formdata:\n    note: hi") {
+		t.Errorf("expected the form in post_upload.yaml:\n%s", upload)
+	}
+
+	env := readFile(t, filepath.Join(dir, "env/global.env"))
+	if !strings.Contains(env, "baseUrl = https://api.legacy.io/v2") {
+		t.Errorf("expected the https base url in global.env:\n%s", env)
+	}
+}
@@ -76,6 +76,7 @@ type Request struct {
 	Header                  []KeyValuePair            `json:"header"`
 	Body                    *Body                     `json:"body,omitempty"`
 	URL                     *PMURL                    `json:"url"`
+	Auth                    *PMAuth                   `json:"auth,omitempty"`
 	Description             string                    `json:"description,omitempty"`
 	ProtocolProfileBehavior *map[string]any           `json:"protocolProfileBehavior,omitempty"`
 }
@@ -88,6 +89,22 @@ type PMURL struct {
 	Query []KeyValuePair `json:"query,omitempty"`
 }
 
+// PMAuth represents the auth of a request. Values are in the list named after the type
+type PMAuth struct {
+	Type   string        `json:"type"`
+	Basic  []PMAuthParam `json:"basic,omitempty"`
+	Bearer []PMAuthParam `json:"bearer,omitempty"`
+	APIKey []PMAuthParam `json:"apikey,omitempty"`
+	Digest []PMAuthParam `json:"digest,omitempty"`
+	AWSV4  []PMAuthParam `json:"awsv4,omitempty"`
+}
+
+// PMAuthParam is a value of the auth. Some values, like the options of digest, are not strings
+type PMAuthParam struct {
+	Key   string `json:"key"`
+	Value any    `json:"value"`
+}
+
 type graphQl struct {
 	Variables string `json:"variables,omitempty" yaml:"variables"`
 	Query     string `json:"query,omitempty"     yaml:"query"`
@@ -278,6 +295,81 @@ func headerToYAML(header []KeyValuePair) (string, error) {
 	return string(yamlBytes), nil
 }
 
+// authToYaml converts the auth of the request to hulak's auth section.
+// Types hulak doesn't support, like oauth2, are left out
+func authToYaml(pmAuth *PMAuth) (string, error) {
+	if pmAuth == nil {
+		return "", nil
+	}
+
+	valuesOf := func(params []PMAuthParam) map[string]string {
+		values := make(map[string]string)
+
+		for _, param := range params {
+			if param.Value != nil {
+				values[param.Key] = addDotToTemplate(fmt.Sprint(param.Value))
+			}
+		}
+
+		return values
+	}
+
+	var auth yaml.MapSlice
+
+	switch strings.ToLower(pmAuth.Type) {
+	case "basic", "digest":
+		params := pmAuth.Basic
+		if strings.ToLower(pmAuth.Type) == "digest" {
+			params = pmAuth.Digest
+		}
+
+		values := valuesOf(params)
+		auth = yaml.MapSlice{
+			{Key: "type", Value: strings.ToLower(pmAuth.Type)},
+			{Key: "username", Value: values["username"]},
+			{Key: "password", Value: values["password"]},
+		}
+	case "bearer":
+		auth = yaml.MapSlice{{Key: "type", Value: "bearer"}, {Key: "token", Value: valuesOf(pmAuth.Bearer)["token"]}}
+	case "apikey":
+		values := valuesOf(pmAuth.APIKey)
+
+		in := yamlparser.APIKeyInHeader
+		if values["in"] == yamlparser.APIKeyInQuery {
+			in = yamlparser.APIKeyInQuery
+		}
+
+		auth = yaml.MapSlice{
+			{Key: "type", Value: "apikey"},
+			{Key: "key", Value: values["key"]},
+			{Key: "value", Value: values["value"]},
+			{Key: "in", Value: in},
+		}
+	case "awsv4":
+		values := valuesOf(pmAuth.AWSV4)
+		auth = yaml.MapSlice{
+			{Key: "type", Value: "aws_sigv4"},
+			{Key: "region", Value: values["region"]},
+			{Key: "service", Value: values["service"]},
+			{Key: "access_key", Value: values["accessKey"]},
+			{Key: "secret_key", Value: values["secretKey"]},
+		}
+
+		if values["sessionToken"] != "" {
+			auth = append(auth, yaml.MapItem{Key: "session_token", Value: values["sessionToken"]})
+		}
+	default:
+		return "", nil
+	}
+
+	yamlBytes, err := yaml.Marshal(map[string]any{"auth": auth})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal auth to YAML: %w", err)
+	}
+
+	return strings.TrimSpace(string(yamlBytes)), nil
+}
+
 // bodyToYaml converts a Postman Body struct to a YAML format that matches yamlParser.Body
 func bodyToYaml(pmbody Body) (string, error) {
 	yamlOutput := make(map[string]any)
@@ -366,6 +458,78 @@ func forEachRequest(collection PmCollection, parentDirPath string) error {
 	return processItems(collection.Item, parentDirPath)
 }
 
+// requestToYaml converts the request of the item to the content of an api file
+func requestToYaml(item ItemOrReq) (string, error) {
+	// Convert method to YAML
+	methodYAML, err := methodToYaml(item.Request.Method)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert method for request '%s': %w", item.Name, err)
+	}
+
+	var urlYAML string
+	// Convert URL to YAML
+	if item.Request.URL != nil {
+		urlYAML, err = urlToYaml(*item.Request.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert URL for request '%s': %w", item.Name, err)
+		}
+	} else {
+		return "", fmt.Errorf("URL is nil for request '%s'", item.Name)
+	}
+
+	// Convert headers to YAML
+	headerYAML, err := headerToYAML(item.Request.Header)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert headers for request '%s': %w", item.Name, err)
+	}
+
+	authYAML, err := authToYaml(item.Request.Auth)
+	if err != nil {
+		return "", fmt.Errorf("failed to convert auth for request '%s': %w", item.Name, err)
+	}
+
+	// Convert body to YAML if it exists
+	var bodyYAML string
+
+	if item.Request.Body != nil {
+		bodyYAML, err = bodyToYaml(*item.Request.Body)
+		if err != nil {
+			return "", fmt.Errorf("failed to convert body for request '%s': %w", item.Name, err)
+		}
+	}
+
+	// Build request YAML
+	requestYAML := fmt.Sprintf("---\n# Request: %s\n", item.Name)
+
+	if item.Description != "" {
+		requestYAML += fmt.Sprintf("# Description: %s\n", item.Description)
+	}
+
+	// Remove prefixes and clean up the components
+	methodYAML = strings.TrimPrefix(strings.TrimSpace(methodYAML), "method:")
+	urlYAML = strings.TrimSpace(urlYAML)
+	headerYAML = strings.TrimSpace(headerYAML)
+	bodyYAML = strings.TrimSpace(bodyYAML)
+
+	// Combine all parts with proper indentation
+	requestYAML += fmt.Sprintf("method:%s\n", methodYAML)
+	requestYAML += urlYAML + "\n"
+
+	if headerYAML != "" {
+		requestYAML += headerYAML + "\n"
+	}
+
+	if authYAML != "" {
+		requestYAML += authYAML + "\n"
+	}
+
+	if bodyYAML != "" {
+		requestYAML += bodyYAML + "\n"
+	}
+
+	return requestYAML, nil
+}
+
 func processItems(items []ItemOrReq, parentDirPath string) error {
 	counter := 0
 
@@ -386,39 +550,9 @@ func processItems(items []ItemOrReq, parentDirPath string) error {
 		}
 
 		if item.Request != nil {
-			// Convert method to YAML
-			methodYAML, err := methodToYaml(item.Request.Method)
+			requestYAML, err := requestToYaml(item)
 			if err != nil {
-				return fmt.Errorf("failed to convert method for request '%s': %w", item.Name, err)
-			}
-
-			var urlYAML string
-			// Convert URL to YAML
-			if item.Request.URL != nil {
-				urlYAML, err = urlToYaml(*item.Request.URL)
-				if err != nil {
-					return fmt.Errorf("failed to convert URL for request '%s': %w", item.Name, err)
-				}
-			} else {
-				return fmt.Errorf("URL is nil for request '%s'", item.Name)
-			}
-
-			// Convert headers to YAML
-			headerYAML, err := headerToYAML(item.Request.Header)
-			if err != nil {
-				return fmt.Errorf("failed to convert headers for request '%s': %w", item.Name, err)
-			}
-
-			// Convert body to YAML if it exists
-			var bodyYAML string
-
-			if item.Request.Body != nil {
-				var err error
-
-				bodyYAML, err = bodyToYaml(*item.Request.Body)
-				if err != nil {
-					return fmt.Errorf("failed to convert body for request '%s': %w", item.Name, err)
-				}
+				return err
 			}
 
 			// Save response examples for this request
@@ -438,9 +572,6 @@ func processItems(items []ItemOrReq, parentDirPath string) error {
 				}
 			}
 
-			// Build request YAML
-			requestYAML := fmt.Sprintf("---\n# Request: %s\n", item.Name)
-
 			if item.Description != "" {
 				descriptionFilePath := filepath.Join(itemDirPath, "description.txt")
 				if err := os.WriteFile(descriptionFilePath, []byte(item.Description), os.ModePerm); err != nil {
@@ -450,26 +581,6 @@ func processItems(items []ItemOrReq, parentDirPath string) error {
 						err,
 					)
 				}
-
-				requestYAML += fmt.Sprintf("# Description: %s\n", item.Description)
-			}
-
-			// Remove prefixes and clean up the components
-			methodYAML = strings.TrimPrefix(strings.TrimSpace(methodYAML), "method:")
-			urlYAML = strings.TrimSpace(urlYAML)
-			headerYAML = strings.TrimSpace(headerYAML)
-			bodyYAML = strings.TrimSpace(bodyYAML)
-
-			// Combine all parts with proper indentation
-			requestYAML += fmt.Sprintf("method:%s\n", methodYAML)
-			requestYAML += urlYAML + "\n"
-
-			if headerYAML != "" {
-				requestYAML += headerYAML + "\n"
-			}
-
-			if bodyYAML != "" {
-				requestYAML += bodyYAML + "\n"
 			}
 
 			// Write each request YAML
@@ -709,3 +709,60 @@ func TestBodyToYaml(t *testing.T) {
 		}
 	})
 }
+
+func TestAuthToYaml(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *PMAuth
+		expected string
+	}{
+		{"no auth", nil, ""},
+		{
+			"basic",
+			&PMAuth{Type: "basic", Basic: []PMAuthParam{
+				{Key: "password", Value: "{{password}}"},
+				{Key: "username", Value: "john"},
+			}},
+			"auth:\n  type: basic\n  username: john\n  password: \"{{.password}}\"",
+		},
+		{
+			"bearer",
+			&PMAuth{Type: "bearer", Bearer: []PMAuthParam{{Key: "token", Value: "{{token}}"}}},
+			"auth:\n  type: bearer\n  token: \"{{.token}}\"",
+		},
+		{
+			"apikey in query",
+			&PMAuth{Type: "apikey", APIKey: []PMAuthParam{
+				{Key: "key", Value: "api_key"},
+				{Key: "value", Value: "{{key}}"},
+				{Key: "in", Value: "query"},
+			}},
+			"auth:\n  type: apikey\n  key: api_key\n  value: \"{{.key}}\"\n  in: query",
+		},
+		{
+			"awsv4",
+			&PMAuth{Type: "awsv4", AWSV4: []PMAuthParam{
+				{Key: "accessKey", Value: "{{accessKey}}"},
+				{Key: "secretKey", Value: "{{secretKey}}"},
+				{Key: "region", Value: "us-east-1"},
+				{Key: "service", Value: "execute-api"},
+			}},
+			"auth:\n  type: aws_sigv4\n  region: us-east-1\n  service: execute-api\n" +
+				"  access_key: \"{{.accessKey}}\"\n  secret_key: \"{{.secretKey}}\"",
+		},
+		{"unsupported oauth2", &PMAuth{Type: "oauth2"}, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := authToYaml(tc.input)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if result != tc.expected {
+				t.Errorf("YAML mismatch:\nExpected:\n%s\n\nActual:\n%s", tc.expected, result)
+			}
+		})
+	}
+}
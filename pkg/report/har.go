@@ -0,0 +1,250 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+)
+
+// harVersion is the version of the HAR spec of the report
+const harVersion = "1.2"
+
+type harReport struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	// Comment is the path of the file that made the request
+	Comment string `json:"comment,omitempty"`
+}
+
+type harRequest struct {
+	Method      string       `json:"method"`
+	URL         string       `json:"url"`
+	HTTPVersion string       `json:"httpVersion"`
+	Cookies     []harPair    `json:"cookies"`
+	Headers     []harPair    `json:"headers"`
+	QueryString []harPair    `json:"queryString"`
+	PostData    *harPostData `json:"postData,omitempty"`
+	HeadersSize int          `json:"headersSize"`
+	BodySize    int          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int        `json:"status"`
+	StatusText  string     `json:"statusText"`
+	HTTPVersion string     `json:"httpVersion"`
+	Cookies     []harPair  `json:"cookies"`
+	Headers     []harPair  `json:"headers"`
+	Content     harContent `json:"content"`
+	RedirectURL string     `json:"redirectURL"`
+	HeadersSize int        `json:"headersSize"`
+	BodySize    int        `json:"bodySize"`
+}
+
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// harTimings has only the wait, since the time spent on each phase of the request is not measured
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// writeHAR writes every request and response of the run as a HAR 1.2 log, in the order the files ran.
+// Secrets are redacted like in the html report, so the file can be shared
+func writeHAR(out io.Writer, results []FileResult, secretsMap map[string]any) error {
+	r := newRedactor(secretsMap)
+	report := harReport{Log: harLog{
+		Version: harVersion,
+		Creator: harCreator{Name: "hulak"},
+		Entries: []harEntry{},
+	}}
+
+	for _, result := range results {
+		for _, resp := range result.Responses {
+			if resp.Exchange == nil || resp.Exchange.Method == "" {
+				continue
+			}
+
+			entry := newHAREntry(resp.Exchange, r)
+			entry.Comment = result.Path
+			report.Log.Entries = append(report.Log.Entries, entry)
+		}
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
+
+func newHAREntry(exchange *apicalls.Exchange, r redactor) harEntry {
+	milliseconds := float64(exchange.Duration.Microseconds()) / 1000
+
+	started := exchange.Started
+	if started.IsZero() {
+		started = time.Now()
+	}
+
+	requestBody := r.body(string(exchange.RequestBody))
+	responseBody := r.body(string(exchange.ResponseBody))
+
+	entry := harEntry{
+		StartedDateTime: started.UTC().Format(time.RFC3339Nano),
+		Time:            milliseconds,
+		Request: harRequest{
+			Method:      exchange.Method,
+			URL:         r.url(exchange.URL),
+			HTTPVersion: exchange.Proto,
+			Cookies:     harRequestCookies(exchange.RequestHeaders),
+			Headers:     harHeaders(exchange.RequestHeaders, r),
+			QueryString: harQuery(r.url(exchange.URL)),
+			HeadersSize: -1,
+			BodySize:    len(exchange.RequestBody),
+		},
+		Response: harResponse{
+			Status:      exchange.StatusCode,
+			StatusText:  strings.TrimPrefix(exchange.Status, strconv.Itoa(exchange.StatusCode)+" "),
+			HTTPVersion: exchange.Proto,
+			Cookies:     harResponseCookies(exchange.Cookies),
+			Headers:     harHeaders(exchange.ResponseHeaders, r),
+			Content: harContent{
+				Size:     len(exchange.ResponseBody),
+				MimeType: exchange.ResponseHeaders.Get("Content-Type"),
+				Text:     responseBody,
+			},
+			RedirectURL: exchange.ResponseHeaders.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(exchange.ResponseBody),
+		},
+		Timings: harTimings{Wait: milliseconds},
+	}
+
+	if len(exchange.RequestBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: exchange.RequestHeaders.Get("Content-Type"),
+			Text:     requestBody,
+		}
+	}
+
+	return entry
+}
+
+// harHeaders lists each value of the headers, sorted by name, with the sensitive ones redacted
+func harHeaders(header http.Header, r redactor) []harPair {
+	pairs := []harPair{}
+
+	for name, values := range header {
+		for _, value := range values {
+			if isSensitive(name) {
+				value = redacted
+			}
+
+			pairs = append(pairs, harPair{Name: name, Value: r.text(value)})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return pairs[i].Name < pairs[j].Name
+	})
+
+	return pairs
+}
+
+// harQuery lists the params of the url, which is already redacted
+func harQuery(rawURL string) []harPair {
+	pairs := []harPair{}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return pairs
+	}
+
+	for part := range strings.SplitSeq(u.RawQuery, "&") {
+		if part == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(part, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+
+		pairs = append(pairs, harPair{Name: name, Value: value})
+	}
+
+	return pairs
+}
+
+// harRequestCookies lists the names of the cookies sent with the request. Values are always redacted
+func harRequestCookies(header http.Header) []harPair {
+	pairs := []harPair{}
+
+	for _, line := range header.Values("Cookie") {
+		cookies, err := http.ParseCookie(line)
+		if err != nil {
+			continue
+		}
+
+		for _, cookie := range cookies {
+			pairs = append(pairs, harPair{Name: cookie.Name, Value: redacted})
+		}
+	}
+
+	return pairs
+}
+
+// harResponseCookies lists the names of the cookies set by the response. Values are always redacted
+func harResponseCookies(cookies []*http.Cookie) []harPair {
+	pairs := []harPair{}
+
+	for _, cookie := range cookies {
+		pairs = append(pairs, harPair{Name: cookie.Name, Value: redacted})
+	}
+
+	return pairs
+}
@@ -0,0 +1,148 @@
+package report
+
+import (
+	"embed"
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+)
+
+//go:embed assets/report.html
+var embeddedFiles embed.FS
+
+var htmlTemplate = template.Must(template.ParseFS(embeddedFiles, "assets/report.html"))
+
+type htmlReport struct {
+	Generated string
+	Summary   summary
+	Duration  string
+	Groups    []htmlGroup
+}
+
+// htmlGroup is the files of a directory
+type htmlGroup struct {
+	Dir   string
+	Files []htmlFile
+}
+
+type htmlFile struct {
+	Path       string
+	Name       string
+	Result     string
+	Status     int
+	Duration   string
+	Retries    int
+	Error      string
+	Assertions []apicalls.AssertionResult
+	Requests   []htmlRequest
+}
+
+type htmlRequest struct {
+	Method          string
+	URL             string
+	Status          int
+	StatusText      string
+	Duration        string
+	RequestHeaders  []htmlHeader
+	RequestBody     string
+	ResponseHeaders []htmlHeader
+	ResponseBody    string
+}
+
+type htmlHeader struct {
+	Name  string
+	Value string
+}
+
+// writeHTML writes a single page with every request of the run, grouped by the directory of the file.
+// Secrets are redacted from the urls, headers and bodies
+func writeHTML(out io.Writer, results []FileResult, secretsMap map[string]any) error {
+	r := newRedactor(secretsMap)
+	s := summarize(results)
+
+	report := htmlReport{
+		Generated: time.Now().Format(time.RFC1123),
+		Summary:   s,
+		Duration:  formatDuration(s.Duration),
+	}
+
+	groups := make(map[string]*htmlGroup)
+
+	var dirs []string
+
+	for _, result := range results {
+		dir := filepath.Dir(result.Path)
+
+		group, ok := groups[dir]
+		if !ok {
+			group = &htmlGroup{Dir: dir}
+			groups[dir] = group
+			dirs = append(dirs, dir)
+		}
+
+		group.Files = append(group.Files, newHTMLFile(result, r))
+	}
+
+	sort.Strings(dirs)
+
+	for _, dir := range dirs {
+		report.Groups = append(report.Groups, *groups[dir])
+	}
+
+	return htmlTemplate.Execute(out, report)
+}
+
+func newHTMLFile(result FileResult, r redactor) htmlFile {
+	file := htmlFile{
+		Path:       result.Path,
+		Name:       filepath.Base(result.Path),
+		Result:     result.outcome(),
+		Status:     result.Status,
+		Duration:   formatDuration(result.Duration),
+		Retries:    result.Retries,
+		Error:      r.text(result.message()),
+		Assertions: result.assertions(),
+	}
+
+	for _, resp := range result.Responses {
+		resp = resp.Detailed()
+		if resp.Request == nil || resp.Response == nil {
+			continue
+		}
+
+		file.Requests = append(file.Requests, htmlRequest{
+			Method:          resp.Request.Method,
+			URL:             r.url(resp.Request.URL),
+			Status:          resp.Response.StatusCode,
+			StatusText:      resp.Response.Status,
+			Duration:        formatDuration(resp.Exchange.Duration),
+			RequestHeaders:  sortedHeaders(r.headers(resp.Request.Headers)),
+			RequestBody:     r.body(prettyJSON(resp.Request.Body)),
+			ResponseHeaders: sortedHeaders(r.headers(resp.Response.Headers)),
+			ResponseBody:    r.body(prettyJSON(resp.Response.Body)),
+		})
+	}
+
+	return file
+}
+
+func sortedHeaders(headers map[string]string) []htmlHeader {
+	sorted := make([]htmlHeader, 0, len(headers))
+	for name, val := range headers {
+		sorted = append(sorted, htmlHeader{Name: name, Value: val})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	return sorted
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond).String()
+}
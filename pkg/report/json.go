@@ -0,0 +1,54 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+)
+
+type jsonReport struct {
+	Summary jsonSummary `json:"summary"`
+	Files   []jsonFile  `json:"files"`
+}
+
+type jsonSummary struct {
+	summary
+	DurationMs int64 `json:"duration_ms"`
+}
+
+type jsonFile struct {
+	Path       string                     `json:"path"`
+	Result     string                     `json:"result"`
+	Status     int                        `json:"status,omitempty"`
+	DurationMs int64                      `json:"duration_ms"`
+	Retries    int                        `json:"retries"`
+	Error      string                     `json:"error,omitempty"`
+	Assertions []apicalls.AssertionResult `json:"assertions,omitempty"`
+}
+
+// writeJSON writes the summary of the run, and the result of each file along with its assertions
+func writeJSON(out io.Writer, results []FileResult) error {
+	s := summarize(results)
+	report := jsonReport{
+		Summary: jsonSummary{summary: s, DurationMs: s.Duration.Milliseconds()},
+		Files:   make([]jsonFile, 0, len(results)),
+	}
+
+	for _, result := range results {
+		report.Files = append(report.Files, jsonFile{
+			Path:       result.Path,
+			Result:     result.outcome(),
+			Status:     result.Status,
+			DurationMs: result.Duration.Milliseconds(),
+			Retries:    result.Retries,
+			Error:      result.message(),
+			Assertions: result.assertions(),
+		})
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// junitSuites is the root of the JUnit report. Each file is a test suite
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Skipped  int          `xml:"skipped,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// seconds formats the duration the way JUnit expects it
+func seconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
+
+// writeJUnit writes a test suite for each file, with a test case for each assertion.
+// Status code and retries of the file are added as properties of the suite
+func writeJUnit(out io.Writer, results []FileResult) error {
+	s := summarize(results)
+	root := junitSuites{Name: "hulak", Time: seconds(s.Duration)}
+
+	for _, result := range results {
+		suite := junitSuite{
+			Name: result.Path,
+			Time: seconds(result.Duration),
+			Properties: []junitProperty{
+				{Name: "retries", Value: strconv.Itoa(result.Retries)},
+			},
+		}
+
+		if result.Status != 0 {
+			suite.Properties = append(suite.Properties,
+				junitProperty{Name: "status", Value: strconv.Itoa(result.Status)})
+		}
+
+		for _, c := range result.testCases() {
+			jc := junitCase{Name: c.name, ClassName: result.Path, Time: seconds(c.duration)}
+
+			switch c.outcome() {
+			case skipped:
+				jc.Skipped = &junitMessage{Message: c.failure}
+				suite.Skipped++
+			case failed:
+				jc.Failure = &junitMessage{Message: firstLine(c.failure), Text: c.failure}
+				suite.Failures++
+			}
+
+			suite.Cases = append(suite.Cases, jc)
+			suite.Tests++
+		}
+
+		root.Tests += suite.Tests
+		root.Failures += suite.Failures
+		root.Skipped += suite.Skipped
+		root.Suites = append(root.Suites, suite)
+	}
+
+	if _, err := fmt.Fprint(out, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(out)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintln(out)
+
+	return err
+}
@@ -0,0 +1,190 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the secrets in the report
+const redacted = "[REDACTED]"
+
+// secret values shorter than this are not replaced in the text, since they would match almost anything
+const minSecretLen = 4
+
+// names of headers, params, body fields and env keys that hold secrets.
+// Names are compared in lower case, without '-' and '_'
+var sensitiveNames = []string{
+	"authorization",
+	"cookie",
+	"token",
+	"secret",
+	"password",
+	"passwd",
+	"apikey",
+	"credential",
+	"session",
+	"signature",
+	"privatekey",
+}
+
+// isSensitive checks if the name of the header, param, field or env key looks like it holds a secret
+func isSensitive(name string) bool {
+	normalized := strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(name))
+
+	for _, sensitive := range sensitiveNames {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactor hides secrets from the requests and responses in the report
+type redactor struct {
+	// values of the sensitive keys in the env, and their url encoded form, longest first
+	secrets []string
+}
+
+// newRedactor collects the values of the sensitive keys in the secretsMap, like apiKey or clientSecret
+func newRedactor(secretsMap map[string]any) redactor {
+	var r redactor
+
+	for key, val := range secretsMap {
+		str, ok := val.(string)
+		if ok && isSensitive(key) && len(str) >= minSecretLen {
+			r.secrets = append(r.secrets, str)
+
+			// secrets in urls and form bodies are url encoded
+			if escaped := url.QueryEscape(str); escaped != str {
+				r.secrets = append(r.secrets, escaped)
+			}
+		}
+	}
+
+	// replace longer secrets first, in case one secret contains another
+	sort.Slice(r.secrets, func(i, j int) bool {
+		return len(r.secrets[i]) > len(r.secrets[j])
+	})
+
+	return r
+}
+
+// text replaces the values of the sensitive env keys in the text
+func (r redactor) text(text string) string {
+	for _, secret := range r.secrets {
+		text = strings.ReplaceAll(text, secret, redacted)
+	}
+
+	return text
+}
+
+// headers redacts sensitive headers, like Authorization and Cookie, along with the secrets in the rest
+func (r redactor) headers(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers))
+
+	for name, val := range headers {
+		if isSensitive(name) {
+			val = redacted
+		}
+
+		result[name] = r.text(val)
+	}
+
+	return result
+}
+
+// url redacts the sensitive params of the url, like ?api_key=
+func (r redactor) url(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return r.text(rawURL)
+	}
+
+	params := u.Query()
+	changed := false
+
+	for name := range params {
+		if isSensitive(name) {
+			params[name] = []string{redacted}
+			changed = true
+		}
+	}
+
+	if changed {
+		// keep the placeholder readable, instead of %5BREDACTED%5D
+		u.RawQuery = strings.ReplaceAll(params.Encode(), url.QueryEscape(redacted), redacted)
+	}
+
+	return r.text(u.String())
+}
+
+// body redacts the sensitive fields of JSON and url encoded bodies,
+// along with the secrets in the rest of the body
+func (r redactor) body(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if trimmed == "" {
+		return ""
+	}
+
+	var parsed any
+	if err := json.Unmarshal([]byte(trimmed), &parsed); err == nil {
+		return r.text(prettyJSON(redactFields(parsed)))
+	}
+
+	if form, err := url.ParseQuery(trimmed); err == nil && strings.Contains(trimmed, "=") &&
+		!strings.ContainsAny(trimmed, " \n") {
+		for name := range form {
+			if isSensitive(name) {
+				form[name] = []string{redacted}
+			}
+		}
+
+		return r.text(form.Encode())
+	}
+
+	return r.text(body)
+}
+
+// redactFields replaces the values of the sensitive fields in the JSON
+func redactFields(val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if isSensitive(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactFields(field)
+			}
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = redactFields(item)
+		}
+	}
+
+	return val
+}
+
+// prettyJSON indents the JSON, or returns it as string when it can't be marshalled
+func prettyJSON(val any) string {
+	if str, ok := val.(string); ok {
+		return str
+	}
+
+	// the html template escapes the body, json doesn't need to
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(val); err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
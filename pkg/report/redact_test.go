@@ -0,0 +1,109 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	r := newRedactor(map[string]any{
+		"apiKey":       "abcd-1234",
+		"clientSecret": "s3cr3t-value",
+		"password":     "p@ss w/rd+1",
+		"baseUrl":      "https://api.example.com",
+		"token":        "abc",
+		"userId":       123,
+	})
+
+	t.Run("headers", func(t *testing.T) {
+		got := r.headers(map[string]string{
+			"Authorization": "Bearer xyz",
+			"X-Api-Key":     "anything",
+			"X-Custom":      "key abcd-1234",
+			"Accept":        "application/json",
+		})
+
+		expected := map[string]string{
+			"Authorization": redacted,
+			"X-Api-Key":     redacted,
+			"X-Custom":      "key " + redacted,
+			"Accept":        "application/json",
+		}
+
+		for name, val := range expected {
+			if got[name] != val {
+				t.Errorf("expected %s to be %q, got %q", name, val, got[name])
+			}
+		}
+	})
+
+	t.Run("url", func(t *testing.T) {
+		got := r.url("https://api.example.com/users?api_key=xyz&page=2")
+		if !strings.Contains(got, "api_key="+redacted) || !strings.Contains(got, "page=2") {
+			t.Errorf("expected only the api_key to be redacted, got %s", got)
+		}
+
+		if !strings.HasPrefix(got, "https://api.example.com/users") {
+			t.Errorf("expected values of non sensitive keys to be kept, got %s", got)
+		}
+
+		got = r.url("https://api.example.com/login?q=p%40ss+w%2Frd%2B1")
+		if got != "https://api.example.com/login?q="+redacted {
+			t.Errorf("expected the url encoded secret to be redacted, got %s", got)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+		hidden   []string
+	}{
+		{
+			name:     "json fields",
+			body:     `{"user": "jane", "password": "hunter2", "nested": {"access_token": "t0k3n"}}`,
+			expected: []string{`"user": "jane"`, `"password": "[REDACTED]"`},
+			hidden:   []string{"hunter2", "t0k3n"},
+		},
+		{
+			name:     "url encoded form",
+			body:     "grant_type=client_credentials&client_secret=xyz",
+			expected: []string{"grant_type=client_credentials"},
+			hidden:   []string{"xyz"},
+		},
+		{
+			name:     "url encoded secret in a form",
+			body:     "user=jane&note=p%40ss+w%2Frd%2B1",
+			expected: []string{"user=jane", "note=" + redacted},
+			hidden:   []string{"p%40ss"},
+		},
+		{
+			name:     "secret in plain text",
+			body:     "the secret is s3cr3t-value",
+			expected: []string{"the secret is [REDACTED]"},
+		},
+		{
+			name:     "short secrets are kept",
+			body:     "abc",
+			expected: []string{"abc"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := r.body(tc.body)
+
+			for _, expected := range tc.expected {
+				if !strings.Contains(got, expected) {
+					t.Errorf("expected %q in %s", expected, got)
+				}
+			}
+
+			for _, hidden := range tc.hidden {
+				if strings.Contains(got, hidden) {
+					t.Errorf("expected %q to be redacted in %s", hidden, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Package report writes the results of a run as JUnit, TAP or JSON for CI systems,
+// or as a single HTML page or a HAR file with every request and response of the run
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// Format of the report
+type Format string
+
+// Formats supported by -report flag
+const (
+	JUnit Format = "junit"
+	TAP   Format = "tap"
+	JSON  Format = "json"
+	HTML  Format = "html"
+	HAR   Format = "har"
+)
+
+// name of the report file when -report-out is not provided
+const defaultFileName = "hulak_report"
+
+// extension of the default report file for each format
+var extensions = map[Format]string{
+	JUnit: ".xml",
+	TAP:   ".tap",
+	JSON:  ".json",
+	HTML:  ".html",
+	HAR:   ".har",
+}
+
+// IsValid checks if the report format is supported
+func (f Format) IsValid() bool {
+	_, ok := extensions[f]
+
+	return ok
+}
+
+// DefaultPath is the file the report is written to when -report-out is not provided
+func (f Format) DefaultPath() string {
+	return defaultFileName + extensions[f]
+}
+
+// outcome of a file or a test case in the report
+const (
+	passed  = "passed"
+	failed  = "failed"
+	skipped = "skipped"
+)
+
+// FileResult is the outcome of a single file in the run
+type FileResult struct {
+	// Path of the file, as it should appear in the report
+	Path     string
+	Status   int
+	Duration time.Duration
+	Retries  int
+	Skipped  bool
+	Err      error
+	// Responses of every request the file made, along with their assertions
+	Responses []apicalls.CustomResponse
+}
+
+// testCase is a single check in the report. It's either an assertion, or the file itself
+type testCase struct {
+	name     string
+	duration time.Duration
+	failure  string
+	skipped  bool
+}
+
+func (c testCase) outcome() string {
+	switch {
+	case c.skipped:
+		return skipped
+	case c.failure != "":
+		return failed
+	default:
+		return passed
+	}
+}
+
+func (r FileResult) outcome() string {
+	switch {
+	case r.Skipped:
+		return skipped
+	case r.Err != nil:
+		return failed
+	default:
+		return passed
+	}
+}
+
+// message is the error of the file without colors
+func (r FileResult) message() string {
+	if r.Err == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(utils.StripColors(r.Err.Error()))
+}
+
+// assertions returns the assertions of all responses of the file
+func (r FileResult) assertions() []apicalls.AssertionResult {
+	var results []apicalls.AssertionResult
+
+	for i, resp := range r.Responses {
+		for _, assertion := range resp.Assertions {
+			assertion.Name = r.assertionName(i, assertion.Name)
+			results = append(results, assertion)
+		}
+	}
+
+	return results
+}
+
+// assertionName adds the number of the request to the name of the assertion, when the file made more than one request
+func (r FileResult) assertionName(request int, name string) string {
+	if len(r.Responses) < 2 {
+		return name
+	}
+
+	return fmt.Sprintf("request %d: %s", request+1, name)
+}
+
+// testCases returns a test case for each assertion of the file, or a single test case
+// for the file when it has none. A file that failed for a reason other than its assertions,
+// like a missing capture or a connection error, gets a test case for the file as well
+func (r FileResult) testCases() []testCase {
+	if r.Skipped {
+		return []testCase{{name: r.Path, skipped: true, failure: r.message()}}
+	}
+
+	var cases []testCase
+
+	assertionFailed := false
+
+	for i, resp := range r.Responses {
+		duration := time.Duration(0)
+		if resp.Exchange != nil {
+			duration = resp.Exchange.Duration
+		}
+
+		for _, assertion := range resp.Assertions {
+			c := testCase{name: r.assertionName(i, assertion.Name), duration: duration}
+			if !assertion.Passed {
+				c.failure = assertion.Message
+				if c.failure == "" {
+					c.failure = "assertion failed"
+				}
+
+				assertionFailed = true
+			}
+
+			cases = append(cases, c)
+		}
+	}
+
+	if len(cases) == 0 || (r.Err != nil && !assertionFailed) {
+		cases = append(cases, testCase{name: r.Path, duration: r.Duration, failure: r.message()})
+	}
+
+	return cases
+}
+
+// summary counts the files of the run by their outcome
+type summary struct {
+	Passed   int           `json:"passed"`
+	Failed   int           `json:"failed"`
+	Skipped  int           `json:"skipped"`
+	Total    int           `json:"total"`
+	Duration time.Duration `json:"-"`
+}
+
+func summarize(results []FileResult) summary {
+	s := summary{Total: len(results)}
+
+	for _, result := range results {
+		s.Duration += result.Duration
+
+		switch result.outcome() {
+		case passed:
+			s.Passed++
+		case failed:
+			s.Failed++
+		case skipped:
+			s.Skipped++
+		}
+	}
+
+	return s
+}
+
+func unsupportedFormat(format Format) error {
+	return utils.ColorError(fmt.Sprintf("unsupported report format '%s'. Use junit, tap, json, html or har", format))
+}
+
+// Render writes the report of the results in the format to out.
+// Values of the sensitive keys in the secretsMap, like apiKey, are redacted from the html and har reports
+func Render(out io.Writer, format Format, results []FileResult, secretsMap map[string]any) error {
+	switch format {
+	case JUnit:
+		return writeJUnit(out, results)
+	case TAP:
+		return writeTAP(out, results)
+	case JSON:
+		return writeJSON(out, results)
+	case HTML:
+		return writeHTML(out, results, secretsMap)
+	case HAR:
+		return writeHAR(out, results, secretsMap)
+	default:
+		return unsupportedFormat(format)
+	}
+}
+
+// Write saves the report of the results in the format to outPath,
+// or to hulak_report with the extension of the format when outPath is empty.
+// Returns the path of the report
+func Write(format Format, outPath string, results []FileResult, secretsMap map[string]any) (string, error) {
+	if !format.IsValid() {
+		return "", unsupportedFormat(format)
+	}
+
+	if outPath == "" {
+		outPath = format.DefaultPath()
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, format, results, secretsMap); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(outPath, buf.Bytes(), utils.FilePer); err != nil {
+		return "", utils.ColorError("error saving the report", err)
+	}
+
+	return outPath, nil
+}
@@ -0,0 +1,363 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+)
+
+func testResults() []FileResult {
+	return []FileResult{
+		{
+			Path:     "users/get_user.yaml",
+			Status:   200,
+			Duration: 150 * time.Millisecond,
+			Responses: []apicalls.CustomResponse{{
+				Exchange: &apicalls.Exchange{StatusCode: 200, Duration: 120 * time.Millisecond},
+			}},
+		},
+		{
+			Path:     "users/create_user.yaml",
+			Status:   500,
+			Duration: 80 * time.Millisecond,
+			Retries:  0,
+			Err:      errors.New("1 of 2 assertions failed in 'create_user.yaml'"),
+			Responses: []apicalls.CustomResponse{{
+				Exchange: &apicalls.Exchange{StatusCode: 500, Duration: 70 * time.Millisecond},
+				Assertions: []apicalls.AssertionResult{
+					{Name: "status 201", Passed: false, Message: "expected 201, got 500"},
+					{Name: "duration under 1s", Passed: true},
+				},
+			}},
+		},
+		{
+			Path:    "users/delete_user.yaml",
+			Skipped: true,
+			Err:     errors.New("skipped because 'create_user.yaml' failed"),
+		},
+		{
+			Path:     "health.yaml",
+			Duration: 2 * time.Second,
+			Retries:  2,
+			Err:      errors.New("\033[31;1mError: connection refused\033[0m"),
+		},
+	}
+}
+
+func TestTestCases(t *testing.T) {
+	results := testResults()
+
+	tests := []struct {
+		name     string
+		result   FileResult
+		expected []testCase
+	}{
+		{
+			name:     "file without assertions",
+			result:   results[0],
+			expected: []testCase{{name: "users/get_user.yaml", duration: 150 * time.Millisecond}},
+		},
+		{
+			name:   "test case for each assertion",
+			result: results[1],
+			expected: []testCase{
+				{name: "status 201", duration: 70 * time.Millisecond, failure: "expected 201, got 500"},
+				{name: "duration under 1s", duration: 70 * time.Millisecond},
+			},
+		},
+		{
+			name:   "skipped file",
+			result: results[2],
+			expected: []testCase{
+				{name: "users/delete_user.yaml", skipped: true, failure: "skipped because 'create_user.yaml' failed"},
+			},
+		},
+		{
+			name:   "failed file without colors",
+			result: results[3],
+			expected: []testCase{
+				{name: "health.yaml", duration: 2 * time.Second, failure: "Error: connection refused"},
+			},
+		},
+		{
+			name: "file failed after its assertions passed",
+			result: FileResult{
+				Path: "login.yaml",
+				Err:  errors.New("capture 'token' not found"),
+				Responses: []apicalls.CustomResponse{{
+					Assertions: []apicalls.AssertionResult{{Name: "status 200", Passed: true}},
+				}},
+			},
+			expected: []testCase{
+				{name: "status 200"},
+				{name: "login.yaml", failure: "capture 'token' not found"},
+			},
+		},
+		{
+			name: "assertions of each request",
+			result: FileResult{
+				Path: "rows.yaml",
+				Responses: []apicalls.CustomResponse{
+					{Assertions: []apicalls.AssertionResult{{Name: "status 200", Passed: true}}},
+					{Assertions: []apicalls.AssertionResult{{Name: "status 200", Passed: true}}},
+				},
+			},
+			expected: []testCase{
+				{name: "request 1: status 200"},
+				{name: "request 2: status 200"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.result.testCases()
+			if len(got) != len(tc.expected) {
+				t.Fatalf("expected %d test cases, got %d: %+v", len(tc.expected), len(got), got)
+			}
+
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Errorf("test case %d: expected %+v, got %+v", i, tc.expected[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, JUnit, testResults(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var suites junitSuites
+	if err := xml.Unmarshal(buf.Bytes(), &suites); err != nil {
+		t.Fatalf("invalid xml: %v\n%s", err, buf.String())
+	}
+
+	if suites.Tests != 5 || suites.Failures != 2 || suites.Skipped != 1 {
+		t.Errorf("expected 5 tests, 2 failures and 1 skipped, got %d, %d and %d",
+			suites.Tests, suites.Failures, suites.Skipped)
+	}
+
+	if len(suites.Suites) != 4 {
+		t.Fatalf("expected a suite for each file, got %d", len(suites.Suites))
+	}
+
+	create := suites.Suites[1]
+	if create.Cases[0].Failure == nil || create.Cases[0].Failure.Message != "expected 201, got 500" {
+		t.Errorf("expected failure message of the assertion, got %+v", create.Cases[0].Failure)
+	}
+
+	if create.Cases[0].Time != "0.070" {
+		t.Errorf("expected duration of the response, got %s", create.Cases[0].Time)
+	}
+
+	health := suites.Suites[3]
+	if health.Properties[0] != (junitProperty{Name: "retries", Value: "2"}) {
+		t.Errorf("expected retries property, got %+v", health.Properties)
+	}
+}
+
+func TestTAP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, TAP, testResults(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+
+	for _, expected := range []string{
+		"TAP version 13\n1..5\n",
+		"ok 1 - users/get_user.yaml\n",
+		"not ok 2 - users/create_user.yaml: status 201\n",
+		"  message: \"expected 201, got 500\"\n",
+		"ok 3 - users/create_user.yaml: duration under 1s\n",
+		"ok 4 - users/delete_user.yaml # SKIP skipped because 'create_user.yaml' failed\n",
+		"not ok 5 - health.yaml\n  ---\n  duration_ms: 2000\n  retries: 2\n",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got\n%s", expected, output)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, JSON, testResults(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var report struct {
+		Summary map[string]int `json:"summary"`
+		Files   []jsonFile     `json:"files"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+
+	expected := map[string]int{"passed": 1, "failed": 2, "skipped": 1, "total": 4, "duration_ms": 2230}
+	for key, val := range expected {
+		if report.Summary[key] != val {
+			t.Errorf("expected summary %s to be %d, got %d", key, val, report.Summary[key])
+		}
+	}
+
+	if report.Files[3].Error != "Error: connection refused" || report.Files[3].Retries != 2 {
+		t.Errorf("unexpected result for failed file: %+v", report.Files[3])
+	}
+
+	if len(report.Files[1].Assertions) != 2 {
+		t.Errorf("expected assertions of the file, got %+v", report.Files[1].Assertions)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	results := testResults()
+	results[0].Responses[0].Exchange = &apicalls.Exchange{
+		Method:          http.MethodPost,
+		URL:             "https://api.example.com/users?page=2",
+		RequestHeaders:  http.Header{"Authorization": {"Bearer t0k3n"}},
+		RequestBody:     []byte(`{"name": "<jane>", "password": "hunter2"}`),
+		StatusCode:      201,
+		Status:          "201 Created",
+		ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		Body:            map[string]any{"id": 1, "apiKey": "abcd-1234"},
+		Duration:        120 * time.Millisecond,
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, HTML, results, map[string]any{"clientSecret": "s3cr3t-value"}); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+
+	for _, expected := range []string{
+		"<h2>users</h2>",
+		"<h2>.</h2>",
+		`<span class="method">POST</span>`,
+		"https://api.example.com/users?page=2",
+		"201 Created",
+		"120ms",
+		"&lt;jane&gt;",
+		"expected 201, got 500",
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected report to contain %q", expected)
+		}
+	}
+
+	for _, secret := range []string{"t0k3n", "hunter2", "abcd-1234"} {
+		if strings.Contains(output, secret) {
+			t.Errorf("expected %q to be redacted", secret)
+		}
+	}
+
+	if strings.Index(output, "<h2>.</h2>") > strings.Index(output, "<h2>users</h2>") {
+		t.Error("expected directories to be sorted")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path, err := Write(JSON, "", testResults(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if path != "hulak_report.json" {
+		t.Errorf("expected default report path, got %s", path)
+	}
+
+	outPath := filepath.Join(dir, "junit.xml")
+	if path, err = Write(JUnit, outPath, testResults(), nil); err != nil || path != outPath {
+		t.Errorf("expected report at %s, got %s: %v", outPath, path, err)
+	}
+
+	if _, err := os.Stat(outPath); err != nil {
+		t.Errorf("expected report file: %v", err)
+	}
+
+	if _, err := Write(Format("xml"), "", testResults(), nil); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+}
+
+func TestHAR(t *testing.T) {
+	results := testResults()
+	results[0].Responses[0].Exchange = &apicalls.Exchange{
+		Method: http.MethodPost,
+		URL:    "https://api.example.com/users?page=2&api_key=abcd-1234",
+		RequestHeaders: http.Header{
+			"Authorization": {"Bearer t0k3n"},
+			"Content-Type":  {"application/json"},
+			"Cookie":        {"session=s3ss10n; theme=dark"},
+		},
+		RequestBody:     []byte(`{"name":"jane","password":"hunter2"}`),
+		StatusCode:      201,
+		Status:          "201 Created",
+		Proto:           "HTTP/1.1",
+		ResponseHeaders: http.Header{"Content-Type": {"application/json"}},
+		Cookies:         []*http.Cookie{{Name: "refresh", Value: "r3fr3sh"}},
+		ResponseBody:    []byte(`{"id":1}`),
+		Started:         time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+		Duration:        120 * time.Millisecond,
+	}
+
+	var buf bytes.Buffer
+	if err := Render(&buf, HAR, results, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var har harReport
+	if err := json.Unmarshal(buf.Bytes(), &har); err != nil {
+		t.Fatalf("invalid HAR: %v", err)
+	}
+
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("expected a single entry of HAR 1.2, got %+v", har.Log)
+	}
+
+	entry := har.Log.Entries[0]
+	if entry.StartedDateTime != "2024-05-01T10:00:00Z" || entry.Time != 120 || entry.Comment != "users/get_user.yaml" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	if entry.Request.PostData == nil || entry.Request.PostData.MimeType != "application/json" {
+		t.Errorf("expected the post data, got %+v", entry.Request.PostData)
+	}
+
+	if entry.Response.StatusText != "Created" || entry.Response.Content.Text != "{\n  \"id\": 1\n}" {
+		t.Errorf("unexpected response %+v", entry.Response)
+	}
+
+	expectedQuery := []harPair{{Name: "api_key", Value: redacted}, {Name: "page", Value: "2"}}
+	if !reflect.DeepEqual(entry.Request.QueryString, expectedQuery) {
+		t.Errorf("expected query %+v, got %+v", expectedQuery, entry.Request.QueryString)
+	}
+
+	expectedCookies := []harPair{{Name: "session", Value: redacted}, {Name: "theme", Value: redacted}}
+	if !reflect.DeepEqual(entry.Request.Cookies, expectedCookies) {
+		t.Errorf("expected cookies %+v, got %+v", expectedCookies, entry.Request.Cookies)
+	}
+
+	for _, secret := range []string{"t0k3n", "hunter2", "abcd-1234", "s3ss10n", "r3fr3sh"} {
+		if strings.Contains(buf.String(), secret) {
+			t.Errorf("expected %q to be redacted", secret)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// writeTAP writes a test line for each assertion, in TAP version 13.
+// Duration, status code, retries and the failure message are added to the YAML block of each test
+func writeTAP(out io.Writer, results []FileResult) error {
+	var b strings.Builder
+
+	total := 0
+	for _, result := range results {
+		total += len(result.testCases())
+	}
+
+	b.WriteString("TAP version 13\n")
+	fmt.Fprintf(&b, "1..%d\n", total)
+
+	number := 0
+
+	for _, result := range results {
+		for _, c := range result.testCases() {
+			number++
+
+			name := c.name
+			if name != result.Path {
+				name = result.Path + ": " + c.name
+			}
+
+			switch c.outcome() {
+			case skipped:
+				fmt.Fprintf(&b, "ok %d - %s # SKIP %s\n", number, tapEscape(name), firstLine(c.failure))
+
+				continue
+			case failed:
+				fmt.Fprintf(&b, "not ok %d - %s\n", number, tapEscape(name))
+			default:
+				fmt.Fprintf(&b, "ok %d - %s\n", number, tapEscape(name))
+			}
+
+			b.WriteString("  ---\n")
+			fmt.Fprintf(&b, "  duration_ms: %d\n", c.duration.Milliseconds())
+
+			if result.Status != 0 {
+				fmt.Fprintf(&b, "  status: %d\n", result.Status)
+			}
+
+			fmt.Fprintf(&b, "  retries: %d\n", result.Retries)
+
+			if c.failure != "" {
+				fmt.Fprintf(&b, "  message: %s\n", strconv.Quote(c.failure))
+			}
+
+			b.WriteString("  ...\n")
+		}
+	}
+
+	_, err := io.WriteString(out, b.String())
+
+	return err
+}
+
+// tapEscape escapes '#' in the description, since it starts a directive in TAP
+func tapEscape(description string) string {
+	return strings.ReplaceAll(description, "#", `\#`)
+}
+
+// firstLine returns the first line of the message
+func firstLine(msg string) string {
+	line, _, _ := strings.Cut(msg, "\n")
+
+	return line
+}
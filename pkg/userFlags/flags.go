@@ -27,6 +27,14 @@ var (
 	//
 	// In the above case, the files in the shallowest directories will be processed before deeper ones.
 	dirseq *string
+	// data is a .csv or .json dataset. Each file runs once for each row
+	data *string
+	// failNon2xx makes responses with non-2xx status fail the run
+	failNon2xx *bool
+	// report is the format of the report saved after the run: junit, tap, json, html or har
+	report *string
+	// reportOut is the path of the report file
+	reportOut *string
 )
 
 // go's init func executes automatically, and registers the flags during package initialization
@@ -60,6 +68,30 @@ func init() {
 		"",
 		"Directory path to run in alphabetical order",
 	)
+
+	data = flag.String(
+		"data",
+		"",
+		"CSV or JSON dataset. Request runs once for each row, with the row's values as variables",
+	)
+
+	failNon2xx = flag.Bool(
+		"fail-non-2xx",
+		false,
+		"fail the run when a response has non-2xx status and the file doesn't assert the status",
+	)
+
+	report = flag.String(
+		"report",
+		"",
+		"Save a report of the run. Supported formats: junit, tap, json, html, har",
+	)
+
+	reportOut = flag.String(
+		"report-out",
+		"",
+		"Path of the report file. Defaults to hulak_report with the extension of the format",
+	)
 }
 
 // FilePath returns the parsed value of the file path "fp" flag -fp
@@ -91,3 +123,23 @@ func Dir() string {
 func Dirseq() string {
 	return *dirseq
 }
+
+// Data represents the dataset the requests run for
+func Data() string {
+	return *data
+}
+
+// FailNon2xx represents if responses with non-2xx status should fail the run
+func FailNon2xx() bool {
+	return *failNon2xx
+}
+
+// Report represents the format of the report saved after the run
+func Report() string {
+	return *report
+}
+
+// ReportOut represents the path of the report file
+func ReportOut() string {
+	return *reportOut
+}
@@ -56,6 +56,11 @@ func printHelp() {
 		{"hulak  -fp path/tofile/getUser.yaml -debug", "Run in global environment with debug mode"},
 		{"hulak -env prod -dir path/to/dir ", "Run all files in the directory concurrently"},
 		{"hulak -env prod -dirseq path/to/dir ", "Run all files in the directory alphabetically"},
+		{"hulak -fp path/tofile/getUser.yaml -data users.csv", "Run the file once for each row in the dataset"},
+		{"hulak -dir path/to/dir -fail-non-2xx", "Fail the run on responses with non-2xx status"},
+		{"hulak -dir path/to/dir -report junit -report-out junit.xml", "Save a JUnit, TAP or JSON report of the run"},
+		{"hulak -dir path/to/dir -report html", "Save every request and response of the run as an HTML page"},
+		{"hulak -dir path/to/dir -report har", "Save every request and response of the run as a HAR file"},
 	})
 
 	w.Flush()
@@ -72,7 +77,9 @@ func printHelpSubCommands() {
 		{"hulak version", "Prints hulak version"},
 		{"hulak init", "Initializes default environment and creates an apiOptions.yaml file"},
 		{"hulak init -env global prod test", "Initializes specific environments"},
-		{"hulak migrate <file1> <file2> ...", "Migrates postman env and collections"},
+		{"hulak migrate <file1> <file2> ...", "Migrates postman env and collections, OpenAPI and Swagger specs, Insomnia exports, Bruno collections and HAR files"},
+		{"hulak import curl -o getUser.yaml 'curl ...'", "Converts the curl command to an api file. The command is read from stdin when it's not given"},
+		{"hulak export curl -fp getUser.yaml -env staging", "Prints the api file, resolved with the environment, as a curl command"},
 	})
 
 	w.Flush()
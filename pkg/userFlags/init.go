@@ -16,6 +16,11 @@ func handleInit() error {
 	if err != nil {
 		return fmt.Errorf("\n invalid subcommand %v", err)
 	}
+
+	// tokens of auth files are saved in the project, and shouldn't be committed
+	if err := utils.AddToGitignore(utils.StateDir + "/"); err != nil {
+		utils.PrintWarning(fmt.Sprintf("Could not add '%s/' to .gitignore: %v", utils.StateDir, err))
+	}
 	// Check if -env flag is present
 	if *createEnvs {
 		envs := initialize.Args()
@@ -5,8 +5,12 @@ import (
 	"embed"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 
+	apicalls "github.com/xaaha/hulak/pkg/apiCalls"
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/features"
 	"github.com/xaaha/hulak/pkg/migration"
 	"github.com/xaaha/hulak/pkg/utils"
 )
@@ -18,6 +22,8 @@ var embeddedFiles embed.FS
 const (
 	Version = "version"
 	Migrate = "migrate"
+	Import  = "import"
+	Export  = "export"
 	// future subcommands
 	Init = "init"
 	Help = "help"
@@ -26,11 +32,23 @@ const (
 var (
 	migrate    *flag.FlagSet
 	initialize *flag.FlagSet
+	importCmd  *flag.FlagSet
+	exportCmd  *flag.FlagSet
 
 	// Flag to indicate if environments should be created
 	createEnvs *bool
+
+	// path of the api file hulak import writes to
+	importOut *string
+
+	// file and environment hulak export renders
+	exportFp  *string
+	exportEnv *string
 )
 
+// formats of hulak import and hulak export
+const curlFormat = "curl"
+
 // go's init func executes automatically, and registers the flags during package initialization
 func init() {
 	migrate = flag.NewFlagSet(Migrate, flag.ExitOnError)
@@ -41,6 +59,13 @@ func init() {
 		false,
 		"Create environment files based on following arguments",
 	)
+
+	importCmd = flag.NewFlagSet(Import, flag.ExitOnError)
+	importOut = importCmd.String("o", "", "Path of the api file. The file is printed when it's empty")
+
+	exportCmd = flag.NewFlagSet(Export, flag.ExitOnError)
+	exportFp = exportCmd.String("fp", "", "File path of the api file to export")
+	exportEnv = exportCmd.String("env", utils.DefaultEnvVal, "environment file to use for the export")
 }
 
 // HandleSubcommands loops through all the subcommands
@@ -64,6 +89,20 @@ func HandleSubcommands() error {
 
 		os.Exit(0)
 
+	case Import:
+		if err := handleImport(); err != nil {
+			return err
+		}
+
+		os.Exit(0)
+
+	case Export:
+		if err := handleExport(); err != nil {
+			return err
+		}
+
+		os.Exit(0)
+
 	case Init:
 		if err := handleInit(); err != nil {
 			return err
@@ -83,3 +122,56 @@ func HandleSubcommands() error {
 
 	return nil
 }
+
+// handleImport converts the command of the format to an api file.
+// The command is read from stdin when it's not in the arguments
+func handleImport() error {
+	if len(os.Args) < 3 || os.Args[2] != curlFormat {
+		return utils.ColorError("please provide the format to import, like 'hulak import curl'")
+	}
+
+	if err := importCmd.Parse(os.Args[3:]); err != nil {
+		return fmt.Errorf("\n invalid subcommand %v", err)
+	}
+
+	args := importCmd.Args()
+	if len(args) == 0 {
+		command, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return utils.ColorError("error reading the curl command", err)
+		}
+
+		args = []string{string(command)}
+	}
+
+	return migration.ImportCurl(args, *importOut)
+}
+
+// handleExport prints the api file, resolved with the environment, in the format
+func handleExport() error {
+	if len(os.Args) < 3 || os.Args[2] != curlFormat {
+		return utils.ColorError("please provide the format to export, like 'hulak export curl'")
+	}
+
+	if err := exportCmd.Parse(os.Args[3:]); err != nil {
+		return fmt.Errorf("\n invalid subcommand %v", err)
+	}
+
+	if *exportFp == "" {
+		return utils.ColorError("please provide the api file to export with '-fp path/to/file.yaml'")
+	}
+
+	secretsMap, err := envparser.GenerateSecretsMap(*exportEnv)
+	if err != nil {
+		return err
+	}
+
+	command, err := apicalls.ExportCurl(*exportFp, secretsMap, features.Token)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(command)
+
+	return nil
+}
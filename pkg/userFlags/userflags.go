@@ -10,12 +10,16 @@ import (
 
 // AllFlags  All user flags and subcommands
 type AllFlags struct {
-	Env      string
-	FilePath string
-	File     string
-	Debug    bool
-	Dir      string
-	Dirseq   string
+	Env        string
+	FilePath   string
+	File       string
+	Debug      bool
+	Dir        string
+	Dirseq     string
+	Data       string
+	FailNon2xx bool
+	Report     string
+	ReportOut  string
 }
 
 // ParseFlagsSubcmds Exports necessary flags and subcommands for main runner
@@ -40,12 +44,16 @@ func ParseFlagsSubcmds() (*AllFlags, error) {
 	}
 
 	return &AllFlags{
-		Env:      Env(),
-		FilePath: FilePath(),
-		File:     File(),
-		Debug:    Debug(),
-		Dir:      Dir(),
-		Dirseq:   Dirseq(),
+		Env:        Env(),
+		FilePath:   FilePath(),
+		File:       File(),
+		Debug:      Debug(),
+		Dir:        Dir(),
+		Dirseq:     Dirseq(),
+		Data:       Data(),
+		FailNon2xx: FailNon2xx(),
+		Report:     Report(),
+		ReportOut:  ReportOut(),
 	}, nil
 }
 
@@ -30,6 +30,13 @@ const (
 	KeyNotFound        = "key not found: "
 )
 
+// ProjectConfigFile is the optional project configuration in the project root
+const ProjectConfigFile = "hulak.yaml"
+
+// StateDir keeps what hulak saves between runs, like the tokens of auth files, in the project root.
+// It has live credentials, so it's kept out of git
+const StateDir = ".hulak"
+
 // acceptable file patterns
 const (
 	YAML = ".yaml"
@@ -51,6 +58,8 @@ const (
 	JSONInt    = "int"
 	JSONBool   = "bool"
 	JSONNull   = "null"
+	JSONArray  = "array"
+	JSONObject = "object"
 )
 
 // ResponseType is Auth2.0 ResponseType
@@ -13,9 +13,20 @@ import (
 // For arrays, reference an index with square brackets (e.g., myArr[0] for the first element).
 // You can also access nested properties like myArr[0].name for the "name" key of the first array element.
 func LookupValue(key string, data map[string]any) (any, error) {
+	value, err := LookupRawValue(key, data)
+	if err != nil {
+		return "", err
+	}
+
+	return MarshalToJSON(value)
+}
+
+// LookupRawValue retrieves the value for a given key or path from the map, just like LookupValue.
+// Unlike LookupValue, objects and arrays are returned as is, instead of JSON string
+func LookupRawValue(key string, data map[string]any) (any, error) {
 	// Step 1: Check for direct key match
 	if value, exists := data[key]; exists {
-		return MarshalToJSON(value)
+		return value, nil
 	}
 
 	pathSeparator := "."
@@ -68,7 +79,7 @@ func LookupValue(key string, data map[string]any) (any, error) {
 
 		// Step 8: Check for the last segment
 		if i == len(segments)-1 {
-			return MarshalToJSON(current)
+			return current, nil
 		}
 	}
 
@@ -6,9 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 )
 
+// matches the color codes used in the console output
+var colorCodes = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
 // ColorError Creates an error message that optionally includes an additional error.
 // If an error is provided, it formats the message with the error appended.
 // The returned error is colored for console output.
@@ -24,6 +28,11 @@ func ColorError(errMsg string, errs ...error) error {
 	return fmt.Errorf("\n%sError: %s%s", Red, fullMsg, ColorReset)
 }
 
+// StripColors removes the console colors from the message, for output that is not a terminal
+func StripColors(msg string) string {
+	return colorCodes.ReplaceAllString(msg, "")
+}
+
 // PrintGreen Prints Success Message
 func PrintGreen(msg string) {
 	fmt.Printf("%s%s%s\n", Green, msg, ColorReset)
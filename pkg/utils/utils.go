@@ -3,11 +3,15 @@
 package utils
 
 import (
+	"bytes"
 	"fmt"
 	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+
+	"github.com/goccy/go-yaml"
 )
 
 // CreatePath creates and returns file or directory path by joining the project root with provided filePath
@@ -131,9 +135,12 @@ func GetEnvFiles() ([]string, error) {
 	return environmentFiles, nil
 }
 
-// ConvertKeysToLowerCase converts all keys in a map to lowercase recursively
-// except "variables" as Graphql variables is case-sensitive
-func ConvertKeysToLowerCase(dict map[string]any) map[string]any {
+// ConvertKeysToLowerCase converts all keys in a map to lowercase recursively, including the maps in lists,
+// except "variables" as Graphql variables is case-sensitive,
+// keys inside "urlparams" as url params are case sensitive,
+// and keys inside "equals" as the expected value is compared with the response as it is.
+// Values of the keys in keep are kept as they are too, and ordered maps keep their order
+func ConvertKeysToLowerCase(dict map[string]any, keep ...string) map[string]any {
 	loweredMap := make(map[string]any)
 
 	for key, val := range dict {
@@ -145,18 +152,50 @@ func ConvertKeysToLowerCase(dict map[string]any) map[string]any {
 		}
 
 		lowerKey := strings.ToLower(key)
-		// If val is a map and the key isn't "variables", process it recursively.
-		switch almostFinalValue := val.(type) {
-		case map[string]any:
-			loweredMap[lowerKey] = ConvertKeysToLowerCase(almostFinalValue)
-		default:
-			loweredMap[lowerKey] = almostFinalValue
-		}
+		loweredMap[lowerKey] = lowerCaseValue(lowerKey, val, keep)
 	}
 
 	return loweredMap
 }
 
+// lowerCaseValue lowercases the keys in the value of the key, unless the value is kept as it is
+func lowerCaseValue(key string, val any, keep []string) any {
+	// url params are case sensitive, only the urlparams key itself is lowered
+	if key == "urlparams" || key == "equals" || slices.Contains(keep, key) {
+		return val
+	}
+
+	switch typed := val.(type) {
+	case map[string]any:
+		return ConvertKeysToLowerCase(typed, keep...)
+	case yaml.MapSlice:
+		lowered := make(yaml.MapSlice, 0, len(typed))
+
+		for _, item := range typed {
+			itemKey := fmt.Sprint(item.Key)
+			if itemKey == "variables" {
+				lowered = append(lowered, item)
+
+				continue
+			}
+
+			itemKey = strings.ToLower(itemKey)
+			lowered = append(lowered, yaml.MapItem{Key: itemKey, Value: lowerCaseValue(itemKey, item.Value, keep)})
+		}
+
+		return lowered
+	case []any:
+		list := make([]any, 0, len(typed))
+		for _, each := range typed {
+			list = append(list, lowerCaseValue("", each, keep))
+		}
+
+		return list
+	default:
+		return val
+	}
+}
+
 // CopyEnvMap Copies the Environment map[string]any and returns a map[string]string
 // EnvMap is a simple JSON without any nested properties. Mostly used for goroutines.
 func CopyEnvMap(original map[string]any) map[string]any {
@@ -284,3 +323,57 @@ func FileExists(path string) bool {
 	// Make sure it's a file and not a directory
 	return !info.IsDir()
 }
+
+// ResolveRelativeFile finds the file referenced in another file. Relative paths are looked up
+// from the directory of the referencing file first, and then from the project root.
+// Returns the absolute path and true if the file exists
+func ResolveRelativeFile(fromFile, ref string) (string, bool) {
+	candidates := []string{ref}
+
+	if !filepath.IsAbs(ref) {
+		candidates = []string{filepath.Join(filepath.Dir(fromFile), ref)}
+		if fromRoot, err := CreatePath(ref); err == nil {
+			candidates = append(candidates, fromRoot)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if FileExists(candidate) {
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs, true
+			}
+
+			return filepath.Clean(candidate), true
+		}
+	}
+
+	return "", false
+}
+
+// AddToGitignore adds the entry to the .gitignore of the project root, unless it's already there.
+// Creates the .gitignore when it's missing
+func AddToGitignore(entry string) error {
+	path, err := CreatePath(".gitignore")
+	if err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for line := range strings.SplitSeq(string(content), "\n") {
+		if strings.TrimSpace(line) == entry {
+			return nil
+		}
+	}
+
+	if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+		content = append(content, '\n')
+	}
+
+	content = append(content, entry+"\n"...)
+
+	return os.WriteFile(path, content, FilePer)
+}
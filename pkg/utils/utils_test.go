@@ -6,6 +6,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/goccy/go-yaml"
 )
 
 func TestCreateFilePath(t *testing.T) {
@@ -196,6 +198,15 @@ func TestToLowercaseMap(t *testing.T) {
 				"outerkey": map[string]any{},
 			},
 		},
+		{
+			name: "Url params keys are case sensitive",
+			input: map[string]any{
+				"URLParams": map[string]any{"userId": "1", "PageSize": 10},
+			},
+			expected: map[string]any{
+				"urlparams": map[string]any{"userId": "1", "PageSize": 10},
+			},
+		},
 	}
 
 	// Iterate over each test case
@@ -209,6 +220,44 @@ func TestToLowercaseMap(t *testing.T) {
 	}
 }
 
+func TestConvertKeysToLowerCaseListsAndKeep(t *testing.T) {
+	input := map[string]any{
+		"Steps": []any{
+			yaml.MapSlice{
+				{Key: "Name", Value: "login"},
+				{Key: "Request", Value: yaml.MapSlice{
+					{Key: "URLParams", Value: yaml.MapSlice{{Key: "userId", Value: 1}, {Key: "Page", Value: 2}}},
+					{Key: "Method", Value: "GET"},
+				}},
+				{Key: "ForEach", Value: []any{map[string]any{"UserId": 1}}},
+			},
+		},
+		"Assert": map[string]any{
+			"Body": []any{map[string]any{"Path": "user", "Equals": map[string]any{"UserName": "xaaha"}}},
+		},
+	}
+
+	expected := map[string]any{
+		"steps": []any{
+			yaml.MapSlice{
+				{Key: "name", Value: "login"},
+				{Key: "request", Value: yaml.MapSlice{
+					{Key: "urlparams", Value: yaml.MapSlice{{Key: "userId", Value: 1}, {Key: "Page", Value: 2}}},
+					{Key: "method", Value: "GET"},
+				}},
+				{Key: "foreach", Value: []any{map[string]any{"UserId": 1}}},
+			},
+		},
+		"assert": map[string]any{
+			"body": []any{map[string]any{"path": "user", "equals": map[string]any{"UserName": "xaaha"}}},
+		},
+	}
+
+	if result := ConvertKeysToLowerCase(input, "foreach"); !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v, got %v", expected, result)
+	}
+}
+
 func TestCreateDir(t *testing.T) {
 	t.Run("DirDoesNotExist_CreatesDirectory", func(t *testing.T) {
 		// Use t.TempDir() as a temporary base directory.
@@ -900,3 +949,37 @@ func TestSanitizeDirPathWithSpecialPaths(t *testing.T) {
 		})
 	}
 }
+
+func TestAddToGitignore(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := AddToGitignore(".hulak/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if content, _ := os.ReadFile(".gitignore"); string(content) != ".hulak/\n" {
+		t.Errorf("expected a new .gitignore with the entry, got %q", content)
+	}
+
+	if err := os.WriteFile(".gitignore", []byte("node_modules\n.hulak/\n*.log"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddToGitignore(".hulak/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := AddToGitignore("dist/"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(".gitignore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "node_modules\n.hulak/\n*.log\ndist/\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+}
@@ -0,0 +1,181 @@
+package yamlparser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// apiAuthType is the type of the auth section in an api file
+type apiAuthType string
+
+// Supported values of auth.type in an api file
+const (
+	// BasicAuth sends username and password in the Authorization header
+	BasicAuth apiAuthType = "basic"
+	// BearerAuth sends the token in the Authorization header
+	BearerAuth apiAuthType = "bearer"
+	// APIKeyAuth sends the key in a header, or in the url params
+	APIKeyAuth apiAuthType = "apikey"
+	// DigestAuth answers the Digest challenge of the server, and retries the request. RFC 7616
+	DigestAuth apiAuthType = "digest"
+	// AWSSigV4Auth signs the request with AWS Signature Version 4
+	AWSSigV4Auth apiAuthType = "aws_sigv4"
+)
+
+// Where the api key is sent, with auth.in
+const (
+	APIKeyInHeader = "header"
+	APIKeyInQuery  = "query"
+)
+
+// ApiAuth is the auth section of an api file. Credentials are encoded right before the request,
+// so they stay in the env files as they are.
+//
+//	auth:
+//	  type: basic
+//	  username: "{{.username}}"
+//	  password: "{{.password}}"
+type ApiAuth struct {
+	Type     apiAuthType `json:"type"               yaml:"type"`
+	Username string      `json:"username,omitempty" yaml:"username"`
+	Password string      `json:"password,omitempty" yaml:"password"`
+	Token    string      `json:"token,omitempty"    yaml:"token"`
+	// Key is the name of the header or the url param of the api key, and Value is the key itself
+	Key   string `json:"key,omitempty"   yaml:"key"`
+	Value string `json:"value,omitempty" yaml:"value"`
+	// In is where the api key is sent, header by default
+	In string `json:"in,omitempty" yaml:"in"`
+	// AWS Signature Version 4
+	Region       string `json:"region,omitempty"        yaml:"region"`
+	Service      string `json:"service,omitempty"       yaml:"service"`
+	AccessKey    string `json:"access_key,omitempty"    yaml:"access_key"`
+	SecretKey    string `json:"secret_key,omitempty"    yaml:"secret_key"`
+	SessionToken string `json:"session_token,omitempty" yaml:"session_token"`
+}
+
+// DigestCredentials answer the Digest challenge of the server
+type DigestCredentials struct {
+	Username string
+	Password string
+}
+
+// SigV4Credentials sign the request with AWS Signature Version 4.
+// SessionToken is only needed with temporary credentials
+type SigV4Credentials struct {
+	Region       string
+	Service      string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+// authType is the type in lowercase, since Basic and basic are the same
+func (a *ApiAuth) authType() apiAuthType {
+	return apiAuthType(strings.ToLower(string(a.Type)))
+}
+
+// IsValid checks the auth section. Missing auth section is valid
+func (a *ApiAuth) IsValid() (bool, error) {
+	if a == nil {
+		return true, nil
+	}
+
+	switch a.authType() {
+	case BasicAuth, DigestAuth:
+		if a.Username == "" {
+			return false, utils.ColorError(fmt.Sprintf("%s auth requires username", a.authType()))
+		}
+	case BearerAuth:
+		if a.Token == "" {
+			return false, utils.ColorError("bearer auth requires token")
+		}
+	case APIKeyAuth:
+		if a.Key == "" || a.Value == "" {
+			return false, utils.ColorError("apikey auth requires key and value")
+		}
+
+		switch strings.ToLower(a.In) {
+		case "", APIKeyInHeader, APIKeyInQuery:
+		default:
+			return false, utils.ColorError("invalid apikey 'in: " + a.In + "'. Use header or query")
+		}
+	case AWSSigV4Auth:
+		if a.Region == "" || a.Service == "" || a.AccessKey == "" || a.SecretKey == "" {
+			return false, utils.ColorError("aws_sigv4 auth requires region, service, access_key and secret_key")
+		}
+	default:
+		return false, utils.ColorError(
+			"unsupported auth type '" + string(a.Type) + "'. Use basic, bearer, apikey, digest or aws_sigv4",
+		)
+	}
+
+	return true, nil
+}
+
+// apply adds the credentials to the request. Authorization header of the auth section
+// replaces the one in headers. Digest is only sent after the server's challenge,
+// and aws_sigv4 is signed right before the request is sent
+func (a *ApiAuth) apply(apiInfo *ApiInfo) {
+	if a == nil {
+		return
+	}
+
+	if apiInfo.Headers == nil {
+		apiInfo.Headers = make(map[string]string)
+	}
+
+	switch a.authType() {
+	case BasicAuth:
+		setHeader(apiInfo.Headers, "authorization", BasicAuthHeader(a.Username, a.Password))
+	case BearerAuth:
+		setHeader(apiInfo.Headers, "authorization", "Bearer "+a.Token)
+	case APIKeyAuth:
+		if strings.ToLower(a.In) == APIKeyInQuery {
+			apiInfo.UrlParams = setURLParam(apiInfo.UrlParams, a.Key, a.Value)
+		} else {
+			setHeader(apiInfo.Headers, a.Key, a.Value)
+		}
+	case DigestAuth:
+		apiInfo.Digest = &DigestCredentials{Username: a.Username, Password: a.Password}
+	case AWSSigV4Auth:
+		apiInfo.SigV4 = &SigV4Credentials{
+			Region:       a.Region,
+			Service:      a.Service,
+			AccessKey:    a.AccessKey,
+			SecretKey:    a.SecretKey,
+			SessionToken: a.SessionToken,
+		}
+	}
+}
+
+// BasicAuthHeader is the value of the Authorization header for basic auth. RFC 7617
+func BasicAuthHeader(username, password string) string {
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+}
+
+// setHeader sets the header, replacing the header with the same name in any case
+func setHeader(headers map[string]string, name, value string) {
+	for key := range headers {
+		if strings.EqualFold(key, name) {
+			delete(headers, key)
+		}
+	}
+
+	headers[name] = value
+}
+
+// setURLParam sets the url param, replacing the param with the same key
+func setURLParam(params URLParams, key, value string) URLParams {
+	result := make(URLParams, 0, len(params)+1)
+
+	for _, param := range params {
+		if param.Key != key {
+			result = append(result, param)
+		}
+	}
+
+	return append(result, URLParam{Key: key, Values: []string{value}})
+}
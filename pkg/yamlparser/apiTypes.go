@@ -48,11 +48,19 @@ func (httpMethod *HTTPMethodType) IsValid() bool {
 
 // Struct we need to call request api
 type ApiInfo struct {
-	Body      io.Reader
-	Headers   map[string]string
-	UrlParams map[string]string
-	Method    string
-	Url       string
+	Body            io.Reader
+	Headers         map[string]string
+	UrlParams       URLParams
+	UrlParamsFormat ArrayFormat
+	Client          *ClientConfig
+	// Digest answers the Digest challenge of the server, when the file has digest auth
+	Digest *DigestCredentials
+	// SigV4 signs the request with AWS Signature Version 4, when the file has aws_sigv4 auth
+	SigV4 *SigV4Credentials
+	// Sign has the headers templated after the body is encoded, when the file has a sign section
+	Sign   *Signing
+	Method string
+	Url    string
 }
 
 type URL string
@@ -67,11 +75,18 @@ func (u *URL) IsValidURL() bool {
 
 // ApiCallFile represents user's yaml file for api request
 type ApiCallFile struct {
-	URLParams map[string]string `json:"urlparams,omitempty" yaml:"urlparams"`
-	Headers   map[string]string `json:"headers,omitempty"   yaml:"headers"`
-	Body      *Body             `json:"body,omitempty"      yaml:"body"`
-	Method    HTTPMethodType    `json:"method,omitempty"    yaml:"method"`
-	URL       URL               `json:"url,omitempty"       yaml:"url"`
+	URLParams       URLParams         `json:"urlparams,omitempty"        yaml:"urlparams"`
+	URLParamsFormat ArrayFormat       `json:"urlparams_format,omitempty" yaml:"urlparams_format"`
+	Headers         map[string]string `json:"headers,omitempty"          yaml:"headers"`
+	Body            *Body             `json:"body,omitempty"             yaml:"body"`
+	Client          *ClientConfig     `json:"client,omitempty"           yaml:"client"`
+	Auth            *ApiAuth          `json:"auth,omitempty"             yaml:"auth"`
+	Sign            SignHeaders       `json:"sign,omitempty"             yaml:"sign"`
+	Assert          *Assert           `json:"assert,omitempty"           yaml:"assert"`
+	Capture         Captures          `json:"capture,omitempty"          yaml:"capture"`
+	SaveToEnv       SaveToEnv         `json:"save_to_env,omitempty"      yaml:"save_to_env"`
+	Method          HTTPMethodType    `json:"method,omitempty"           yaml:"method"`
+	URL             URL               `json:"url,omitempty"              yaml:"url"`
 }
 
 // IsValid checks whether the user has valid file
@@ -96,6 +111,37 @@ func (user *ApiCallFile) IsValid(filePath string) (bool, error) {
 		return false, fmt.Errorf("missing or invalid URL: %s in file %s", user.URL, filePath)
 	}
 
+	if !user.URLParamsFormat.IsValid() {
+		return false, fmt.Errorf(
+			"invalid urlparams_format '%s' in '%s'. Use one of: %s, %s, %s",
+			user.URLParamsFormat, filePath, ArrayRepeat, ArrayComma, ArrayBrackets,
+		)
+	}
+
+	if valid, err := user.Client.IsValid(); !valid {
+		return false, fmt.Errorf("invalid 'client' in '%s': %w", filePath, err)
+	}
+
+	if valid, err := user.Auth.IsValid(); !valid {
+		return false, fmt.Errorf("invalid 'auth' in '%s': %w", filePath, err)
+	}
+
+	if valid, err := user.Sign.IsValid(); !valid {
+		return false, fmt.Errorf("invalid 'sign' in '%s': %w", filePath, err)
+	}
+
+	if valid, err := user.Assert.IsValid(); !valid {
+		return false, fmt.Errorf("invalid 'assert' in '%s': %w", filePath, err)
+	}
+
+	if valid, err := user.Capture.IsValid(); !valid {
+		return false, fmt.Errorf("invalid 'capture' in '%s': %w", filePath, err)
+	}
+
+	if valid, err := user.SaveToEnv.IsValid(user.Capture); !valid {
+		return false, fmt.Errorf("invalid 'save_to_env' in '%s': %w", filePath, err)
+	}
+
 	if !user.Body.IsValid() {
 		utils.PanicRedAndExit(
 			"Invalid Body in '%s'. Make sure body contains only one valid argument.\n %v",
@@ -122,13 +168,23 @@ func (user *ApiCallFile) PrepareStruct() (ApiInfo, error) {
 		user.Headers["content-type"] = contentType
 	}
 
-	return ApiInfo{
-		Method:    string(user.Method),
-		Url:       string(user.URL),
-		UrlParams: user.URLParams,
-		Headers:   user.Headers,
-		Body:      body,
-	}, nil
+	apiInfo := ApiInfo{
+		Method:          string(user.Method),
+		Url:             string(user.URL),
+		UrlParams:       user.URLParams,
+		UrlParamsFormat: user.URLParamsFormat,
+		Client:          user.Client,
+		Headers:         user.Headers,
+		Body:            body,
+	}
+
+	if len(user.Sign) > 0 {
+		apiInfo.Sign = &Signing{Headers: user.Sign}
+	}
+
+	user.Auth.apply(&apiInfo)
+
+	return apiInfo, nil
 }
 
 // Body represents Body in a yaml file
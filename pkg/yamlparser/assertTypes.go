@@ -0,0 +1,267 @@
+package yamlparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// StatusAssertion is the list of acceptable status codes.
+// Each item is either a status code like 200, or a class like 2xx
+type StatusAssertion []string
+
+// UnmarshalYAML decodes a single status or a list of statuses
+func (s *StatusAssertion) UnmarshalYAML(unmarshal func(any) error) error {
+	var raw any
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	switch val := raw.(type) {
+	case nil:
+		*s = nil
+	case []any:
+		statuses := make(StatusAssertion, 0, len(val))
+		for _, each := range val {
+			statuses = append(statuses, strings.ToLower(fmt.Sprint(each)))
+		}
+
+		*s = statuses
+	default:
+		*s = StatusAssertion{strings.ToLower(fmt.Sprint(val))}
+	}
+
+	return nil
+}
+
+// IsValid checks each status is a 3 digit status code or a class like 2xx
+func (s StatusAssertion) IsValid() bool {
+	statusPattern := regexp.MustCompile(`^[1-5]([0-9]{2}|xx)$`)
+	for _, status := range s {
+		if !statusPattern.MatchString(status) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Matches checks whether the status code satisfies any of the expected statuses
+func (s StatusAssertion) Matches(statusCode int) bool {
+	code := strconv.Itoa(statusCode)
+	for _, status := range s {
+		if status == code || (strings.HasSuffix(status, "xx") && status[0] == code[0]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matcher has the checks performed on a value from the response.
+// All the provided checks must pass
+type Matcher struct {
+	Equals   any    `json:"equals,omitempty"   yaml:"equals"`
+	Matches  string `json:"matches,omitempty"  yaml:"matches"`
+	Contains string `json:"contains,omitempty" yaml:"contains"`
+	Type     string `json:"type,omitempty"     yaml:"type"`
+	Exists   *bool  `json:"exists,omitempty"   yaml:"exists"`
+	// equalsNull is true for 'equals: null', since Equals is nil for it as well as when it's missing
+	equalsNull bool
+}
+
+// HasEquals returns true when the matcher has 'equals', including 'equals: null'
+func (m *Matcher) HasEquals() bool {
+	return m.Equals != nil || m.equalsNull
+}
+
+// setEqualsNull records whether the yaml of the matcher has 'equals: null'.
+// Matcher is inlined in the header and body assertions, so they call it from their UnmarshalYAML
+func (m *Matcher) setEqualsNull(unmarshal func(any) error) error {
+	var raw map[string]any
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	val, ok := raw["equals"]
+	m.equalsNull = ok && val == nil
+
+	return nil
+}
+
+// valid types for Matcher.Type
+var matcherTypes = []string{
+	utils.JSONString,
+	utils.JSONNumber,
+	utils.JSONInt,
+	utils.JSONBool,
+	utils.JSONNull,
+	utils.JSONArray,
+	utils.JSONObject,
+}
+
+// IsValid checks the matcher has at least one check, valid regex and valid type
+func (m *Matcher) IsValid() (bool, error) {
+	if !m.HasEquals() && m.Matches == "" && m.Contains == "" && m.Type == "" && m.Exists == nil {
+		return false, fmt.Errorf("provide at least one of equals, matches, contains, type or exists")
+	}
+
+	if m.Matches != "" {
+		if _, err := regexp.Compile(m.Matches); err != nil {
+			return false, fmt.Errorf("invalid regex '%s': %w", m.Matches, err)
+		}
+	}
+
+	if m.Type != "" && !isValidMatcherType(m.Type) {
+		return false, fmt.Errorf(
+			"invalid type '%s'. Use one of %s",
+			m.Type,
+			strings.Join(matcherTypes, ", "),
+		)
+	}
+
+	return true, nil
+}
+
+func isValidMatcherType(typ string) bool {
+	for _, each := range matcherTypes {
+		if strings.EqualFold(each, typ) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HeaderAssertion checks a response header
+type HeaderAssertion struct {
+	Name    string `json:"name" yaml:"name"`
+	Matcher `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes the header assertion and keeps track of 'equals: null'
+func (h *HeaderAssertion) UnmarshalYAML(unmarshal func(any) error) error {
+	type plain HeaderAssertion
+	if err := unmarshal((*plain)(h)); err != nil {
+		return err
+	}
+
+	return h.setEqualsNull(unmarshal)
+}
+
+// BodyAssertion checks the value at the path of the JSON response body.
+// Path has the same syntax as getValueOf, like data.users[0].name
+type BodyAssertion struct {
+	Path    string `json:"path" yaml:"path"`
+	Matcher `yaml:",inline"`
+}
+
+// UnmarshalYAML decodes the body assertion and keeps track of 'equals: null'
+func (b *BodyAssertion) UnmarshalYAML(unmarshal func(any) error) error {
+	type plain BodyAssertion
+	if err := unmarshal((*plain)(b)); err != nil {
+		return err
+	}
+
+	return b.setEqualsNull(unmarshal)
+}
+
+// Assert represents the 'assert' section in the api file
+//
+//	assert:
+//	  status: 200 # or [200, 201] or 2xx
+//	  max_duration: 500ms
+//	  headers:
+//	    - name: Content-Type
+//	      contains: application/json
+//	  body:
+//	    - path: data.users[0].name
+//	      equals: xaaha
+//	    - path: data.users
+//	      type: array
+//	  body_contains:
+//	    - xaaha
+type Assert struct {
+	Status       StatusAssertion   `json:"status,omitempty"        yaml:"status"`
+	MaxDuration  *Duration         `json:"max_duration,omitempty"  yaml:"max_duration"`
+	Headers      []HeaderAssertion `json:"headers,omitempty"       yaml:"headers"`
+	Body         []BodyAssertion   `json:"body,omitempty"          yaml:"body"`
+	BodyContains []string          `json:"body_contains,omitempty" yaml:"body_contains"`
+}
+
+// IsValid checks the validity of the assert section. Missing assert section is valid
+func (a *Assert) IsValid() (bool, error) {
+	if a == nil {
+		return true, nil
+	}
+
+	if !a.Status.IsValid() {
+		return false, fmt.Errorf("invalid status '%v'. Use status code like 200 or class like 2xx", a.Status)
+	}
+
+	if a.MaxDuration != nil && *a.MaxDuration <= 0 {
+		return false, fmt.Errorf("max_duration must be greater than 0")
+	}
+
+	for i, header := range a.Headers {
+		if header.Name == "" {
+			return false, fmt.Errorf("missing name in headers[%d]", i)
+		}
+
+		if valid, err := header.IsValid(); !valid {
+			return false, fmt.Errorf("invalid header assertion '%s': %w", header.Name, err)
+		}
+	}
+
+	for i, body := range a.Body {
+		if body.Path == "" {
+			return false, fmt.Errorf("missing path in body[%d]", i)
+		}
+
+		if valid, err := body.IsValid(); !valid {
+			return false, fmt.Errorf("invalid body assertion '%s': %w", body.Path, err)
+		}
+	}
+
+	return true, nil
+}
+
+// HasAssertions returns true if there is at least one assertion to run
+func (a *Assert) HasAssertions() bool {
+	return a != nil && (len(a.Status) > 0 || a.MaxDuration != nil || len(a.Headers) > 0 ||
+		len(a.Body) > 0 || len(a.BodyContains) > 0)
+}
+
+// Merge returns a new Assert with the assertions of both. Status and max_duration in the other replace the ones in a.
+// Either of them could be nil
+func (a *Assert) Merge(other *Assert) *Assert {
+	if a == nil && other == nil {
+		return nil
+	}
+
+	merged := Assert{}
+	if a != nil {
+		merged = *a
+	}
+
+	if other == nil {
+		return &merged
+	}
+
+	if len(other.Status) > 0 {
+		merged.Status = other.Status
+	}
+
+	if other.MaxDuration != nil {
+		merged.MaxDuration = other.MaxDuration
+	}
+
+	merged.Headers = append(append([]HeaderAssertion{}, merged.Headers...), other.Headers...)
+	merged.Body = append(append([]BodyAssertion{}, merged.Body...), other.Body...)
+	merged.BodyContains = append(append([]string{}, merged.BodyContains...), other.BodyContains...)
+
+	return &merged
+}
@@ -0,0 +1,165 @@
+package yamlparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestAssertUnmarshal(t *testing.T) {
+	content := `
+assert:
+  status: [200, 2XX]
+  max_duration: 500ms
+  headers:
+    - name: Content-Type
+      contains: application/json
+  body:
+    - path: data.users[0].name
+      equals: xaaha
+    - path: data.users
+      type: array
+    - path: error
+      exists: false
+    - path: deleted_at
+      equals: null
+  body_contains:
+    - xaaha
+`
+	var file struct {
+		Assert *Assert `yaml:"assert"`
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	assert := file.Assert
+	if len(assert.Status) != 2 || assert.Status[0] != "200" || assert.Status[1] != "2xx" {
+		t.Errorf("unexpected status %v", assert.Status)
+	}
+
+	if time.Duration(*assert.MaxDuration) != 500*time.Millisecond {
+		t.Errorf("expected max_duration of 500ms, got %v", time.Duration(*assert.MaxDuration))
+	}
+
+	if len(assert.Headers) != 1 || assert.Headers[0].Name != "Content-Type" ||
+		assert.Headers[0].Contains != "application/json" {
+		t.Errorf("unexpected headers %+v", assert.Headers)
+	}
+
+	if len(assert.Body) != 4 || assert.Body[0].Equals != "xaaha" || assert.Body[1].Type != "array" {
+		t.Errorf("unexpected body %+v", assert.Body)
+	}
+
+	if !assert.Body[3].HasEquals() || assert.Body[3].Equals != nil {
+		t.Errorf("expected equals null for %s, got %+v", assert.Body[3].Path, assert.Body[3].Matcher)
+	}
+
+	if assert.Body[1].HasEquals() {
+		t.Errorf("expected no equals for %s", assert.Body[1].Path)
+	}
+
+	if assert.Body[2].Exists == nil || *assert.Body[2].Exists {
+		t.Errorf("expected exists to be false for %s", assert.Body[2].Path)
+	}
+
+	if valid, err := assert.IsValid(); !valid {
+		t.Errorf("expected assert to be valid, got %v", err)
+	}
+
+	if !assert.HasAssertions() {
+		t.Errorf("expected assertions")
+	}
+}
+
+func TestAssertSingleStatus(t *testing.T) {
+	var file struct {
+		Assert Assert `yaml:"assert"`
+	}
+
+	if err := yaml.Unmarshal([]byte("assert:\n  status: 201"), &file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(file.Assert.Status) != 1 || file.Assert.Status[0] != "201" {
+		t.Errorf("expected status [201], got %v", file.Assert.Status)
+	}
+}
+
+func TestStatusAssertionMatches(t *testing.T) {
+	tests := []struct {
+		status   StatusAssertion
+		code     int
+		expected bool
+	}{
+		{status: StatusAssertion{"200"}, code: 200, expected: true},
+		{status: StatusAssertion{"200"}, code: 201, expected: false},
+		{status: StatusAssertion{"2xx"}, code: 204, expected: true},
+		{status: StatusAssertion{"4xx", "200"}, code: 404, expected: true},
+		{status: StatusAssertion{"4xx", "200"}, code: 500, expected: false},
+	}
+
+	for _, tc := range tests {
+		if got := tc.status.Matches(tc.code); got != tc.expected {
+			t.Errorf("%v matches %d: expected %v, got %v", tc.status, tc.code, tc.expected, got)
+		}
+	}
+}
+
+func TestAssertIsValid(t *testing.T) {
+	exists := true
+	zero := Duration(0)
+
+	tests := []struct {
+		name    string
+		assert  *Assert
+		isValid bool
+	}{
+		{name: "nil assert", assert: nil, isValid: true},
+		{name: "valid status", assert: &Assert{Status: StatusAssertion{"200", "3xx"}}, isValid: true},
+		{name: "invalid status", assert: &Assert{Status: StatusAssertion{"20"}}, isValid: false},
+		{name: "invalid status class", assert: &Assert{Status: StatusAssertion{"6xx"}}, isValid: false},
+		{name: "zero max_duration", assert: &Assert{MaxDuration: &zero}, isValid: false},
+		{
+			name:    "header without name",
+			assert:  &Assert{Headers: []HeaderAssertion{{Matcher: Matcher{Exists: &exists}}}},
+			isValid: false,
+		},
+		{
+			name:    "header without check",
+			assert:  &Assert{Headers: []HeaderAssertion{{Name: "X-Id"}}},
+			isValid: false,
+		},
+		{
+			name:    "body with invalid regex",
+			assert:  &Assert{Body: []BodyAssertion{{Path: "id", Matcher: Matcher{Matches: "[a-"}}}},
+			isValid: false,
+		},
+		{
+			name:    "body with invalid type",
+			assert:  &Assert{Body: []BodyAssertion{{Path: "id", Matcher: Matcher{Type: "date"}}}},
+			isValid: false,
+		},
+		{
+			name:    "body without path",
+			assert:  &Assert{Body: []BodyAssertion{{Matcher: Matcher{Type: "string"}}}},
+			isValid: false,
+		},
+		{
+			name:    "valid body",
+			assert:  &Assert{Body: []BodyAssertion{{Path: "id", Matcher: Matcher{Type: "Number"}}}},
+			isValid: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := tc.assert.IsValid()
+			if valid != tc.isValid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tc.isValid, valid, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package yamlparser
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// capture names are used in templates as {{.name}}
+var captureNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Capture extracts a single value from the response into a run scoped variable.
+// Exactly one of path, header, cookie or status is required
+//
+//	capture:
+//	  - name: user_id
+//	    path: data.users[0].id
+//	  - name: request_id
+//	    header: X-Request-Id
+//	  - name: session
+//	    cookie: session_id
+//	  - name: login_status
+//	    status: true
+type Capture struct {
+	Name   string `json:"name"             yaml:"name"`
+	Path   string `json:"path,omitempty"   yaml:"path"`
+	Header string `json:"header,omitempty" yaml:"header"`
+	Cookie string `json:"cookie,omitempty" yaml:"cookie"`
+	Status bool   `json:"status,omitempty" yaml:"status"`
+}
+
+// IsValid checks the capture has a usable name and exactly one source
+func (c *Capture) IsValid() (bool, error) {
+	if !captureNamePattern.MatchString(c.Name) {
+		return false, fmt.Errorf(
+			"invalid capture name '%s'. Use letters, digits and underscore, not starting with a digit",
+			c.Name,
+		)
+	}
+
+	sources := 0
+
+	for _, source := range []string{c.Path, c.Header, c.Cookie} {
+		if source != "" {
+			sources++
+		}
+	}
+
+	if c.Status {
+		sources++
+	}
+
+	if sources != 1 {
+		return false, fmt.Errorf(
+			"capture '%s' needs exactly one of path, header, cookie or status",
+			c.Name,
+		)
+	}
+
+	return true, nil
+}
+
+// Captures is the 'capture' section of the api file
+type Captures []Capture
+
+// IsValid checks each capture and makes sure the names are unique
+func (c Captures) IsValid() (bool, error) {
+	names := make(map[string]bool)
+
+	for i := range c {
+		if valid, err := c[i].IsValid(); !valid {
+			return false, err
+		}
+
+		if names[c[i].Name] {
+			return false, fmt.Errorf("capture '%s' is declared more than once", c[i].Name)
+		}
+
+		names[c[i].Name] = true
+	}
+
+	return true, nil
+}
+
+// SaveToEnv is the 'save_to_env' section of the file. It has the names of the captured values
+// that are written to the env file of the current environment
+//
+//	save_to_env:
+//	  - accessToken
+type SaveToEnv []string
+
+// IsValid checks each name in save_to_env is captured in the same file
+func (s SaveToEnv) IsValid(captures Captures) (bool, error) {
+	for _, name := range s {
+		found := false
+
+		for _, capture := range captures {
+			if capture.Name == name {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false, fmt.Errorf("'%s' in save_to_env is not captured in the file", name)
+		}
+	}
+
+	return true, nil
+}
+
+// Values returns the captured values listed in save_to_env
+func (s SaveToEnv) Values(captured map[string]any) map[string]any {
+	values := make(map[string]any)
+
+	for _, name := range s {
+		if val, ok := captured[name]; ok {
+			values[name] = val
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,100 @@
+package yamlparser
+
+import (
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestCaptureUnmarshal(t *testing.T) {
+	content := `
+capture:
+  - name: userId
+    path: data.users[0].id
+  - name: request_id
+    header: X-Request-Id
+  - name: session
+    cookie: session_id
+  - name: login_status
+    status: true
+`
+	var file struct {
+		Capture Captures `yaml:"capture"`
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := Captures{
+		{Name: "userId", Path: "data.users[0].id"},
+		{Name: "request_id", Header: "X-Request-Id"},
+		{Name: "session", Cookie: "session_id"},
+		{Name: "login_status", Status: true},
+	}
+
+	if len(file.Capture) != len(expected) {
+		t.Fatalf("expected %d captures, got %d", len(expected), len(file.Capture))
+	}
+
+	for i := range expected {
+		if file.Capture[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], file.Capture[i])
+		}
+	}
+
+	if valid, err := file.Capture.IsValid(); !valid {
+		t.Errorf("expected captures to be valid, got %v", err)
+	}
+}
+
+func TestCapturesIsValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		captures Captures
+		isValid  bool
+	}{
+		{name: "empty", captures: nil, isValid: true},
+		{name: "no source", captures: Captures{{Name: "id"}}, isValid: false},
+		{name: "two sources", captures: Captures{{Name: "id", Path: "id", Header: "X-Id"}}, isValid: false},
+		{name: "missing name", captures: Captures{{Path: "id"}}, isValid: false},
+		{name: "name with dash", captures: Captures{{Name: "user-id", Path: "id"}}, isValid: false},
+		{name: "name starting with digit", captures: Captures{{Name: "1id", Path: "id"}}, isValid: false},
+		{
+			name:     "duplicate names",
+			captures: Captures{{Name: "id", Path: "id"}, {Name: "id", Header: "X-Id"}},
+			isValid:  false,
+		},
+		{
+			name:     "valid",
+			captures: Captures{{Name: "_id", Path: "id"}, {Name: "code", Status: true}},
+			isValid:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := tc.captures.IsValid()
+			if valid != tc.isValid {
+				t.Errorf("expected valid to be %v, got %v (%v)", tc.isValid, valid, err)
+			}
+		})
+	}
+}
+
+func TestSaveToEnv(t *testing.T) {
+	captures := Captures{{Name: "token", Path: "access_token"}, {Name: "code", Status: true}}
+
+	if valid, err := (SaveToEnv{"token"}).IsValid(captures); !valid {
+		t.Errorf("expected save_to_env to be valid, got %v", err)
+	}
+
+	if valid, _ := (SaveToEnv{"refreshToken"}).IsValid(captures); valid {
+		t.Errorf("expected save_to_env with name that is not captured to be invalid")
+	}
+
+	values := SaveToEnv{"token", "missing"}.Values(map[string]any{"token": "abc", "code": 200})
+	if len(values) != 1 || values["token"] != "abc" {
+		t.Errorf("unexpected values %v", values)
+	}
+}
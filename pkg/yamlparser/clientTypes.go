@@ -0,0 +1,228 @@
+package yamlparser
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// Supported http versions for the client
+const (
+	HTTP1 = "1.1"
+	HTTP2 = "2"
+)
+
+// Duration is time.Duration in yaml file. It accepts go duration string like "30s", "1m30s",
+// or a number, which is treated as seconds
+type Duration time.Duration
+
+// UnmarshalYAML decodes "30s" or 30 as Duration
+func (d *Duration) UnmarshalYAML(unmarshal func(any) error) error {
+	var raw any
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	switch val := raw.(type) {
+	case nil:
+		*d = 0
+	case uint64, int64, int, float64:
+		seconds, err := strconv.ParseFloat(fmt.Sprint(val), 64)
+		if err != nil {
+			return fmt.Errorf("invalid duration '%v': %w", val, err)
+		}
+
+		*d = Duration(seconds * float64(time.Second))
+	case string:
+		if seconds, err := strconv.ParseFloat(val, 64); err == nil {
+			*d = Duration(seconds * float64(time.Second))
+
+			return nil
+		}
+
+		parsed, err := time.ParseDuration(val)
+		if err != nil {
+			return fmt.Errorf("invalid duration '%s'. Use values like 30s, 1m or 500ms", val)
+		}
+
+		*d = Duration(parsed)
+	default:
+		return fmt.Errorf("invalid duration '%v'", val)
+	}
+
+	return nil
+}
+
+// HTTPVersion is the http version the client uses, "1.1" or "2".
+// Since yaml decodes 1.1 and 2 as numbers, any scalar is accepted
+type HTTPVersion string
+
+// UnmarshalYAML decodes any scalar as HTTPVersion
+func (v *HTTPVersion) UnmarshalYAML(unmarshal func(any) error) error {
+	var raw any
+	if err := unmarshal(&raw); err != nil {
+		return err
+	}
+
+	if raw == nil {
+		*v = ""
+
+		return nil
+	}
+
+	*v = HTTPVersion(strings.TrimSpace(fmt.Sprint(raw)))
+
+	return nil
+}
+
+// ClientConfig represents the 'client' section in the yaml file.
+// It configures the http client used for the request.
+//
+//	client:
+//	  timeout: 10s
+//	  follow_redirects: true
+//	  max_redirects: 3
+//	  proxy: http://localhost:8080
+//	  ca_cert: certs/ca.pem
+//	  cert: "{{.clientCertPath}}"
+//	  key: '{{getFile "certs/client.key"}}'
+//	  insecure_skip_verify: false
+//	  http_version: "1.1"
+//
+// ca_cert, cert and key could either be a file path or the PEM content itself
+type ClientConfig struct {
+	Timeout            *Duration   `json:"timeout,omitempty"              yaml:"timeout"`
+	FollowRedirects    *bool       `json:"follow_redirects,omitempty"     yaml:"follow_redirects"`
+	MaxRedirects       *int        `json:"max_redirects,omitempty"        yaml:"max_redirects"`
+	Proxy              string      `json:"proxy,omitempty"                yaml:"proxy"`
+	CACert             string      `json:"ca_cert,omitempty"              yaml:"ca_cert"`
+	Cert               string      `json:"cert,omitempty"                 yaml:"cert"`
+	Key                string      `json:"key,omitempty"                  yaml:"key"`
+	InsecureSkipVerify *bool       `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify"`
+	HTTPVersion        HTTPVersion `json:"http_version,omitempty"         yaml:"http_version"`
+}
+
+// IsPEM checks whether the value is PEM content rather than a file path
+func IsPEM(value string) bool {
+	return strings.Contains(value, "-----BEGIN ")
+}
+
+// pemOrFileExists checks whether the value is PEM content or an existing file
+func pemOrFileExists(value string) bool {
+	return IsPEM(value) || utils.FileExists(value)
+}
+
+// IsValid checks the client section. Missing client section is valid
+func (c *ClientConfig) IsValid() (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+
+	if c.Timeout != nil && *c.Timeout < 0 {
+		return false, utils.ColorError("client timeout can't be negative")
+	}
+
+	if c.MaxRedirects != nil && *c.MaxRedirects < 0 {
+		return false, utils.ColorError("client max_redirects can't be negative")
+	}
+
+	if c.Proxy != "" {
+		proxyURL, err := url.Parse(c.Proxy)
+		if err != nil || proxyURL.Scheme == "" || proxyURL.Host == "" {
+			return false, utils.ColorError("invalid client proxy url: " + c.Proxy)
+		}
+	}
+
+	if c.CACert != "" && !pemOrFileExists(c.CACert) {
+		return false, utils.ColorError("client ca_cert file does not exist: " + c.CACert)
+	}
+
+	if c.Key != "" && c.Cert == "" {
+		return false, utils.ColorError("client key is provided without the cert")
+	}
+
+	if c.Cert != "" && !pemOrFileExists(c.Cert) {
+		return false, utils.ColorError("client cert file does not exist: " + c.Cert)
+	}
+
+	if c.Key != "" && !pemOrFileExists(c.Key) {
+		return false, utils.ColorError("client key file does not exist: " + c.Key)
+	}
+
+	switch c.HTTPVersion {
+	case "", HTTP1, HTTP2, "2.0", "1":
+	default:
+		return false, utils.ColorError(
+			fmt.Sprintf("invalid client http_version '%s'. Use '%s' or '%s'", c.HTTPVersion, HTTP1, HTTP2),
+		)
+	}
+
+	return true, nil
+}
+
+// Merge returns a new ClientConfig where the fields set in the override replace the fields in c.
+// Either of them could be nil
+func (c *ClientConfig) Merge(override *ClientConfig) *ClientConfig {
+	if c == nil && override == nil {
+		return nil
+	}
+
+	merged := ClientConfig{}
+	if c != nil {
+		merged = *c
+	}
+
+	if override == nil {
+		return &merged
+	}
+
+	if override.Timeout != nil {
+		merged.Timeout = override.Timeout
+	}
+
+	if override.FollowRedirects != nil {
+		merged.FollowRedirects = override.FollowRedirects
+	}
+
+	if override.MaxRedirects != nil {
+		merged.MaxRedirects = override.MaxRedirects
+	}
+
+	if override.Proxy != "" {
+		merged.Proxy = override.Proxy
+	}
+
+	if override.CACert != "" {
+		merged.CACert = override.CACert
+	}
+
+	// cert and key are a pair, so they are overridden together
+	if override.Cert != "" {
+		merged.Cert = override.Cert
+		merged.Key = override.Key
+	}
+
+	if override.InsecureSkipVerify != nil {
+		merged.InsecureSkipVerify = override.InsecureSkipVerify
+	}
+
+	if override.HTTPVersion != "" {
+		merged.HTTPVersion = override.HTTPVersion
+	}
+
+	return &merged
+}
+
+// ForceHTTP1 is true when the client should only use HTTP/1.1
+func (c *ClientConfig) ForceHTTP1() bool {
+	return c != nil && (c.HTTPVersion == HTTP1 || c.HTTPVersion == "1")
+}
+
+// ForceHTTP2 is true when the client should only use HTTP/2
+func (c *ClientConfig) ForceHTTP2() bool {
+	return c != nil && (c.HTTPVersion == HTTP2 || c.HTTPVersion == "2.0")
+}
@@ -0,0 +1,171 @@
+package yamlparser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestClientConfigUnmarshal(t *testing.T) {
+	content := `
+client:
+  timeout: 1m30s
+  follow_redirects: false
+  max_redirects: 2
+  http_version: 1.1
+`
+	var file struct {
+		Client *ClientConfig `yaml:"client"`
+	}
+
+	if err := yaml.Unmarshal([]byte(content), &file); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if time.Duration(*file.Client.Timeout) != 90*time.Second {
+		t.Errorf("expected timeout of 90s, got %v", time.Duration(*file.Client.Timeout))
+	}
+
+	if *file.Client.FollowRedirects {
+		t.Errorf("expected follow_redirects to be false")
+	}
+
+	if *file.Client.MaxRedirects != 2 {
+		t.Errorf("expected max_redirects to be 2, got %d", *file.Client.MaxRedirects)
+	}
+
+	if !file.Client.ForceHTTP1() {
+		t.Errorf("expected http_version 1.1, got %s", file.Client.HTTPVersion)
+	}
+}
+
+func TestDurationUnmarshal(t *testing.T) {
+	tests := []struct {
+		content   string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{content: "timeout: 15", expected: 15 * time.Second},
+		{content: "timeout: 0.5", expected: 500 * time.Millisecond},
+		{content: "timeout: '20'", expected: 20 * time.Second},
+		{content: "timeout: 250ms", expected: 250 * time.Millisecond},
+		{content: "timeout: soon", expectErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.content, func(t *testing.T) {
+			var file struct {
+				Timeout Duration `yaml:"timeout"`
+			}
+
+			err := yaml.Unmarshal([]byte(tc.content), &file)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if time.Duration(file.Timeout) != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, time.Duration(file.Timeout))
+			}
+		})
+	}
+}
+
+func TestClientConfigIsValid(t *testing.T) {
+	negative := -1
+	negativeDuration := Duration(-time.Second)
+
+	tests := []struct {
+		name     string
+		config   *ClientConfig
+		expected bool
+	}{
+		{name: "nil client", config: nil, expected: true},
+		{name: "empty client", config: &ClientConfig{}, expected: true},
+		{name: "valid proxy", config: &ClientConfig{Proxy: "http://localhost:8080"}, expected: true},
+		{name: "invalid proxy", config: &ClientConfig{Proxy: "localhost"}, expected: false},
+		{name: "negative redirects", config: &ClientConfig{MaxRedirects: &negative}, expected: false},
+		{name: "negative timeout", config: &ClientConfig{Timeout: &negativeDuration}, expected: false},
+		{name: "missing ca_cert", config: &ClientConfig{CACert: "/no/such/ca.pem"}, expected: false},
+		{name: "http2", config: &ClientConfig{HTTPVersion: HTTP2}, expected: true},
+		{name: "http3", config: &ClientConfig{HTTPVersion: "3"}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := tc.config.IsValid()
+			if valid != tc.expected {
+				t.Errorf("expected %v, got %v with error %v", tc.expected, valid, err)
+			}
+		})
+	}
+}
+
+func TestClientConfigMerge(t *testing.T) {
+	follow := false
+	projectTimeout := Duration(30 * time.Second)
+	fileTimeout := Duration(5 * time.Second)
+
+	project := &ClientConfig{Timeout: &projectTimeout, Proxy: "http://proxy:8080"}
+	file := &ClientConfig{Timeout: &fileTimeout, FollowRedirects: &follow}
+
+	merged := project.Merge(file)
+	if time.Duration(*merged.Timeout) != 5*time.Second {
+		t.Errorf("expected file timeout to win, got %v", time.Duration(*merged.Timeout))
+	}
+
+	if merged.Proxy != "http://proxy:8080" {
+		t.Errorf("expected project proxy to be kept, got %s", merged.Proxy)
+	}
+
+	if merged.FollowRedirects == nil || *merged.FollowRedirects {
+		t.Errorf("expected follow_redirects from file")
+	}
+
+	if time.Duration(*project.Timeout) != 30*time.Second {
+		t.Errorf("merge should not modify the project config")
+	}
+
+	var nilConfig *ClientConfig
+	if nilConfig.Merge(nil) != nil {
+		t.Errorf("expected nil when both configs are nil")
+	}
+}
+
+func TestClientConfigCertValidation(t *testing.T) {
+	pemContent := "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----"
+
+	tests := []struct {
+		name     string
+		config   *ClientConfig
+		expected bool
+	}{
+		{name: "cert and key as PEM", config: &ClientConfig{Cert: pemContent, Key: pemContent}, expected: true},
+		{name: "cert only", config: &ClientConfig{Cert: pemContent}, expected: true},
+		{name: "key without cert", config: &ClientConfig{Key: pemContent}, expected: false},
+		{name: "missing cert file", config: &ClientConfig{Cert: "/no/such/client.pem"}, expected: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if valid, err := tc.config.IsValid(); valid != tc.expected {
+				t.Errorf("expected %v, got %v with error %v", tc.expected, valid, err)
+			}
+		})
+	}
+
+	project := &ClientConfig{Cert: "project.pem", Key: "project.key"}
+	merged := project.Merge(&ClientConfig{Cert: pemContent})
+
+	if merged.Cert != pemContent || merged.Key != "" {
+		t.Errorf("expected cert and key to be overridden together, got %+v", merged)
+	}
+}
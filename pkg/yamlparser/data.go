@@ -0,0 +1,73 @@
+package yamlparser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+const dataKey = "data"
+
+// DataRows returns the rows of the top level 'data' key of the file, or nil when the file has no data key.
+// Data is either the path of a .csv or .json dataset, relative to the file or the project root, or a list of rows
+//
+//	data: users.csv
+//	# or
+//	data:
+//	  - userId: 1
+//	    locale: en-US
+//	  - userId: 2
+//	    locale: fr-FR
+//
+// Like depends_on, the data block is read before the file is templated, since its values are used in the templates
+func DataRows(filePath string) ([]map[string]any, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := topLevelValue(content, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid yaml in '%s': %w", filePath, err)
+	}
+
+	switch val := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		if strings.Contains(val, "{{") {
+			return nil, fmt.Errorf("template actions are not supported in %s of '%s'", dataKey, filePath)
+		}
+
+		dataset, ok := utils.ResolveRelativeFile(filePath, val)
+		if !ok {
+			return nil, fmt.Errorf("dataset '%s' in '%s' does not exist", val, filePath)
+		}
+
+		return envparser.LoadDataset(dataset)
+	case []any:
+		rows := make([]map[string]any, 0, len(val))
+
+		for _, each := range val {
+			row, ok := TemplateValue(each).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s in '%s': each row should be key value pairs", dataKey, filePath)
+			}
+
+			rows = append(rows, row)
+		}
+
+		if len(rows) == 0 {
+			return nil, fmt.Errorf("%s in '%s' has no rows", dataKey, filePath)
+		}
+
+		return rows, nil
+	default:
+		return nil, fmt.Errorf(
+			"invalid %s in '%s': use the path of a .csv or .json file, or a list of rows", dataKey, filePath,
+		)
+	}
+}
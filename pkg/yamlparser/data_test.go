@@ -0,0 +1,88 @@
+package yamlparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDataRows(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "users.csv"), []byte("userId\n7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		expected  []map[string]any
+		expectErr bool
+	}{
+		{
+			name:    "no data",
+			content: "method: GET\nurl: \"{{.baseUrl}}\"\n",
+		},
+		{
+			name:     "dataset next to the file",
+			content:  "data: users.csv\nmethod: GET\nurl: \"{{.baseUrl}}/users/{{.userId}}\"\n",
+			expected: []map[string]any{{"userId": 7}},
+		},
+		{
+			name: "inline rows",
+			content: `method: GET
+Data:
+  - userId: 1
+    locale: en-US
+  - userId: 2
+    locale: fr-FR
+url: "{{.baseUrl}}/users/{{.userId}}"
+`,
+			expected: []map[string]any{
+				{"userId": 1, "locale": "en-US"},
+				{"userId": 2, "locale": "fr-FR"},
+			},
+		},
+		{
+			name:      "missing dataset",
+			content:   "data: missing.csv\n",
+			expectErr: true,
+		},
+		{
+			name:      "template in data",
+			content:   "data: '{{.dataset}}'\n",
+			expectErr: true,
+		},
+		{
+			name:      "row is not a map",
+			content:   "data: [1, 2]\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(dir, "get_user.yaml")
+			if err := os.WriteFile(filePath, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			rows, err := DataRows(filePath)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got rows %v", rows)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(rows, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, rows)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package yamlparser
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const dependsOnKey = "depends_on"
+
+// DependsOn returns the files listed in the top level 'depends_on' key of the file.
+//
+//	depends_on: [login.yaml, create_user.yaml]
+//
+// The file is not templated, since the values a file uses could be captured by its dependencies.
+// So, depends_on can't use template actions
+func DependsOn(filePath string) ([]string, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := topLevelValue(content, dependsOnKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid yaml in '%s': %w", filePath, err)
+	}
+
+	var dependencies []string
+
+	switch val := value.(type) {
+	case nil:
+	case string:
+		dependencies = append(dependencies, val)
+	case []any:
+		for _, each := range val {
+			str, ok := each.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				return nil, fmt.Errorf(
+					"invalid %s in '%s': '%v' is not a file path", dependsOnKey, filePath, each,
+				)
+			}
+
+			dependencies = append(dependencies, str)
+		}
+	default:
+		return nil, fmt.Errorf(
+			"invalid %s in '%s': use a file path or a list of file paths", dependsOnKey, filePath,
+		)
+	}
+
+	for _, dep := range dependencies {
+		if strings.Contains(dep, "{{") {
+			return nil, fmt.Errorf(
+				"template actions are not supported in %s of '%s'", dependsOnKey, filePath,
+			)
+		}
+	}
+
+	return dependencies, nil
+}
+
+// topLevelValue returns the value of the top level key of the yaml content, matched in any case like Depends_On.
+// Returns nil when the content doesn't have the key
+func topLevelValue(content []byte, key string) (any, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+
+	for docKey, value := range doc {
+		if strings.EqualFold(docKey, key) {
+			return value, nil
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,89 @@
+package yamlparser
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDependsOn(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  []string
+		expectErr bool
+	}{
+		{
+			name:    "no depends_on",
+			content: "method: GET\nurl: https://example.com\n",
+		},
+		{
+			name:     "flow list",
+			content:  "method: GET\nurl: \"{{.baseUrl}}/users\"\ndepends_on: [login.yaml, create_user.yaml]\n",
+			expected: []string{"login.yaml", "create_user.yaml"},
+		},
+		{
+			name: "block list with comments",
+			content: `method: GET
+depends_on:
+  # login first
+  - login.yaml
+  - users/create_user.yaml
+url: "{{.baseUrl}}/users"
+`,
+			expected: []string{"login.yaml", "users/create_user.yaml"},
+		},
+		{
+			name:     "list without indentation",
+			content:  "depends_on:\n- login.yaml\n- create_user.yaml\nmethod: GET\n",
+			expected: []string{"login.yaml", "create_user.yaml"},
+		},
+		{
+			name:     "single file",
+			content:  "Depends_On: login.yaml\nurl: \"{{.baseUrl}}\"\n",
+			expected: []string{"login.yaml"},
+		},
+		{
+			name:      "template in depends_on",
+			content:   "depends_on: ['{{.loginFile}}']\n",
+			expectErr: true,
+		},
+		{
+			name:      "invalid yaml",
+			content:   "depends_on: [login.yaml\nmethod: GET\n",
+			expectErr: true,
+		},
+		{
+			name:      "map in depends_on",
+			content:   "depends_on:\n  file: login.yaml\n",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			filePath := filepath.Join(t.TempDir(), "file.yaml")
+			if err := os.WriteFile(filePath, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := DependsOn(filePath)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", got)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
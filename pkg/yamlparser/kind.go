@@ -2,9 +2,11 @@
 package yamlparser
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/envparser"
 	"github.com/xaaha/hulak/pkg/utils"
 )
 
@@ -13,8 +15,9 @@ type Kind string
 
 // Available configuration kinds
 const (
-	KindAuth Kind = "Auth"
-	KindAPI  Kind = "API"
+	KindAuth     Kind = "Auth"
+	KindAPI      Kind = "API"
+	KindWorkflow Kind = "Workflow"
 )
 
 // KindConfig holds configuration for handling different kinds
@@ -38,6 +41,7 @@ func newKindConfig() *KindConfig {
 	// Register default kinds
 	kc.registerKind(KindAuth)
 	kc.registerKind(KindAPI)
+	kc.registerKind(KindWorkflow)
 
 	return kc
 }
@@ -109,6 +113,11 @@ func (conf *ConfigType) IsAPI() bool {
 	return conf.IsKind(KindAPI)
 }
 
+// IsWorkflow checks if the kind is Workflow
+func (conf *ConfigType) IsWorkflow() bool {
+	return conf.IsKind(KindWorkflow)
+}
+
 // ValidateKinds checks if all kinds in the slice are valid
 func ValidateKinds(kinds []Kind) ([]string, bool) {
 	var invalidKinds []string
@@ -126,18 +135,29 @@ func ValidateKinds(kinds []Kind) ([]string, bool) {
 	return invalidKinds, isValid
 }
 
-// ParseConfig parses a YAML file and returns the configuration type
+// ParseConfig parses a YAML file and returns the configuration type.
+// Only the kind is templated, since a workflow uses values that are captured while it runs
 func ParseConfig(filePath string, secretsMap map[string]any) (*ConfigType, error) {
-	buf, err := checkYamlFile(filePath, secretsMap)
-	if err != nil {
-		return nil, utils.ColorError("error reading YAML file: %w", err)
+	var data map[string]any
+	if err := yaml.Unmarshal(readYamlFile(filePath), &data); err != nil {
+		return nil, utils.ColorError("error decoding YAML", err)
 	}
 
+	data = utils.ConvertKeysToLowerCase(data)
+
 	var config ConfigType
 
-	dec := yaml.NewDecoder(buf)
-	if err := dec.Decode(&config); err != nil {
-		return nil, utils.ColorError("error decoding YAML: %w", err)
+	switch kind := data["kind"].(type) {
+	case nil:
+	case string:
+		replaced, err := envparser.SubstituteVariables(kind, secretsMap, nil)
+		if err != nil {
+			return nil, utils.ColorError("error reading kind", err)
+		}
+
+		config.Kind = Kind(fmt.Sprint(replaced))
+	default:
+		return nil, utils.ColorError(fmt.Sprintf("invalid kind '%v' in '%s'", kind, filePath))
 	}
 
 	return &config, nil
@@ -2,7 +2,15 @@
 package yamlparser
 
 import (
+	"encoding/base64"
+	"fmt"
 	"io"
+	"maps"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/xaaha/hulak/pkg/utils"
 )
@@ -17,10 +25,139 @@ const (
 	Oauth2type3 authtype = "oauth2.0"
 )
 
+// grantType is the OAuth2.0 flow used to get the token
+type grantType string
+
+// Supported values of auth.grant
+const (
+	// AuthorizationCode opens the browser and exchanges the code for the token. It's the default grant
+	AuthorizationCode grantType = "authorization_code"
+	// ClientCredentials gets the token with client_id and client_secret, without a browser
+	ClientCredentials grantType = "client_credentials"
+	// DeviceCode prints a code to enter on another device, and polls for the token. RFC 8628
+	DeviceCode grantType = "device_code"
+)
+
+// deviceCodeGrantType is the grant_type sent to the access_token_url for the device_code grant
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// clientAuth is how client_id and client_secret are sent to the access_token_url
+type clientAuth string
+
+// Supported values of auth.client_auth
+const (
+	// ClientAuthBody sends the client credentials in the body. It's the default
+	ClientAuthBody clientAuth = "body"
+	// ClientAuthBasic sends the client credentials in the Authorization header
+	ClientAuthBasic clientAuth = "basic"
+)
+
 // Auth Represents how Auth section in yaml looks like
 type Auth struct {
-	Type           authtype `json:"type"             yaml:"type"`
-	AccessTokenURL URL      `json:"access_token_url" yaml:"access_token_url"`
+	Type           authtype   `json:"type"                    yaml:"type"`
+	AccessTokenURL URL        `json:"access_token_url"        yaml:"access_token_url"`
+	Grant          grantType  `json:"grant,omitempty"         yaml:"grant"`
+	ClientID       string     `json:"client_id,omitempty"     yaml:"client_id"`
+	ClientSecret   string     `json:"client_secret,omitempty" yaml:"client_secret"`
+	Scope          string     `json:"scope,omitempty"         yaml:"scope"`
+	ClientAuth     clientAuth `json:"client_auth,omitempty"   yaml:"client_auth"`
+	// DeviceAuthorizationURL is the endpoint that issues the device and user codes for the device_code grant
+	DeviceAuthorizationURL URL `json:"device_authorization_url,omitempty" yaml:"device_authorization_url"`
+	// PKCE sends code_challenge with the authorization_code grant. It's enabled unless it's false
+	PKCE *bool `json:"pkce,omitempty" yaml:"pkce"`
+	// Callback is where the browser is redirected with the code, for the authorization_code grant
+	Callback *Callback `json:"callback,omitempty" yaml:"callback"`
+	// Issuer is the OpenID Connect provider. Endpoints missing in the file are discovered
+	// from its /.well-known/openid-configuration
+	Issuer URL `json:"issuer,omitempty" yaml:"issuer"`
+	// VerifyIDToken checks the signature of the id_token with the keys the issuer publishes
+	VerifyIDToken bool `json:"verify_id_token,omitempty" yaml:"verify_id_token"`
+}
+
+// Defaults of the callback server for the authorization_code grant
+const (
+	DefaultCallbackHost    = "localhost"
+	DefaultCallbackPort    = 2982
+	DefaultCallbackPath    = "/callback"
+	DefaultCallbackTimeout = 60 * time.Second
+)
+
+// Callback configures the local server that receives the code from the provider.
+// The redirect_uri is http://host:port/path, which must be registered with the provider.
+//
+//	callback:
+//	  host: 127.0.0.1
+//	  port: 0 # any free port
+//	  path: /oauth/callback
+//	  timeout: 2m
+type Callback struct {
+	Host    string    `json:"host,omitempty"    yaml:"host"`
+	Port    *int      `json:"port,omitempty"    yaml:"port"`
+	Path    string    `json:"path,omitempty"    yaml:"path"`
+	Timeout *Duration `json:"timeout,omitempty" yaml:"timeout"`
+}
+
+// IsValid checks the callback section. Missing callback section is valid
+func (c *Callback) IsValid() (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+
+	if strings.ContainsAny(c.Host, "/:?#") && net.ParseIP(c.Host) == nil {
+		return false, utils.ColorError("invalid callback host '" + c.Host + "'. Use a host name or an ip, like localhost")
+	}
+
+	if c.Port != nil && (*c.Port < 0 || *c.Port > 65535) {
+		return false, utils.ColorError(fmt.Sprintf("invalid callback port %d. Use 0 for any free port", *c.Port))
+	}
+
+	if strings.ContainsAny(c.Path, "?#") {
+		return false, utils.ColorError("callback path '" + c.Path + "' can't have a query or a fragment")
+	}
+
+	if c.Timeout != nil && *c.Timeout < 0 {
+		return false, utils.ColorError("callback timeout can't be negative")
+	}
+
+	return true, nil
+}
+
+// Address is the host:port the callback server listens on. Port 0 lets the system pick a free port
+func (c *Callback) Address() string {
+	host := DefaultCallbackHost
+	port := DefaultCallbackPort
+
+	if c != nil && c.Host != "" {
+		host = c.Host
+	}
+
+	if c != nil && c.Port != nil {
+		port = *c.Port
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// URLPath is the path of the callback, /callback by default
+func (c *Callback) URLPath() string {
+	if c == nil || c.Path == "" {
+		return DefaultCallbackPath
+	}
+
+	if !strings.HasPrefix(c.Path, "/") {
+		return "/" + c.Path
+	}
+
+	return c.Path
+}
+
+// WaitTimeout is how long hulak waits for the code, 60 seconds by default
+func (c *Callback) WaitTimeout() time.Duration {
+	if c == nil || c.Timeout == nil || *c.Timeout == 0 {
+		return DefaultCallbackTimeout
+	}
+
+	return time.Duration(*c.Timeout)
 }
 
 // IsValid checks if auth key contains type and has at least 1 item in Extras
@@ -32,6 +169,11 @@ func (a *Auth) IsValid() bool {
 
 	switch a.Type {
 	case Oauth2type1, Oauth2type2, Oauth2type3:
+		// issuer publishes the access_token_url
+		if a.Issuer != "" {
+			return a.Issuer.IsValidURL()
+		}
+
 		if a.AccessTokenURL == "" || !a.AccessTokenURL.IsValidURL() {
 			return false
 		}
@@ -43,6 +185,91 @@ func (a *Auth) IsValid() bool {
 	}
 }
 
+// validGrant checks if the grant and the client_auth of the auth section are supported
+func (a *Auth) validGrant() error {
+	switch a.GrantType() {
+	case AuthorizationCode, ClientCredentials:
+	case DeviceCode:
+		if a.Issuer == "" && !a.DeviceAuthorizationURL.IsValidURL() {
+			return utils.ColorError("device_code grant requires a valid device_authorization_url")
+		}
+
+		if a.ClientID == "" {
+			return utils.ColorError("device_code grant requires client_id in the auth section")
+		}
+	default:
+		return utils.ColorError("unsupported grant '" + string(a.Grant) +
+			"'. Supported grants are authorization_code, client_credentials and device_code")
+	}
+
+	if a.VerifyIDToken && a.Issuer == "" {
+		return utils.ColorError("verify_id_token requires the issuer, which publishes the keys")
+	}
+
+	switch a.ClientAuth {
+	case "", ClientAuthBody:
+	case ClientAuthBasic:
+		if a.ClientID == "" {
+			return utils.ColorError("'client_auth: basic' requires client_id in the auth section")
+		}
+	default:
+		return utils.ColorError("unsupported client_auth '" + string(a.ClientAuth) + "'. Use body or basic")
+	}
+
+	return nil
+}
+
+// GrantType returns the grant of the auth, authorization_code by default
+func (a *Auth) GrantType() grantType {
+	if a.Grant == "" {
+		return AuthorizationCode
+	}
+
+	return a.Grant
+}
+
+// UsePKCE checks if the authorization_code grant should use PKCE. It's true unless pkce is false
+func (a *Auth) UsePKCE() bool {
+	return a.PKCE == nil || *a.PKCE
+}
+
+// tokenParams returns the params of the grant sent to the access_token_url, like grant_type and scope.
+// client_id and client_secret are included when they are sent in the body
+func (a *Auth) tokenParams() map[string]string {
+	params := make(map[string]string)
+
+	switch a.GrantType() {
+	case ClientCredentials:
+		params["grant_type"] = string(ClientCredentials)
+
+		if a.Scope != "" {
+			params["scope"] = a.Scope
+		}
+	case DeviceCode:
+		params["grant_type"] = deviceCodeGrantType
+	}
+
+	if a.ClientAuth != ClientAuthBasic {
+		if a.ClientID != "" {
+			params["client_id"] = a.ClientID
+		}
+
+		if a.ClientSecret != "" {
+			params["client_secret"] = a.ClientSecret
+		}
+	}
+
+	return params
+}
+
+// basicAuthHeader is the Authorization header with the client credentials.
+// Credentials are url encoded before they are joined, as the OAuth2.0 spec requires
+func (a *Auth) basicAuthHeader() string {
+	credentials := url.QueryEscape(a.ClientID) + ":" + url.QueryEscape(a.ClientSecret)
+
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+}
+
 // URLPARAMS is the standard url params just like in api file
 type URLPARAMS map[string]string
 
@@ -83,51 +310,46 @@ func (b *Auth2Body) IsValid() bool {
 	return false
 }
 
-// EncodeBody encodes the *Auth2Body
-func (b *Auth2Body) EncodeBody(code string) (io.Reader, string, error) {
-	var body io.Reader
-
-	var contentType string
-
-	if b == nil {
-		return nil, "", nil
+// EncodeBody encodes the *Auth2Body along with the params of the grant, like code or grant_type.
+// Params take precedence over the values in the body. Body could be nil when the grant has all the params it needs
+func (b *Auth2Body) EncodeBody(params map[string]string) (io.Reader, string, error) {
+	formData := make(map[string]string)
+	if b != nil {
+		maps.Copy(formData, b.URLEncodedFormData)
 	}
 
-	codeMap := make(map[string]string)
-	codeMap[utils.ResponseType] = code
-	mergedMap := utils.MergeMaps(b.URLEncodedFormData, codeMap)
-
-	switch {
-	case len(b.URLEncodedFormData) > 0:
-		encodedBody, err := EncodeXwwwFormURLBody(mergedMap)
-		if err != nil {
-			return nil, "", utils.ColorError("#oAuthTypes.go", err)
-		}
+	maps.Copy(formData, params)
 
-		body, contentType = encodedBody, "application/x-www-form-urlencoded"
-	default:
+	if len(formData) == 0 {
 		return nil, "", utils.ColorError("no valid body type provided")
 	}
 
-	return body, contentType, nil
+	encodedBody, err := EncodeXwwwFormURLBody(formData)
+	if err != nil {
+		return nil, "", utils.ColorError("#oAuthTypes.go", err)
+	}
+
+	return encodedBody, "application/x-www-form-urlencoded", nil
 }
 
 // AuthRequestFile  represents how a yaml file for Auth2.0 would look like
 type AuthRequestFile struct {
-	Method    HTTPMethodType    `json:"method"              yaml:"method"`
-	URL       URL               `json:"url"                 yaml:"url"`
-	URLParams URLPARAMS         `json:"urlparams,omitempty" yaml:"urlparams"`
-	Auth      *Auth             `json:"auth"                yaml:"auth"`
-	Headers   map[string]string `json:"headers,omitempty"   yaml:"headers"`
+	Method    HTTPMethodType    `json:"method"                yaml:"method"`
+	URL       URL               `json:"url"                   yaml:"url"`
+	URLParams URLPARAMS         `json:"urlparams,omitempty"   yaml:"urlparams"`
+	Auth      *Auth             `json:"auth"                  yaml:"auth"`
+	Headers   map[string]string `json:"headers,omitempty"     yaml:"headers"`
+	Capture   Captures          `json:"capture,omitempty"     yaml:"capture"`
+	SaveToEnv SaveToEnv         `json:"save_to_env,omitempty" yaml:"save_to_env"`
 	Body      *Auth2Body
 }
 
 // IsValid checks if AuthRequestBody is valid ,
 // Has valid method, if missing method defaults to post.
 // Has valid auth section with type and access_token_url, for auth2.0
-// Has Required, and valid Url
+// Has Required, and valid Url, for the authorization_code grant
 // If UrlParams is present, client_id is required
-// Valid Body is present
+// Valid Body is present, for the authorization_code grant
 func (auth2Body *AuthRequestFile) IsValid() (bool, error) {
 	if auth2Body == nil {
 		return false, utils.ColorError("auth request body is nil")
@@ -153,12 +375,23 @@ func (auth2Body *AuthRequestFile) IsValid() (bool, error) {
 
 	if valid := auth2Body.Auth.IsValid(); !valid {
 		return false, utils.ColorError(
-			"invalid 'auth' section. Make sure the Auth2.0 file contains valid auth section with 'type' && access_token_url",
+			"invalid 'auth' section. Make sure the Auth2.0 file contains valid auth section with 'type' && access_token_url, or issuer",
 		)
 	}
 
-	// Validate URL
-	if !auth2Body.URL.IsValidURL() {
+	if err := auth2Body.Auth.validGrant(); err != nil {
+		return false, err
+	}
+
+	if valid, err := auth2Body.Auth.Callback.IsValid(); !valid {
+		return false, err
+	}
+
+	// client_credentials and device_code grants don't open the browser, so they need neither url nor body
+	browserFlow := auth2Body.Auth.GrantType() == AuthorizationCode
+
+	// Validate URL. Issuer publishes it as authorization_endpoint
+	if browserFlow && auth2Body.Auth.Issuer == "" && !auth2Body.URL.IsValidURL() {
 		return false, utils.ColorError("missing or invalid URL in auth request body")
 	}
 
@@ -168,33 +401,128 @@ func (auth2Body *AuthRequestFile) IsValid() (bool, error) {
 	}
 
 	// Validate Body
-	if !auth2Body.Body.IsValid() {
+	if browserFlow && !auth2Body.Body.IsValid() {
 		return false, utils.ColorError("invalid body content")
 	}
 
+	if valid, err := auth2Body.Capture.IsValid(); !valid {
+		return false, utils.ColorError("invalid 'capture'", err)
+	}
+
+	if valid, err := auth2Body.SaveToEnv.IsValid(auth2Body.Capture); !valid {
+		return false, utils.ColorError("invalid 'save_to_env'", err)
+	}
+
 	return true, nil
 }
 
-// PrepareStruct prepars struct for the standard call
-func (auth2Body *AuthRequestFile) PrepareStruct(code string) (ApiInfo, error) {
-	body, contentType, err := auth2Body.Body.EncodeBody(code)
+// OIDCEndpoints are the endpoints an OpenID Connect issuer publishes in its discovery document
+type OIDCEndpoints struct {
+	Authorization       string
+	Token               string
+	DeviceAuthorization string
+}
+
+// FillEndpoints fills the endpoints missing in the file with the ones the issuer published.
+// Endpoints in the file take precedence. Returns an error when the grant still misses an endpoint
+func (auth2Body *AuthRequestFile) FillEndpoints(endpoints OIDCEndpoints) error {
+	if auth2Body.URL == "" {
+		auth2Body.URL = URL(endpoints.Authorization)
+	}
+
+	if auth2Body.Auth.AccessTokenURL == "" {
+		auth2Body.Auth.AccessTokenURL = URL(endpoints.Token)
+	}
+
+	if auth2Body.Auth.DeviceAuthorizationURL == "" {
+		auth2Body.Auth.DeviceAuthorizationURL = URL(endpoints.DeviceAuthorization)
+	}
+
+	if !auth2Body.Auth.AccessTokenURL.IsValidURL() {
+		return utils.ColorError("issuer didn't publish a valid token_endpoint. Add access_token_url to the auth section")
+	}
+
+	switch auth2Body.Auth.GrantType() {
+	case AuthorizationCode:
+		if !auth2Body.URL.IsValidURL() {
+			return utils.ColorError("issuer didn't publish a valid authorization_endpoint. Add url to the file")
+		}
+	case DeviceCode:
+		if !auth2Body.Auth.DeviceAuthorizationURL.IsValidURL() {
+			return utils.ColorError(
+				"issuer didn't publish a valid device_authorization_endpoint. Add device_authorization_url to the auth section",
+			)
+		}
+	case ClientCredentials:
+	}
+
+	return nil
+}
+
+// PrepareStruct prepars struct for the request to the access_token_url.
+// params are added to the body along with the params of the grant,
+// like the code from the browser for the authorization_code grant
+func (auth2Body *AuthRequestFile) PrepareStruct(params map[string]string) (ApiInfo, error) {
+	grantParams := auth2Body.Auth.tokenParams()
+	maps.Copy(grantParams, params)
+
+	body, contentType, err := auth2Body.Body.EncodeBody(grantParams)
 	if err != nil {
 		return ApiInfo{}, utils.ColorError("#apiTypes.go", err)
 	}
 
-	if contentType != "" {
-		if auth2Body.Headers == nil {
-			auth2Body.Headers = make(map[string]string)
-		}
+	if auth2Body.Headers == nil {
+		auth2Body.Headers = make(map[string]string)
+	}
 
+	if contentType != "" {
 		auth2Body.Headers["content-type"] = contentType
 	}
 
+	if auth2Body.Auth.ClientAuth == ClientAuthBasic {
+		auth2Body.Headers["authorization"] = auth2Body.Auth.basicAuthHeader()
+	}
+
+	// urlparams belong to the authorization url opened in the browser,
+	// so they are not sent to the access token url
+	return ApiInfo{
+		Method:  string(auth2Body.Method),
+		Url:     string(auth2Body.Auth.AccessTokenURL),
+		Headers: auth2Body.Headers,
+		Body:    body,
+	}, nil
+}
+
+// PrepareDeviceAuthorization prepares the request to the device_authorization_url,
+// that returns the user code to enter on another device
+func (auth2Body *AuthRequestFile) PrepareDeviceAuthorization() (ApiInfo, error) {
+	params := make(map[string]string)
+	if auth2Body.Auth.Scope != "" {
+		params["scope"] = auth2Body.Auth.Scope
+	}
+
+	headers := maps.Clone(auth2Body.Headers)
+	if headers == nil {
+		headers = make(map[string]string)
+	}
+
+	if auth2Body.Auth.ClientAuth == ClientAuthBasic {
+		headers["authorization"] = auth2Body.Auth.basicAuthHeader()
+	} else {
+		params["client_id"] = auth2Body.Auth.ClientID
+	}
+
+	body, err := EncodeXwwwFormURLBody(params)
+	if err != nil {
+		return ApiInfo{}, utils.ColorError("#oAuthTypes.go", err)
+	}
+
+	headers["content-type"] = "application/x-www-form-urlencoded"
+
 	return ApiInfo{
-		Method:    string(auth2Body.Method),
-		Url:       string(auth2Body.Auth.AccessTokenURL),
-		UrlParams: auth2Body.URLParams,
-		Headers:   auth2Body.Headers,
-		Body:      body,
+		Method:  string(POST),
+		Url:     string(auth2Body.Auth.DeviceAuthorizationURL),
+		Headers: headers,
+		Body:    body,
 	}, nil
 }
@@ -1,8 +1,13 @@
 package yamlparser
 
 import (
+	"io"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/goccy/go-yaml"
 )
 
 func TestAuth_IsValid(t *testing.T) {
@@ -262,6 +267,93 @@ func TestAuthRequestBody_IsValid(t *testing.T) {
 			expectedBool: true,
 			expectedErr:  "",
 		},
+		{
+			name: "Valid client_credentials without url and body",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type2,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					ClientID:       "my_id",
+					ClientSecret:   "my_secret",
+				},
+			},
+			expectedBool: true,
+		},
+		{
+			name: "Unsupported grant",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type2,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          "password",
+				},
+			},
+			expectedBool: false,
+			expectedErr:  "unsupported grant 'password'",
+		},
+		{
+			name: "Basic client_auth without client_id",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type2,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					ClientAuth:     ClientAuthBasic,
+				},
+			},
+			expectedBool: false,
+			expectedErr:  "requires client_id",
+		},
+		{
+			name: "Callback on any free port",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type2,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					Callback:       &Callback{Port: new(int)},
+				},
+			},
+			expectedBool: true,
+		},
+		{
+			name: "Callback port out of range",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type2,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					Callback:       &Callback{Port: intPtr(70000)},
+				},
+			},
+			expectedBool: false,
+			expectedErr:  "invalid callback port 70000",
+		},
+		{
+			name: "Issuer publishes url and access_token_url",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:   Oauth2type1,
+					Issuer: "https://accounts.example.com",
+				},
+				Body: &Auth2Body{URLEncodedFormData: map[string]string{"client_id": "xaaha"}},
+			},
+			expectedBool: true,
+		},
+		{
+			name: "verify_id_token without issuer",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type1,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					VerifyIDToken:  true,
+				},
+			},
+			expectedBool: false,
+			expectedErr:  "verify_id_token requires the issuer",
+		},
 	}
 
 	for _, tt := range tests {
@@ -287,3 +379,229 @@ func TestAuthRequestBody_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthRequestFile_PrepareStruct(t *testing.T) {
+	tests := []struct {
+		name          string
+		authRequest   AuthRequestFile
+		params        map[string]string
+		expectedBody  url.Values
+		expectedBasic string
+	}{
+		{
+			name: "authorization_code adds the code to the body",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{Type: Oauth2type1, AccessTokenURL: "https://auth.example.com/token"},
+				Body: &Auth2Body{URLEncodedFormData: map[string]string{"client_id": "my_id"}},
+			},
+			params:       map[string]string{"code": "abc"},
+			expectedBody: url.Values{"client_id": {"my_id"}, "code": {"abc"}},
+		},
+		{
+			name: "client_credentials in the body",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type1,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					ClientID:       "my_id",
+					ClientSecret:   "my_secret",
+					Scope:          "read write",
+				},
+				Body: &Auth2Body{URLEncodedFormData: map[string]string{"audience": "api"}},
+			},
+			expectedBody: url.Values{
+				"grant_type":    {"client_credentials"},
+				"client_id":     {"my_id"},
+				"client_secret": {"my_secret"},
+				"scope":         {"read write"},
+				"audience":      {"api"},
+			},
+		},
+		{
+			name: "client_credentials with basic auth",
+			authRequest: AuthRequestFile{
+				Auth: &Auth{
+					Type:           Oauth2type1,
+					AccessTokenURL: "https://auth.example.com/token",
+					Grant:          ClientCredentials,
+					ClientID:       "my id",
+					ClientSecret:   "s3cr3t",
+					ClientAuth:     ClientAuthBasic,
+				},
+			},
+			expectedBody: url.Values{"grant_type": {"client_credentials"}},
+			// base64 of "my+id:s3cr3t"
+			expectedBasic: "Basic bXkraWQ6czNjcjN0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiInfo, err := tt.authRequest.PrepareStruct(tt.params)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if apiInfo.Url != "https://auth.example.com/token" {
+				t.Errorf("expected request to access_token_url, got %s", apiInfo.Url)
+			}
+
+			raw, err := io.ReadAll(apiInfo.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body, err := url.ParseQuery(string(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if body.Encode() != tt.expectedBody.Encode() {
+				t.Errorf("expected body %s, got %s", tt.expectedBody.Encode(), body.Encode())
+			}
+
+			if apiInfo.Headers["authorization"] != tt.expectedBasic {
+				t.Errorf("expected authorization header %q, got %q", tt.expectedBasic, apiInfo.Headers["authorization"])
+			}
+
+			if apiInfo.Headers["content-type"] != "application/x-www-form-urlencoded" {
+				t.Errorf("expected url encoded content type, got %q", apiInfo.Headers["content-type"])
+			}
+		})
+	}
+}
+
+func intPtr(val int) *int {
+	return &val
+}
+
+func TestCallback(t *testing.T) {
+	tests := []struct {
+		name            string
+		content         string
+		expectedAddress string
+		expectedPath    string
+		expectedTimeout time.Duration
+		expectedErr     string
+	}{
+		{
+			name:            "defaults",
+			content:         "{}",
+			expectedAddress: "localhost:2982",
+			expectedPath:    "/callback",
+			expectedTimeout: 60 * time.Second,
+		},
+		{
+			name:            "custom values",
+			content:         "host: 127.0.0.1\nport: 8080\npath: oauth/done\ntimeout: 2m",
+			expectedAddress: "127.0.0.1:8080",
+			expectedPath:    "/oauth/done",
+			expectedTimeout: 2 * time.Minute,
+		},
+		{
+			name:            "any free port",
+			content:         "port: 0\ntimeout: 90",
+			expectedAddress: "localhost:0",
+			expectedPath:    "/callback",
+			expectedTimeout: 90 * time.Second,
+		},
+		{
+			name:            "ipv6 host",
+			content:         "host: '::1'",
+			expectedAddress: "[::1]:2982",
+			expectedPath:    "/callback",
+			expectedTimeout: 60 * time.Second,
+		},
+		{
+			name:        "host with scheme",
+			content:     "host: http://localhost",
+			expectedErr: "invalid callback host",
+		},
+		{
+			name:        "path with query",
+			content:     "path: /callback?x=1",
+			expectedErr: "can't have a query",
+		},
+		{
+			name:        "negative timeout",
+			content:     "timeout: -1s",
+			expectedErr: "can't be negative",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var callback Callback
+			if err := yaml.Unmarshal([]byte(tc.content), &callback); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			valid, err := callback.IsValid()
+			if tc.expectedErr != "" {
+				if valid || err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Fatalf("expected error with %q, got %v", tc.expectedErr, err)
+				}
+
+				return
+			}
+
+			if !valid {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := callback.Address(); got != tc.expectedAddress {
+				t.Errorf("expected address %q, got %q", tc.expectedAddress, got)
+			}
+
+			if got := callback.URLPath(); got != tc.expectedPath {
+				t.Errorf("expected path %q, got %q", tc.expectedPath, got)
+			}
+
+			if got := callback.WaitTimeout(); got != tc.expectedTimeout {
+				t.Errorf("expected timeout %v, got %v", tc.expectedTimeout, got)
+			}
+		})
+	}
+
+	var missing *Callback
+	if missing.Address() != "localhost:2982" || missing.URLPath() != "/callback" {
+		t.Error("expected defaults for a missing callback section")
+	}
+}
+
+func TestAuthRequestFile_FillEndpoints(t *testing.T) {
+	endpoints := OIDCEndpoints{
+		Authorization:       "https://accounts.example.com/authorize",
+		Token:               "https://accounts.example.com/token",
+		DeviceAuthorization: "https://accounts.example.com/device",
+	}
+
+	file := AuthRequestFile{
+		Auth: &Auth{
+			Type:           Oauth2type1,
+			Issuer:         "https://accounts.example.com",
+			AccessTokenURL: "https://proxy.example.com/token",
+		},
+	}
+
+	if err := file.FillEndpoints(endpoints); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if file.URL != "https://accounts.example.com/authorize" {
+		t.Errorf("expected the discovered url, got %s", file.URL)
+	}
+
+	// endpoints in the file take precedence
+	if file.Auth.AccessTokenURL != "https://proxy.example.com/token" {
+		t.Errorf("expected the access_token_url of the file, got %s", file.Auth.AccessTokenURL)
+	}
+
+	device := AuthRequestFile{Auth: &Auth{Type: Oauth2type1, Issuer: "https://accounts.example.com", Grant: DeviceCode}}
+
+	err := device.FillEndpoints(OIDCEndpoints{Token: endpoints.Token})
+	if err == nil || !strings.Contains(err.Error(), "device_authorization_endpoint") {
+		t.Errorf("expected missing device_authorization_endpoint error, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package yamlparser
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"sync"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// ProjectConfig represents the optional project configuration file, hulak.yaml, in the project root.
+// It holds the defaults for every request file in the project
+//
+//	client:
+//	  timeout: 30s
+//	  proxy: "{{.proxyUrl}}"
+type ProjectConfig struct {
+	Client *ClientConfig `json:"client,omitempty" yaml:"client"`
+}
+
+// IsValid checks the validity of the project configuration
+func (p *ProjectConfig) IsValid() (bool, error) {
+	if p == nil {
+		return true, nil
+	}
+
+	if valid, err := p.Client.IsValid(); !valid {
+		return false, utils.ColorError("invalid 'client' in "+utils.ProjectConfigFile, err)
+	}
+
+	return true, nil
+}
+
+// projectConfigCache keeps the content of the project configuration file of each project root for the rest of the run.
+// Missing file is kept as nil content
+var projectConfigCache sync.Map
+
+// FinalStructForProject reads the project configuration file from the project root.
+// The file is read once per run, and templated with the secretsMap of each call,
+// so the values of data rows, captures and workflow steps are available like in request files.
+// Returns empty ProjectConfig, if the file does not exist
+func FinalStructForProject(
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (ProjectConfig, error) {
+	filePath, err := utils.CreatePath(utils.ProjectConfigFile)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+
+	raw, err := projectConfigContent(filePath)
+	if err != nil || len(bytes.TrimSpace(raw)) == 0 {
+		return ProjectConfig{}, err
+	}
+
+	buf, err := parseYamlContent(raw, secretsMap, token)
+	if err != nil {
+		return ProjectConfig{}, utils.ColorError("error in "+utils.ProjectConfigFile, err)
+	}
+
+	var config ProjectConfig
+
+	dec := yaml.NewDecoder(buf)
+	if err := dec.Decode(&config); err != nil {
+		return ProjectConfig{}, utils.ColorError("error decoding "+utils.ProjectConfigFile, err)
+	}
+
+	if valid, err := config.IsValid(); !valid {
+		return ProjectConfig{}, err
+	}
+
+	return config, nil
+}
+
+// projectConfigContent returns the content of the project configuration file, read once per run
+func projectConfigContent(filePath string) ([]byte, error) {
+	if cached, ok := projectConfigCache.Load(filePath); ok {
+		return cached.([]byte), nil
+	}
+
+	raw, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		raw, err = nil, nil
+	}
+
+	if err != nil {
+		return nil, utils.ColorError("error reading "+utils.ProjectConfigFile, err)
+	}
+
+	cached, _ := projectConfigCache.LoadOrStore(filePath, raw)
+
+	return cached.([]byte), nil
+}
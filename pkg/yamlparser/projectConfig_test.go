@@ -0,0 +1,53 @@
+package yamlparser
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+func TestFinalStructForProject(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if config, err := FinalStructForProject(map[string]any{}, nil); err != nil || config.Client != nil {
+		t.Fatalf("expected empty config without %s, got %+v and %v", utils.ProjectConfigFile, config, err)
+	}
+}
+
+func TestFinalStructForProjectTemplatedPerCall(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	if err := os.WriteFile(utils.ProjectConfigFile, []byte("client:\n  timeout: \"{{.timeout}}\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	timeoutOf := func(secretsMap map[string]any) time.Duration {
+		t.Helper()
+
+		config, err := FinalStructForProject(secretsMap, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if config.Client == nil || config.Client.Timeout == nil {
+			t.Fatalf("expected a timeout, got %+v", config.Client)
+		}
+
+		return time.Duration(*config.Client.Timeout)
+	}
+
+	if got := timeoutOf(map[string]any{"timeout": "5s"}); got != 5*time.Second {
+		t.Errorf("expected 5s, got %v", got)
+	}
+
+	// the file is read once per run, but templated with the values of each call
+	if err := os.WriteFile(utils.ProjectConfigFile, []byte("client:\n  timeout: 10s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := timeoutOf(map[string]any{"timeout": "1s"}); got != time.Second {
+		t.Errorf("expected 1s from the values of the second call, got %v", got)
+	}
+}
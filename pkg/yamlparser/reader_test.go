@@ -69,6 +69,7 @@ KeyTwo: value2
 					_, _ = checkYamlFile(
 						filepath,
 						secretsMap,
+						nil,
 					) // Call function that triggers os.Exit
 					return
 				}
@@ -84,7 +85,7 @@ KeyTwo: value2
 				}
 				t.Fatalf("Expected process to exit with code 1, but got %v", err)
 			} else {
-				buf, err := checkYamlFile(filepath, secretsMap)
+				buf, err := checkYamlFile(filepath, secretsMap, nil)
 				if err != nil {
 					t.Fatalf("Unexpected error: %v", err)
 				}
@@ -238,7 +239,7 @@ body:
 			}
 			defer os.Remove(filepath)
 
-			result, _, err := FinalStructForAPI(filepath, secretsMap)
+			result, _, err := FinalStructForAPI(filepath, secretsMap, nil)
 
 			if tc.expectErr {
 				if err == nil {
@@ -415,7 +416,7 @@ body:
 
 			if tc.expectErr {
 				if os.Getenv("EXPECT_EXIT") == "1" {
-					_, _ = checkYamlFile(filepath, tc.secretMap)
+					_, _ = checkYamlFile(filepath, tc.secretMap, nil)
 					return
 				}
 
@@ -430,7 +431,7 @@ body:
 				t.Fatalf("Expected process to exit with code 1, but got %v", err)
 			} else {
 				// For cases where we don't expect an error/panic
-				buf, err := checkYamlFile(filepath, tc.secretMap)
+				buf, err := checkYamlFile(filepath, tc.secretMap, nil)
 				if err != nil {
 					t.Errorf("Expected no error for test %s, but got: %v", tc.name, err)
 					return
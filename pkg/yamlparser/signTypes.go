@@ -0,0 +1,35 @@
+package yamlparser
+
+import "github.com/xaaha/hulak/pkg/utils"
+
+// signKey is the section of the api file that is templated after the body is encoded,
+// so it's left as it is when the rest of the file is templated
+const signKey = "sign"
+
+// SignHeaders are the headers templated over the final request, like a signature of the body.
+// The templates have the env values, and the request in .request
+//
+//	sign:
+//	  X-Signature: '{{hmacSHA256 .partnerSecret (print .request.method .request.path .request.timestamp .request.body)}}'
+type SignHeaders map[string]string
+
+// Signing is the sign section with the env values its templates use
+type Signing struct {
+	Headers SignHeaders
+	Secrets map[string]any
+}
+
+// IsValid checks the sign section. Missing sign section is valid
+func (s SignHeaders) IsValid() (bool, error) {
+	for name, value := range s {
+		if name == "" {
+			return false, utils.ColorError("header name can't be empty")
+		}
+
+		if value == "" {
+			return false, utils.ColorError("header '" + name + "' needs a template")
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package yamlparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFinalStructForAPIContentSign(t *testing.T) {
+	content := `
+method: POST
+url: "{{.baseUrl}}/orders"
+headers:
+  X-Partner: "{{.partner}}"
+sign:
+  X-Signature: '{{hmacSHA256 .secret .request.body}}'
+body:
+  raw: '{"id": 1}'
+`
+	secrets := map[string]any{"baseUrl": "https://api.example.com", "partner": "acme", "secret": "s3cret"}
+
+	file, valid, err := FinalStructForAPIContent([]byte(content), "order.yaml", secrets, nil)
+	if err != nil || !valid {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if file.Headers["x-partner"] != "acme" {
+		t.Errorf("expected the headers to be templated, got %v", file.Headers)
+	}
+
+	if file.Sign["x-signature"] != "{{hmacSHA256 .secret .request.body}}" {
+		t.Errorf("expected the sign section to be left as it is, got %v", file.Sign)
+	}
+
+	apiInfo, err := file.PrepareStruct()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if apiInfo.Sign == nil || apiInfo.Sign.Headers["x-signature"] != file.Sign["x-signature"] {
+		t.Errorf("expected the sign headers in apiInfo, got %+v", apiInfo.Sign)
+	}
+}
+
+func TestSignHeaders_IsValid(t *testing.T) {
+	tests := []struct {
+		name        string
+		sign        SignHeaders
+		expectedErr string
+	}{
+		{name: "missing sign"},
+		{name: "header with template", sign: SignHeaders{"x-signature": "{{sha256 .request.body}}"}},
+		{name: "empty template", sign: SignHeaders{"x-signature": ""}, expectedErr: "header 'x-signature' needs a template"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			valid, err := tc.sign.IsValid()
+			if tc.expectedErr == "" {
+				if !valid || err != nil {
+					t.Errorf("expected valid sign, got %v", err)
+				}
+
+				return
+			}
+
+			if valid || err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+				t.Errorf("expected error with %q, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package yamlparser
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// urlParamsKey is the (lowercased) key for url params in api and auth files
+const urlParamsKey = "urlparams"
+
+// ArrayFormat decides how list values in urlparams are encoded
+type ArrayFormat string
+
+// Supported array formats for urlparams
+const (
+	// ArrayRepeat repeats the key for each value: ids=1&ids=2
+	ArrayRepeat ArrayFormat = "repeat"
+	// ArrayComma joins the values with comma: ids=1,2
+	ArrayComma ArrayFormat = "comma"
+	// ArrayBrackets repeats the key with brackets: ids[]=1&ids[]=2
+	ArrayBrackets ArrayFormat = "brackets"
+)
+
+// IsValid checks whether the array format is supported. Empty means ArrayRepeat
+func (format ArrayFormat) IsValid() bool {
+	switch ArrayFormat(strings.ToLower(string(format))) {
+	case "", ArrayRepeat, ArrayComma, ArrayBrackets:
+		return true
+	}
+
+	return false
+}
+
+// URLParam is a single url parameter. IsArray is true when the yaml value is a list
+type URLParam struct {
+	Key     string
+	Values  []string
+	IsArray bool
+}
+
+// URLParams are url parameters in the order they are declared in the yaml file.
+// Values could be a scalar or a list of scalars
+//
+//	urlparams:
+//	  page: 2
+//	  ids: [1, 2, 3]
+type URLParams []URLParam
+
+// UnmarshalYAML decodes urlparams while preserving the declaration order
+func (params *URLParams) UnmarshalYAML(unmarshal func(any) error) error {
+	var raw yaml.MapSlice
+	if err := unmarshal(&raw); err != nil {
+		return fmt.Errorf("urlparams must be key value pairs: %w", err)
+	}
+
+	result := make(URLParams, 0, len(raw))
+
+	for _, item := range raw {
+		param := URLParam{Key: fmt.Sprint(item.Key)}
+
+		switch val := item.Value.(type) {
+		case nil:
+			param.Values = []string{""}
+		case []any:
+			param.IsArray = true
+
+			for _, each := range val {
+				str, err := scalarToString(param.Key, each)
+				if err != nil {
+					return err
+				}
+
+				param.Values = append(param.Values, str)
+			}
+		default:
+			str, err := scalarToString(param.Key, val)
+			if err != nil {
+				return err
+			}
+
+			param.Values = []string{str}
+		}
+
+		result = append(result, param)
+	}
+
+	*params = result
+
+	return nil
+}
+
+// scalarToString converts url param value to string. Nested maps and lists are not allowed
+func scalarToString(key string, val any) (string, error) {
+	switch val.(type) {
+	case nil:
+		return "", nil
+	case map[string]any, yaml.MapSlice, []any:
+		return "", fmt.Errorf("invalid value for urlparam '%s': nested values are not supported", key)
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
+
+// URLParamsFromMap converts map to URLParams. Since map has no order, keys are sorted
+func URLParamsFromMap(paramsMap map[string]string) URLParams {
+	keys := make([]string, 0, len(paramsMap))
+	for key := range paramsMap {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	params := make(URLParams, 0, len(keys))
+	for _, key := range keys {
+		params = append(params, URLParam{Key: key, Values: []string{paramsMap[key]}})
+	}
+
+	return params
+}
+
+// Encode returns the url encoded query string in the declared order.
+// List values are encoded based on the format, ArrayRepeat by default
+func (params URLParams) Encode(format ArrayFormat) string {
+	var parts []string
+
+	format = ArrayFormat(strings.ToLower(string(format)))
+
+	for _, param := range params {
+		if param.Key == "" {
+			continue
+		}
+
+		key := url.QueryEscape(param.Key)
+
+		switch {
+		case len(param.Values) == 0:
+			parts = append(parts, key+"=")
+		case format == ArrayComma:
+			escaped := make([]string, 0, len(param.Values))
+			for _, val := range param.Values {
+				escaped = append(escaped, url.QueryEscape(val))
+			}
+
+			parts = append(parts, key+"="+strings.Join(escaped, ","))
+		default:
+			if format == ArrayBrackets && param.IsArray {
+				key = url.QueryEscape(param.Key + "[]")
+			}
+
+			for _, val := range param.Values {
+				parts = append(parts, key+"="+url.QueryEscape(val))
+			}
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+// urlParamsOrder returns the urlparams keys in the order they are declared in the raw yaml.
+// Decoding into map[string]any loses the order, so the raw yaml is decoded separately
+func urlParamsOrder(raw []byte) []string {
+	var doc yaml.MapSlice
+	if err := yaml.UnmarshalWithOptions(raw, &doc, yaml.UseOrderedMap()); err != nil {
+		return nil
+	}
+
+	for _, item := range doc {
+		key, ok := item.Key.(string)
+		if !ok || !strings.EqualFold(key, urlParamsKey) {
+			continue
+		}
+
+		params, ok := item.Value.(yaml.MapSlice)
+		if !ok {
+			return nil
+		}
+
+		keys := make([]string, 0, len(params))
+		for _, param := range params {
+			keys = append(keys, fmt.Sprint(param.Key))
+		}
+
+		return keys
+	}
+
+	return nil
+}
+
+// orderURLParams replaces the urlparams map in parsedMap with yaml.MapSlice in the provided order,
+// so that the declaration order survives encoding the map back to yaml.
+// Keys missing from the order are appended in sorted order
+func orderURLParams(parsedMap map[string]any, order []string) map[string]any {
+	params, ok := parsedMap[urlParamsKey].(map[string]any)
+	if !ok || len(params) == 0 {
+		return parsedMap
+	}
+
+	ordered := make(yaml.MapSlice, 0, len(params))
+	seen := make(map[string]bool, len(params))
+
+	for _, key := range order {
+		if val, exists := params[key]; exists && !seen[key] {
+			ordered = append(ordered, yaml.MapItem{Key: key, Value: val})
+			seen[key] = true
+		}
+	}
+
+	var rest []string
+
+	for key := range params {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+
+	slices.Sort(rest)
+
+	for _, key := range rest {
+		ordered = append(ordered, yaml.MapItem{Key: key, Value: params[key]})
+	}
+
+	parsedMap[urlParamsKey] = ordered
+
+	return parsedMap
+}
@@ -0,0 +1,130 @@
+package yamlparser
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/goccy/go-yaml"
+)
+
+func TestURLParamsUnmarshalYAML(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		expected  URLParams
+		expectErr bool
+	}{
+		{
+			name: "scalars keep declaration order",
+			content: `
+urlparams:
+  zeta: last
+  alpha: 1
+  flag: true
+`,
+			expected: URLParams{
+				{Key: "zeta", Values: []string{"last"}},
+				{Key: "alpha", Values: []string{"1"}},
+				{Key: "flag", Values: []string{"true"}},
+			},
+		},
+		{
+			name: "list values",
+			content: `
+urlparams:
+  ids: [1, 2, 3]
+  empty:
+`,
+			expected: URLParams{
+				{Key: "ids", Values: []string{"1", "2", "3"}, IsArray: true},
+				{Key: "empty", Values: []string{""}},
+			},
+		},
+		{
+			name: "nested map is not allowed",
+			content: `
+urlparams:
+  filter:
+    name: foo
+`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var file struct {
+				URLParams URLParams `yaml:"urlparams"`
+			}
+
+			err := yaml.Unmarshal([]byte(tc.content), &file)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(file.URLParams, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, file.URLParams)
+			}
+		})
+	}
+}
+
+func TestArrayFormatIsValid(t *testing.T) {
+	for _, format := range []ArrayFormat{"", ArrayRepeat, ArrayComma, ArrayBrackets, "Comma"} {
+		if !format.IsValid() {
+			t.Errorf("expected '%s' to be valid", format)
+		}
+	}
+
+	if ArrayFormat("pipes").IsValid() {
+		t.Errorf("expected 'pipes' to be invalid")
+	}
+}
+
+func TestFinalStructForAPIURLParams(t *testing.T) {
+	content := `
+method: GET
+url: https://api.example.com/users?active=true
+urlparams_format: comma
+urlparams:
+  pageSize: "{{.size}}"
+  ids:
+    - "{{.firstId}}"
+    - 7
+  after: abc
+`
+	secretsMap := map[string]any{"size": 20, "firstId": "5"}
+
+	filePath, err := createTempYamlFile(content)
+	if err != nil {
+		t.Fatalf("Failed to create temp file: %v", err)
+	}
+	defer os.Remove(filePath)
+
+	apiFile, _, err := FinalStructForAPI(filePath, secretsMap, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := URLParams{
+		{Key: "pageSize", Values: []string{"20"}},
+		{Key: "ids", Values: []string{"5", "7"}, IsArray: true},
+		{Key: "after", Values: []string{"abc"}},
+	}
+	if !reflect.DeepEqual(apiFile.URLParams, expected) {
+		t.Errorf("expected %v, got %v", expected, apiFile.URLParams)
+	}
+
+	if got := apiFile.URLParams.Encode(apiFile.URLParamsFormat); got != "pageSize=20&ids=5,7&after=abc" {
+		t.Errorf("unexpected encoding %s", got)
+	}
+}
@@ -0,0 +1,313 @@
+package yamlparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xaaha/hulak/pkg/envparser"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// default name of the loop variable in a step with foreach
+const defaultLoopVar = "item"
+
+// characters that are not safe in the response file name of a step
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// WorkflowStep is a single request in the workflow.
+// Either file, the path of an existing api file, or an inline request is required
+//
+//	steps:
+//	  - name: fetch_user
+//	    if: '{{eq .steps.create_user.status 201}}'
+//	    request:
+//	      method: GET
+//	      url: "{{.baseUrl}}/users/{{.userId}}"
+//	    capture:
+//	      - name: userName
+//	        path: name
+//	    assert:
+//	      status: 200
+type WorkflowStep struct {
+	Name            string `json:"name"                        yaml:"name"`
+	File            string `json:"file,omitempty"              yaml:"file"`
+	Request         any    `json:"request,omitempty"           yaml:"request"`
+	If              string `json:"if,omitempty"                yaml:"if"`
+	Foreach         any    `json:"foreach,omitempty"           yaml:"foreach"`
+	As              string `json:"as,omitempty"                yaml:"as"`
+	Capture         any    `json:"capture,omitempty"           yaml:"capture"`
+	Assert          any    `json:"assert,omitempty"            yaml:"assert"`
+	ContinueOnError bool   `json:"continue_on_error,omitempty" yaml:"continue_on_error"`
+	// absolute path of the file, set when the workflow is validated
+	filePath string
+}
+
+// WorkflowFile represents the yaml file with 'kind: Workflow'.
+// Steps run one after the other, and the values captured by a step are available to the steps after it
+type WorkflowFile struct {
+	Kind  Kind           `json:"kind"           yaml:"kind"`
+	Name  string         `json:"name,omitempty" yaml:"name"`
+	Steps []WorkflowStep `json:"steps"          yaml:"steps"`
+}
+
+// IsValid checks the workflow has steps, and each step has a unique name and exactly one of file or request.
+// Steps without name are named step1, step2 and so on
+func (w *WorkflowFile) IsValid(filePath string) (bool, error) {
+	if len(w.Steps) == 0 {
+		return false, fmt.Errorf("workflow '%s' has no steps", filePath)
+	}
+
+	names := make(map[string]bool)
+
+	for i := range w.Steps {
+		step := &w.Steps[i]
+
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+
+		if names[step.Name] {
+			return false, fmt.Errorf("step name '%s' is repeated in '%s'", step.Name, filePath)
+		}
+
+		names[step.Name] = true
+
+		if valid, err := step.isValid(filePath); !valid {
+			return false, fmt.Errorf("invalid step '%s' in '%s': %w", step.Name, filePath, err)
+		}
+	}
+
+	return true, nil
+}
+
+func (s *WorkflowStep) isValid(workflowPath string) (bool, error) {
+	if (s.File == "") == (s.Request == nil) {
+		return false, fmt.Errorf("provide either file or request")
+	}
+
+	if s.File != "" {
+		resolved, ok := utils.ResolveRelativeFile(workflowPath, s.File)
+		if !ok {
+			return false, fmt.Errorf("file '%s' does not exist", s.File)
+		}
+
+		s.filePath = resolved
+	}
+
+	if s.Request != nil {
+		if _, ok := s.Request.(yaml.MapSlice); !ok {
+			return false, fmt.Errorf("request should have the same keys as an api file")
+		}
+	}
+
+	switch foreach := s.Foreach.(type) {
+	case nil, []any:
+	case string:
+		if !captureNamePattern.MatchString(foreach) {
+			return false, fmt.Errorf("foreach should be a list or the name of a variable with a list")
+		}
+	default:
+		return false, fmt.Errorf("foreach should be a list or the name of a variable with a list")
+	}
+
+	if s.As == "" {
+		s.As = defaultLoopVar
+	}
+
+	if !captureNamePattern.MatchString(s.As) {
+		return false, fmt.Errorf("invalid loop variable '%s'", s.As)
+	}
+
+	return true, nil
+}
+
+// HasLoop is true when the step runs for each item in foreach
+func (s *WorkflowStep) HasLoop() bool {
+	return s.Foreach != nil
+}
+
+// LoopItems returns the items the step loops over. Foreach is either a list
+// or the name of a variable that has a list, like the JSON array captured from a previous step
+func (s *WorkflowStep) LoopItems(vars map[string]any) ([]any, error) {
+	switch foreach := s.Foreach.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return TemplateValue(foreach).([]any), nil
+	case string:
+		val, ok := vars[foreach]
+		if !ok {
+			return nil, fmt.Errorf("foreach variable '%s' not found", foreach)
+		}
+
+		switch typed := val.(type) {
+		case []any:
+			return TemplateValue(typed).([]any), nil
+		case string:
+			var items []any
+			if err := json.Unmarshal([]byte(typed), &items); err != nil {
+				return nil, fmt.Errorf("foreach variable '%s' is not a list", foreach)
+			}
+
+			return TemplateValue(items).([]any), nil
+		}
+
+		return nil, fmt.Errorf("foreach variable '%s' is not a list", foreach)
+	default:
+		return nil, fmt.Errorf("invalid foreach '%v'", foreach)
+	}
+}
+
+// ResponsePath returns the path the response of the step is saved next to.
+// File steps use the file's own path, and inline requests use workflowName_stepName
+func (s *WorkflowStep) ResponsePath(workflowPath string) string {
+	if s.filePath != "" {
+		return s.filePath
+	}
+
+	stepName := unsafeFileChars.ReplaceAllString(s.Name, "_")
+
+	return filepath.Join(
+		filepath.Dir(workflowPath),
+		utils.FileNameWithoutExtension(workflowPath)+"_"+stepName+utils.YAML,
+	)
+}
+
+// stepChecks are the capture and assert sections of a step
+type stepChecks struct {
+	Capture Captures `yaml:"capture"`
+	Assert  *Assert  `yaml:"assert"`
+}
+
+// APICallFile builds the api call for the step with the vars available when the step runs.
+// Capture and assert of the step are added to the ones in the referenced file
+func (s *WorkflowStep) APICallFile(
+	workflowPath string,
+	vars map[string]any,
+	token envparser.TokenFunc,
+) (ApiCallFile, error) {
+	name := fmt.Sprintf("step '%s' of '%s'", s.Name, filepath.Base(workflowPath))
+
+	checks := yaml.MapSlice{}
+	if s.Capture != nil {
+		checks = append(checks, yaml.MapItem{Key: "capture", Value: s.Capture})
+	}
+
+	if s.Assert != nil {
+		checks = append(checks, yaml.MapItem{Key: "assert", Value: s.Assert})
+	}
+
+	if s.filePath == "" {
+		request, _ := s.Request.(yaml.MapSlice)
+
+		raw, err := yaml.Marshal(append(append(yaml.MapSlice{}, request...), checks...))
+		if err != nil {
+			return ApiCallFile{}, err
+		}
+
+		apiConfig, _, err := FinalStructForAPIContent(raw, name, vars, token)
+
+		return apiConfig, err
+	}
+
+	apiConfig, _, err := FinalStructForAPI(s.filePath, vars, token)
+	if err != nil || len(checks) == 0 {
+		return apiConfig, err
+	}
+
+	raw, err := yaml.Marshal(checks)
+	if err != nil {
+		return ApiCallFile{}, err
+	}
+
+	buf, err := parseYamlContent(raw, vars, token)
+	if err != nil {
+		return ApiCallFile{}, err
+	}
+
+	var parsed stepChecks
+	if err := yaml.NewDecoder(buf).Decode(&parsed); err != nil {
+		return ApiCallFile{}, err
+	}
+
+	apiConfig.Capture = append(append(Captures{}, apiConfig.Capture...), parsed.Capture...)
+	if valid, err := apiConfig.Capture.IsValid(); !valid {
+		return ApiCallFile{}, fmt.Errorf("invalid 'capture' in %s: %w", name, err)
+	}
+
+	apiConfig.Assert = apiConfig.Assert.Merge(parsed.Assert)
+	if valid, err := apiConfig.Assert.IsValid(); !valid {
+		return ApiCallFile{}, fmt.Errorf("invalid 'assert' in %s: %w", name, err)
+	}
+
+	return apiConfig, nil
+}
+
+// FinalStructForWorkflow reads the workflow file. Values in the steps are templated when each step runs,
+// since they could use the values captured by the steps before them
+func FinalStructForWorkflow(filePath string) (WorkflowFile, error) {
+	// inline requests keep the order of their keys, like the order of urlparams
+	var data map[string]any
+	if err := yaml.UnmarshalWithOptions(readYamlFile(filePath), &data, yaml.UseOrderedMap()); err != nil {
+		return WorkflowFile{}, utils.ColorError("error decoding workflow", err)
+	}
+
+	// items of foreach are the values of the loop variable, used as they are
+	raw, err := yaml.Marshal(utils.ConvertKeysToLowerCase(data, "foreach"))
+	if err != nil {
+		return WorkflowFile{}, err
+	}
+
+	var workflow WorkflowFile
+	if err := yaml.UnmarshalWithOptions(raw, &workflow, yaml.UseOrderedMap()); err != nil {
+		return WorkflowFile{}, utils.ColorError("error decoding workflow", err)
+	}
+
+	if valid, err := workflow.IsValid(filePath); !valid {
+		return WorkflowFile{}, err
+	}
+
+	return workflow, nil
+}
+
+// TemplateValue converts ordered maps to map[string]any, and whole numbers to int,
+// so that the value can be used in templates like {{.item.id}}
+func TemplateValue(val any) any {
+	switch typed := val.(type) {
+	case yaml.MapSlice:
+		plain := make(map[string]any, len(typed))
+		for _, item := range typed {
+			plain[fmt.Sprint(item.Key)] = TemplateValue(item.Value)
+		}
+
+		return plain
+	case map[string]any:
+		plain := make(map[string]any, len(typed))
+		for key, each := range typed {
+			plain[key] = TemplateValue(each)
+		}
+
+		return plain
+	case []any:
+		list := make([]any, 0, len(typed))
+		for _, each := range typed {
+			list = append(list, TemplateValue(each))
+		}
+
+		return list
+	case float64:
+		if typed == math.Trunc(typed) && math.Abs(typed) <= math.MaxInt32 {
+			return int(typed)
+		}
+
+		return typed
+	case uint64:
+		return int(typed)
+	default:
+		return val
+	}
+}
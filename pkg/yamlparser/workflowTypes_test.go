@@ -0,0 +1,226 @@
+package yamlparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeWorkflow(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	apiFile := "method: GET\nurl: \"{{.baseUrl}}/users\"\nassert:\n  status: 200\n"
+	if err := os.WriteFile(filepath.Join(dir, "get_users.yaml"), []byte(apiFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "flow.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestFinalStructForWorkflow(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		expectErr   string
+		expectNames []string
+	}{
+		{
+			name: "inline request and file",
+			content: `kind: Workflow
+Steps:
+  - Name: create
+    Request:
+      Method: POST
+      URL: "{{.baseUrl}}/users"
+  - file: get_users.yaml
+    if: '{{eq .prev.status 201}}'
+`,
+			expectNames: []string{"create", "step2"},
+		},
+		{
+			name:      "no steps",
+			content:   "kind: Workflow\n",
+			expectErr: "has no steps",
+		},
+		{
+			name:      "file and request",
+			content:   "kind: Workflow\nsteps:\n  - file: get_users.yaml\n    request:\n      method: GET\n",
+			expectErr: "provide either file or request",
+		},
+		{
+			name:      "missing file",
+			content:   "kind: Workflow\nsteps:\n  - file: missing.yaml\n",
+			expectErr: "does not exist",
+		},
+		{
+			name:      "repeated name",
+			content:   "kind: Workflow\nsteps:\n  - name: a\n    file: get_users.yaml\n  - name: a\n    file: get_users.yaml\n",
+			expectErr: "repeated",
+		},
+		{
+			name:      "invalid foreach",
+			content:   "kind: Workflow\nsteps:\n  - file: get_users.yaml\n    foreach:\n      id: 1\n",
+			expectErr: "foreach should be a list",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			workflow, err := FinalStructForWorkflow(writeWorkflow(t, tc.content))
+
+			if tc.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+					t.Fatalf("expected error with '%s', got %v", tc.expectErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, step := range workflow.Steps {
+				names = append(names, step.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expectNames) {
+				t.Errorf("expected steps %v, got %v", tc.expectNames, names)
+			}
+		})
+	}
+}
+
+func TestWorkflowStepAPICallFile(t *testing.T) {
+	path := writeWorkflow(t, `kind: Workflow
+steps:
+  - name: create
+    request:
+      method: POST
+      url: "{{.baseUrl}}/users"
+      urlparams:
+        userId: "{{.userId}}"
+    capture:
+      - name: newId
+        path: id
+  - name: fetch
+    file: get_users.yaml
+    assert:
+      status: 201
+`)
+
+	workflow, err := FinalStructForWorkflow(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	vars := map[string]any{"baseUrl": "https://example.com", "userId": 7}
+
+	create, err := workflow.Steps[0].APICallFile(path, vars, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if create.URL != "https://example.com/users" || create.Method != POST {
+		t.Errorf("unexpected request %s %s", create.Method, create.URL)
+	}
+
+	if len(create.URLParams) != 1 || create.URLParams[0].Key != "userId" ||
+		!reflect.DeepEqual(create.URLParams[0].Values, []string{"7"}) {
+		t.Errorf("expected urlparams to keep the case of the keys, got %v", create.URLParams)
+	}
+
+	if len(create.Capture) != 1 || create.Capture[0].Name != "newId" {
+		t.Errorf("expected capture of the step, got %v", create.Capture)
+	}
+
+	fetch, err := workflow.Steps[1].APICallFile(path, vars, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual([]string(fetch.Assert.Status), []string{"201"}) {
+		t.Errorf("expected the step's assert to override the file's, got %v", fetch.Assert.Status)
+	}
+
+	expectedPath := filepath.Join(filepath.Dir(path), "flow_create.yaml")
+	if got := workflow.Steps[0].ResponsePath(path); got != expectedPath {
+		t.Errorf("expected response path %s, got %s", expectedPath, got)
+	}
+
+	if got := workflow.Steps[1].ResponsePath(path); filepath.Base(got) != "get_users.yaml" {
+		t.Errorf("expected the file's own path, got %s", got)
+	}
+}
+
+func TestWorkflowStepLoopItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		foreach   any
+		vars      map[string]any
+		expected  []any
+		expectErr bool
+	}{
+		{
+			name:     "inline list",
+			foreach:  []any{"a", "b"},
+			expected: []any{"a", "b"},
+		},
+		{
+			name:     "captured array",
+			foreach:  "ids",
+			vars:     map[string]any{"ids": []any{float64(1), float64(2.5)}},
+			expected: []any{1, 2.5},
+		},
+		{
+			name:     "JSON array from env",
+			foreach:  "users",
+			vars:     map[string]any{"users": `[{"id": 3}]`},
+			expected: []any{map[string]any{"id": 3}},
+		},
+		{
+			name:      "missing variable",
+			foreach:   "ids",
+			expectErr: true,
+		},
+		{
+			name:      "not a list",
+			foreach:   "ids",
+			vars:      map[string]any{"ids": 4},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			step := WorkflowStep{Foreach: tc.foreach}
+
+			items, err := step.LoopItems(tc.vars)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(items, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, items)
+			}
+		})
+	}
+}
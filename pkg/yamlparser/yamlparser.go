@@ -3,6 +3,7 @@ package yamlparser
 
 import (
 	"bytes"
+	"io"
 	"os"
 
 	"github.com/goccy/go-yaml"
@@ -20,15 +21,16 @@ import (
 func replaceVarsWithValues(
 	dict map[string]any,
 	secretsMap map[string]any,
+	token envparser.TokenFunc,
 ) map[string]any {
 	changedMap := make(map[string]any)
 
 	for key, val := range dict {
 		switch valTyped := val.(type) {
 		case map[string]any:
-			changedMap[key] = replaceVarsWithValues(valTyped, secretsMap)
+			changedMap[key] = replaceVarsWithValues(valTyped, secretsMap, token)
 		case string:
-			finalChangedValue, err := envparser.SubstituteVariables(valTyped, secretsMap)
+			finalChangedValue, err := envparser.SubstituteVariables(valTyped, secretsMap, token)
 			if err != nil {
 				utils.PrintRed(err.Error())
 			}
@@ -42,7 +44,7 @@ func replaceVarsWithValues(
 			innerMap := make(map[string]any)
 
 			for k, v := range valTyped {
-				finalChangedValue, err := envparser.SubstituteVariables(v, secretsMap)
+				finalChangedValue, err := envparser.SubstituteVariables(v, secretsMap, token)
 				if err != nil {
 					utils.PrintRed(err.Error())
 				}
@@ -55,6 +57,8 @@ func replaceVarsWithValues(
 			}
 
 			changedMap[key] = innerMap
+		case []any:
+			changedMap[key] = replaceVarsInSlice(valTyped, secretsMap, token)
 		default:
 			changedMap[key] = val
 		}
@@ -63,8 +67,31 @@ func replaceVarsWithValues(
 	return changedMap
 }
 
-// Reads YAML, validates if the file exists, is not empty, and changes keys to lowercase
-func checkYamlFile(filepath string, secretsMap map[string]any) (*bytes.Buffer, error) {
+// replaceVarsInSlice replaces variables in each item of the list, like urlparams with list values
+func replaceVarsInSlice(list []any, secretsMap map[string]any, token envparser.TokenFunc) []any {
+	changedList := make([]any, 0, len(list))
+
+	for _, item := range list {
+		switch itemTyped := item.(type) {
+		case map[string]any:
+			changedList = append(changedList, replaceVarsWithValues(itemTyped, secretsMap, token))
+		case string:
+			finalChangedValue, err := envparser.SubstituteVariables(itemTyped, secretsMap, token)
+			if err != nil {
+				utils.PrintRed(err.Error())
+			}
+
+			changedList = append(changedList, finalChangedValue)
+		default:
+			changedList = append(changedList, item)
+		}
+	}
+
+	return changedList
+}
+
+// readYamlFile reads the file and exits if the file does not exist or is empty
+func readYamlFile(filepath string) []byte {
 	if _, err := os.Stat(filepath); os.IsNotExist(err) {
 		utils.PanicRedAndExit("File does not exist, %s", filepath)
 	}
@@ -80,25 +107,58 @@ func checkYamlFile(filepath string, secretsMap map[string]any) (*bytes.Buffer, e
 		utils.PanicRedAndExit("Empty yaml file")
 	}
 
+	raw, err := io.ReadAll(file)
+	if err != nil {
+		utils.PanicRedAndExit("Error reading file: %v", err)
+	}
+
+	return raw
+}
+
+// Reads YAML, validates if the file exists, is not empty, and changes keys to lowercase
+func checkYamlFile(
+	filepath string,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (*bytes.Buffer, error) {
+	return parseYamlContent(readYamlFile(filepath), secretsMap, token)
+}
+
+// parseYamlContent changes keys to lowercase, replaces the variables and translates the types of the yaml content
+func parseYamlContent(
+	raw []byte,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (*bytes.Buffer, error) {
 	var data map[string]any
 
-	dec := yaml.NewDecoder(file)
-	if err = dec.Decode(&data); err != nil {
+	if err := yaml.Unmarshal(raw, &data); err != nil {
 		utils.PanicRedAndExit("1. error decoding data: %v", err)
 	}
 
 	// make yaml keys  case insensitive. method or Method or METHOD should all be the same
 	data = utils.ConvertKeysToLowerCase(data)
 
+	// sign is templated over the final request, right before it's sent
+	sign, hasSign := data[signKey]
+	delete(data, signKey)
+
 	// parse all the values to with {{.key}} from .env folder
-	parsedMap := replaceVarsWithValues(data, secretsMap)
+	parsedMap := replaceVarsWithValues(data, secretsMap, token)
 
 	// translate the types, if acceptable
-	parsedMap, err = translateType(data, parsedMap, secretsMap, actions.GetValueOf)
+	parsedMap, err := translateType(data, parsedMap, secretsMap, actions.GetValueOf)
 	if err != nil {
 		return nil, utils.ColorError("#reader", err)
 	}
 
+	if hasSign {
+		parsedMap[signKey] = sign
+	}
+
+	// keep urlparams in the order user declared them
+	parsedMap = orderURLParams(parsedMap, urlParamsOrder(raw))
+
 	var buf bytes.Buffer
 
 	enc := yaml.NewEncoder(&buf)
@@ -114,8 +174,23 @@ func checkYamlFile(filepath string, secretsMap map[string]any) (*bytes.Buffer, e
 // FinalStructForAPI builds a final struct for the api call.
 // Returns ApiCallFile struct, true if file is valid, and error
 // It  checks the validity of all the fields in the yaml file meant for regular api call
-func FinalStructForAPI(filePath string, secretsMap map[string]any) (ApiCallFile, bool, error) {
-	buf, err := checkYamlFile(filePath, secretsMap)
+func FinalStructForAPI(
+	filePath string,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (ApiCallFile, bool, error) {
+	return FinalStructForAPIContent(readYamlFile(filePath), filePath, secretsMap, token)
+}
+
+// FinalStructForAPIContent builds the final struct for the api call from yaml content, like a request inside a workflow.
+// Name is used in the error messages in place of the file path
+func FinalStructForAPIContent(
+	raw []byte,
+	name string,
+	secretsMap map[string]any,
+	token envparser.TokenFunc,
+) (ApiCallFile, bool, error) {
+	buf, err := parseYamlContent(raw, secretsMap, token)
 	if err != nil {
 		return ApiCallFile{}, false, err
 	}
@@ -127,7 +202,7 @@ func FinalStructForAPI(filePath string, secretsMap map[string]any) (ApiCallFile,
 		return ApiCallFile{}, false, err
 	}
 
-	if valid, err := file.IsValid(filePath); !valid {
+	if valid, err := file.IsValid(name); !valid {
 		return ApiCallFile{}, false, err
 	}
 
@@ -139,8 +214,9 @@ func FinalStructForAPI(filePath string, secretsMap map[string]any) (ApiCallFile,
 func FinalStructForOAuth2(
 	filePath string,
 	secretsMap map[string]any,
+	token envparser.TokenFunc,
 ) (AuthRequestFile, error) {
-	buf, err := checkYamlFile(filePath, secretsMap)
+	buf, err := checkYamlFile(filePath, secretsMap, token)
 	if err != nil {
 		return AuthRequestFile{}, utils.ColorError("Error after reading yaml file: %v", err)
 	}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xaaha/hulak/pkg/report"
+	"github.com/xaaha/hulak/pkg/utils"
+)
+
+// longer error messages are cut in the summary, since the full error is printed when the file fails
+const maxSummaryErrLen = 80
+
+// outcome of a file in the run summary
+const (
+	filePassed  = "passed"
+	fileFailed  = "failed"
+	fileSkipped = "skipped"
+)
+
+func (r taskResult) outcome() string {
+	switch {
+	case r.skipped:
+		return fileSkipped
+	case r.err != nil:
+		return fileFailed
+	default:
+		return filePassed
+	}
+}
+
+// countFailed returns the number of files that failed, and the number of files that were skipped
+func countFailed(results []taskResult) (int, int) {
+	failed, skipped := 0, 0
+
+	for _, result := range results {
+		switch result.outcome() {
+		case fileFailed:
+			failed++
+		case fileSkipped:
+			skipped++
+		}
+	}
+
+	return failed, skipped
+}
+
+// printRunSummary prints the result of each file in the run as a table
+func printRunSummary(out io.Writer, results []taskResult) {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.outcome()]++
+	}
+
+	fmt.Fprintf(out, "\nSummary: %d passed, %d failed, %d skipped, %d total\n",
+		counts[filePassed], counts[fileFailed], counts[fileSkipped], len(results))
+
+	w := tabwriter.NewWriter(out, 0, 0, 4, ' ', 0)
+	fmt.Fprintln(w, "FILE\tRESULT\tSTATUS\tDURATION\tRETRIES\tERROR")
+
+	for _, result := range results {
+		mark := utils.CheckMark
+		switch result.outcome() {
+		case fileFailed:
+			mark = utils.CrossMark
+		case fileSkipped:
+			mark = "-"
+		}
+
+		status := "-"
+		if result.status != 0 {
+			status = fmt.Sprint(result.status)
+		}
+
+		duration := "-"
+		if !result.skipped {
+			duration = result.duration.Round(time.Millisecond).String()
+		}
+
+		fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%d\t%s\n",
+			mark,
+			displayPath(result.path),
+			result.outcome(),
+			status,
+			duration,
+			result.retries,
+			summaryError(result.err),
+		)
+	}
+
+	w.Flush()
+}
+
+// reportResults converts the results of the run for the report
+func reportResults(results []taskResult) []report.FileResult {
+	fileResults := make([]report.FileResult, 0, len(results))
+
+	for _, result := range results {
+		fileResults = append(fileResults, report.FileResult{
+			Path:      displayPath(result.path),
+			Status:    result.status,
+			Duration:  result.duration,
+			Retries:   result.retries,
+			Skipped:   result.skipped,
+			Err:       result.err,
+			Responses: result.responses,
+		})
+	}
+
+	return fileResults
+}
+
+// displayPath returns the path relative to the current directory, when possible
+func displayPath(path string) string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+
+	return rel
+}
+
+// summaryError returns the error in a single line without colors, cut to maxSummaryErrLen
+func summaryError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	msg := strings.TrimSpace(utils.StripColors(err.Error()))
+	msg = strings.Join(strings.Fields(msg), " ")
+
+	if len([]rune(msg)) > maxSummaryErrLen {
+		msg = string([]rune(msg)[:maxSummaryErrLen-3]) + "..."
+	}
+
+	return msg
+}